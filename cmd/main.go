@@ -9,6 +9,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
 	"github.com/hamwiwatsapon/train-booking-go/internal/infrastructure/database"
+	"github.com/hamwiwatsapon/train-booking-go/internal/infrastructure/middleware"
 	"github.com/hamwiwatsapon/train-booking-go/internal/infrastructure/repository"
 	"github.com/hamwiwatsapon/train-booking-go/internal/presentation/handlers"
 	"github.com/hamwiwatsapon/train-booking-go/internal/presentation/routes"
@@ -47,8 +48,97 @@ func main() {
 
 	// Initialize train repository, service, and handler
 	trainRepo := repository.NewTrainRepository(db)
-	trainService := services.NewTrainService(trainRepo)
-	trainHandler := handlers.NewTrainHandler(trainService)
+	trainCatalogRepo := repository.NewTrainCatalogRepository(db)
+	stationOrderRepo := repository.NewStationOrderRepository(db)
+	coachRepo := repository.NewCoachRepository(db)
+	trainService := services.NewTrainService(trainRepo, trainCatalogRepo, stationOrderRepo, coachRepo)
+	timetableService := services.NewTimetableService(stationOrderRepo)
+	calendarRepo := repository.NewServiceCalendarRepository(db)
+	calendarService := services.NewCalendarService(calendarRepo, trainCatalogRepo)
+	trainHandler := handlers.NewTrainHandler(trainService, timetableService, calendarService)
+
+	// Initialize fare service and handler
+	concessionRepo := repository.NewConcessionRepository(db)
+	fareService := services.NewFareService(trainCatalogRepo, stationOrderRepo, concessionRepo, services.NewTariffFareCalculator(services.DefaultFareTariff))
+	fareHandler := handlers.NewFareHandler(fareService)
+
+	// Initialize train run repository, seat service, and train run service and handler
+	trainRunRepo := repository.NewTrainRunRepository(db)
+	seatService := services.NewSeatService(coachRepo, trainRunRepo, stationOrderRepo, services.NewGroupSeatAllocator())
+	trainRunService := services.NewTrainRunService(trainRunRepo, stationOrderRepo, calendarService)
+	trainRunHandler := handlers.NewTrainRunHandler(trainRunService, seatService)
+
+	// Initialize waiting room for ticket sale openings
+	saleWindowRepo := repository.NewSaleWindowRepository(db)
+	waitingRoomService := services.NewWaitingRoomService(saleWindowRepo)
+	waitingRoomHandler := handlers.NewWaitingRoomHandler(waitingRoomService)
+	admission := middleware.WaitingRoomMiddleware(waitingRoomService)
+
+	// Initialize idempotency keys for requests clients may resend
+	idempotencyService := services.NewIdempotencyService(services.DefaultIdempotencyTTL)
+	idempotent := middleware.IdempotencyMiddleware(idempotencyService)
+
+	// Initialize passenger repository, service, and handler
+	passengerRepo := repository.NewPassengerRepository(db)
+	passengerService := services.NewPassengerService(passengerRepo)
+	passengerHandler := handlers.NewPassengerHandler(passengerService)
+
+	// Initialize promotion repository, service, and handler
+	promotionRepo := repository.NewPromotionRepository(db)
+	promotionService := services.NewPromotionService(promotionRepo, trainCatalogRepo)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+
+	// Initialize payment and refund repositories, and refund service
+	paymentRepo := repository.NewPaymentRepository(db)
+	refundRepo := repository.NewRefundRepository(db)
+	refundService := services.NewRefundService(refundRepo, trainCatalogRepo, stationOrderRepo, paymentRepo)
+
+	// Initialize booking repository, service, and handler
+	bookingRepo := repository.NewBookingRepository(db)
+	seatInventory := services.NewSeatInventory(bookingRepo, trainRunRepo)
+	seatInventory.StartWriteBehind(services.DefaultInventoryFlushInterval)
+	seatInventory.StartReconciler(services.DefaultInventoryReconcileInterval)
+	bookingService := services.NewBookingService(bookingRepo, calendarService, fareService, seatService, seatInventory, passengerService, promotionService, refundService)
+	bookingHandler := handlers.NewBookingHandler(bookingService)
+	bookingService.StartHoldSweeper(services.DefaultHoldSweepInterval)
+	trainRunService.StartGenerator(services.DefaultBookingWindowDays, services.DefaultRunGenerationInterval)
+
+	// Initialize payment providers, service, and handler
+	var paymentProviders []services.PaymentProvider
+	if proxyID := os.Getenv("PROMPTPAY_ID"); proxyID != "" {
+		promptPay, err := services.NewPromptPayProvider(services.PromptPayConfig{
+			ProxyID:       proxyID,
+			WebhookSecret: os.Getenv("PROMPTPAY_WEBHOOK_SECRET"),
+		})
+		if err != nil {
+			log.Fatalf("Invalid PromptPay configuration: %v", err)
+		}
+		paymentProviders = append(paymentProviders, promptPay)
+	}
+	if os.Getenv("PAYMENT_FAKE_PROVIDER") == "true" {
+		log.Println("Fake payment provider enabled, payments succeed without being paid")
+		fake, err := services.NewFakePaymentProvider(os.Getenv("PAYMENT_FAKE_WEBHOOK_SECRET"))
+		if err != nil {
+			log.Fatalf("Invalid fake payment provider configuration: %v", err)
+		}
+		paymentProviders = append(paymentProviders, fake)
+	}
+	paymentService := services.NewPaymentService(paymentRepo, bookingService, refundService, paymentProviders...)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+
+	// Initialize refund worker and handler
+	refundWorker := services.NewRefundWorker(refundRepo, paymentRepo, bookingService, paymentService)
+	refundWorker.Start(services.DefaultRefundWorkerInterval)
+	refundHandler := handlers.NewRefundHandler(refundService, refundWorker)
+
+	// Initialize reconciliation repository, service, and handler
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	reconciliationService := services.NewReconciliationService(reconciliationRepo, paymentRepo)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService)
+
+	// Initialize journey service and handler
+	journeyService := services.NewJourneyService(stationOrderRepo, trainRunService, fareService)
+	journeyHandler := handlers.NewJourneyHandler(journeyService)
 
 	// Add enhanced logging middleware
 	app.Use(func(c *fiber.Ctx) error {
@@ -65,6 +155,17 @@ func main() {
 	routes.SetupAuthRoutes(v1, authHandler)
 	routes.SetupProfileRoutes(v1, authHandler)
 	routes.SetupStationRoutes(v1, trainHandler)
+	routes.SetupTrainRoutes(v1, trainHandler)
+	routes.SetupPassengerRoutes(v1, passengerHandler)
+	routes.SetupPromotionRoutes(v1, promotionHandler)
+	routes.SetupRefundRoutes(v1, refundHandler)
+	routes.SetupBookingRoutes(v1, bookingHandler, admission, idempotent)
+	routes.SetupPaymentRoutes(v1, paymentHandler, idempotent)
+	routes.SetupReconciliationRoutes(v1, reconciliationHandler)
+	routes.SetupWaitingRoomRoutes(v1, waitingRoomHandler)
+	routes.SetupTrainRunRoutes(v1, trainRunHandler)
+	routes.SetupFareRoutes(v1, fareHandler)
+	routes.SetupJourneyRoutes(v1, journeyHandler)
 
 	// Start the server
 	app.Listen(":4444")
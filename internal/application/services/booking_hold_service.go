@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+const (
+	// DefaultHoldDuration is how long held seats wait for the customer to confirm.
+	DefaultHoldDuration = 10 * time.Minute
+	// DefaultHoldSweepInterval is how often expired holds are returned to the inventory.
+	DefaultHoldSweepInterval = time.Minute
+	// holdSweepBatch limits how many holds one sweep releases.
+	holdSweepBatch = 100
+)
+
+// holdKey is the Redis key that keeps a hold alive. It expires together with the hold.
+func holdKey(reference string) string {
+	return fmt.Sprintf("booking_hold:%s", reference)
+}
+
+// HoldSeats reserves the seats of the request for DefaultHoldDuration. Held seats are taken
+// out of the inventory like booked ones, so nobody else can pick them, and become a booking
+// only once paid, or through ConfirmHold when there is nothing to pay.
+func (s *BookingService) HoldSeats(userID uint, req BookingRequest) (entities.Booking, error) {
+	expiresAt := time.Now().Add(DefaultHoldDuration)
+	booking, err := s.book(userID, req, entities.BookingStatusHeld, &expiresAt)
+	if err != nil {
+		return entities.Booking{}, err
+	}
+
+	if err := redisClient.Set(ctx, holdKey(booking.Reference), booking.ID, DefaultHoldDuration).Err(); err != nil {
+		// Without the key the hold could never be confirmed, so give the seats back now
+		if _, releaseErr := s.TransitionBooking(booking.ID, entities.BookingStatusExpired, nil, "seat hold could not be stored"); releaseErr != nil {
+			log.Printf("Failed to release hold %s: %v", booking.Reference, releaseErr)
+		}
+		return entities.Booking{}, fmt.Errorf("failed to store seat hold: %v", err)
+	}
+
+	return booking, nil
+}
+
+// ConfirmHold turns the user's held booking into a confirmed one while the hold lasts. Only
+// bookings with nothing to pay are confirmed this way, the others are confirmed by their
+// payment.
+func (s *BookingService) ConfirmHold(id, userID uint, role string) (entities.Booking, error) {
+	booking, err := s.GetBooking(id, userID, role)
+	if err != nil {
+		return entities.Booking{}, err
+	}
+	if booking.Status != entities.BookingStatusHeld {
+		if booking.Status == entities.BookingStatusExpired {
+			return entities.Booking{}, interfaces.ErrHoldExpired
+		}
+		return entities.Booking{}, fmt.Errorf("%w: booking %s is %s, not held", interfaces.ErrInvalidBookingTransition,
+			booking.Reference, booking.Status)
+	}
+	if booking.TotalPrice > 0 {
+		return entities.Booking{}, fmt.Errorf("%w: booking %s costs %d baht", interfaces.ErrPaymentRequired,
+			booking.Reference, booking.TotalPrice)
+	}
+
+	return s.TransitionBooking(id, entities.BookingStatusConfirmed, &userID, "")
+}
+
+// ReleaseExpiredHolds puts the seats of holds that ran out back into the inventory. A hold
+// whose Redis key is still alive is left alone, so a clock running ahead cannot cut it short.
+func (s *BookingService) ReleaseExpiredHolds() (int, error) {
+	holds, err := s.repo.GetExpiredHolds(time.Now(), holdSweepBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, hold := range holds {
+		alive, err := redisClient.Exists(ctx, holdKey(hold.Reference)).Result()
+		if err != nil {
+			return released, fmt.Errorf("failed to check seat hold: %v", err)
+		}
+		if alive > 0 {
+			continue
+		}
+
+		// Fails when the hold was confirmed or cancelled since it was fetched
+		if _, err := s.TransitionBooking(hold.ID, entities.BookingStatusExpired, nil, "seat hold expired"); err != nil {
+			log.Printf("Failed to release hold %s: %v", hold.Reference, err)
+			continue
+		}
+		released++
+	}
+	return released, nil
+}
+
+// StartHoldSweeper releases expired holds right away and then every interval.
+func (s *BookingService) StartHoldSweeper(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			released, err := s.ReleaseExpiredHolds()
+			if err != nil {
+				log.Printf("Failed to release expired holds: %v", err)
+			}
+			if released > 0 {
+				log.Printf("Released %d expired seat holds.", released)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,396 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+const (
+	// maxSeatsPerBooking limits how many seats a single booking may hold.
+	maxSeatsPerBooking = 6
+	// maxAllocationAttempts limits how often seats are allocated again when another
+	// booking takes them first.
+	maxAllocationAttempts = 3
+)
+
+// BookingRequest is what a user asks for when booking. Seats may be picked one by one,
+// otherwise SeatCount seats are sold with the given fare options. Every seat needs a
+// passenger, the n-th passenger travels on the n-th ticket.
+type BookingRequest struct {
+	TrainCode       string
+	ServiceDate     time.Time
+	FromStationCode string
+	ToStationCode   string
+	SeatCount       int
+	FareOptions     FareOptions
+	Seats           []SeatSelection
+	Passengers      []PassengerSelection
+	PromotionCode   string
+}
+
+type BookingService struct {
+	repo       interfaces.BookingRepository
+	calendar   *CalendarService
+	fares      *FareService
+	seats      *SeatService
+	inventory  *SeatInventory
+	passengers *PassengerService
+	promotions *PromotionService
+	refunds    *RefundService
+}
+
+func NewBookingService(repo interfaces.BookingRepository, calendar *CalendarService, fares *FareService, seats *SeatService, inventory *SeatInventory, passengers *PassengerService, promotions *PromotionService, refunds *RefundService) *BookingService {
+	return &BookingService{repo: repo, calendar: calendar, fares: fares, seats: seats, inventory: inventory, passengers: passengers, promotions: promotions, refunds: refunds}
+}
+
+// CreateBooking holds the seats of the request until the booking is paid through the
+// PaymentService. A booking with nothing to pay is confirmed right away.
+func (s *BookingService) CreateBooking(userID uint, req BookingRequest) (entities.Booking, error) {
+	booking, err := s.HoldSeats(userID, req)
+	if err != nil || booking.TotalPrice > 0 {
+		return booking, err
+	}
+	return s.TransitionBooking(booking.ID, entities.BookingStatusConfirmed, &userID, "nothing to pay")
+}
+
+// book takes the seats of the request from the seat inventory and saves the booking. When
+// allocated seats were taken in the meantime, it allocates again.
+func (s *BookingService) book(userID uint, req BookingRequest, status string, holdExpiresAt *time.Time) (entities.Booking, error) {
+	for attempt := 1; ; attempt++ {
+		booking, err := s.newBooking(userID, req, status)
+		if err != nil {
+			return entities.Booking{}, err
+		}
+		booking.HoldExpiresAt = holdExpiresAt
+
+		err = s.inventory.Reserve(&booking)
+		if errors.Is(err, interfaces.ErrSeatsTaken) && len(req.Seats) == 0 && attempt < maxAllocationAttempts {
+			continue
+		}
+		if err != nil {
+			return entities.Booking{}, err
+		}
+
+		saved, err := s.repo.CreateBooking(booking)
+		if err != nil {
+			s.inventory.Undo(booking)
+			return entities.Booking{}, err
+		}
+		return saved, nil
+	}
+}
+
+// newBooking validates the request, picks the seats and prices the tickets of a booking in
+// the given status.
+func (s *BookingService) newBooking(userID uint, req BookingRequest, status string) (entities.Booking, error) {
+	// Validate required fields
+	if userID == 0 {
+		return entities.Booking{}, errors.New("user ID is required")
+	}
+	if req.TrainCode == "" {
+		return entities.Booking{}, errors.New("train code is required")
+	}
+	if req.ServiceDate.IsZero() {
+		return entities.Booking{}, errors.New("service date is required")
+	}
+	if len(req.Seats) > 0 {
+		if req.SeatCount != 0 && req.SeatCount != len(req.Seats) {
+			return entities.Booking{}, fmt.Errorf("seat count %d does not match the %d seats selected", req.SeatCount, len(req.Seats))
+		}
+		req.SeatCount = len(req.Seats)
+	}
+	if req.SeatCount < 1 || req.SeatCount > maxSeatsPerBooking {
+		return entities.Booking{}, fmt.Errorf("seat count must be between 1 and %d", maxSeatsPerBooking)
+	}
+	if len(req.Passengers) != req.SeatCount {
+		return entities.Booking{}, fmt.Errorf("%d passengers named for %d seats", len(req.Passengers), req.SeatCount)
+	}
+	passengers, err := s.passengers.bookingPassengers(userID, req.Passengers)
+	if err != nil {
+		return entities.Booking{}, err
+	}
+
+	if err := s.calendar.EnsureRunsOn(req.TrainCode, req.ServiceDate); err != nil {
+		return entities.Booking{}, err
+	}
+
+	fromOrder, toOrder, err := s.resolveStops(req.TrainCode, req.FromStationCode, req.ToStationCode)
+	if err != nil {
+		return entities.Booking{}, err
+	}
+
+	// Seats not picked by the passenger are allocated, keeping the party together
+	var choices []seatChoice
+	if len(req.Seats) > 0 {
+		choices, err = s.seats.resolveSeats(req.TrainCode, req.Seats)
+	} else {
+		choices, err = s.seats.allocateSeats(req.TrainCode, req.ServiceDate, fromOrder, toOrder, req.SeatCount, req.FareOptions)
+	}
+	if err != nil {
+		return entities.Booking{}, err
+	}
+
+	reference, err := generateBookingReference()
+	if err != nil {
+		return entities.Booking{}, err
+	}
+
+	tickets := make([]entities.Ticket, req.SeatCount)
+	totalPrice := 0
+	for i := range tickets {
+		tickets[i] = entities.Ticket{
+			TicketNumber: fmt.Sprintf("%s-%02d", reference, i+1),
+			TrainCode:    req.TrainCode,
+			Status:       status,
+			Passenger:    &passengers[i],
+			ModifyBy:     userID,
+		}
+
+		// A picked seat is priced by the class and berth it is in
+		options := req.FareOptions
+		if choices != nil {
+			choice := choices[i]
+			seatID := choice.Seat.ID
+			tickets[i].SeatID = &seatID
+			tickets[i].CoachNumber = choice.Coach.Number
+			tickets[i].SeatNumber = choice.Seat.Number
+			options = FareOptions{
+				Class:          choice.Coach.Class,
+				AirConditioned: choice.Coach.AirConditioned,
+				Berth:          choice.Seat.Berth,
+			}
+		}
+
+		// A concession is checked against the passenger it is claimed for
+		options.Concession = ""
+		if code := req.Passengers[i].Concession; code != "" {
+			rule, err := s.fares.checkConcession(code, passengers[i], req.Passengers[i].HeightCm, req.ServiceDate)
+			if err != nil {
+				return entities.Booking{}, fmt.Errorf("passenger %d: %w", i+1, err)
+			}
+			options.Concession = rule.Code
+			tickets[i].ConcessionCode = rule.Code
+			tickets[i].ConcessionName = rule.Name
+			tickets[i].ConcessionProof = rule.Proof
+		}
+
+		fare, err := s.fares.GetFare(req.TrainCode, req.FromStationCode, req.ToStationCode, options)
+		if err != nil {
+			return entities.Booking{}, err
+		}
+		tickets[i].Price = fare.Total
+		totalPrice += fare.Total
+	}
+
+	// Tickets keep their fare, the discount comes off the booking as a whole
+	promotionCode, discount := "", 0
+	if req.PromotionCode != "" {
+		promotionCode, discount, err = s.promotions.discount(req.PromotionCode, userID, req.TrainCode, req.ServiceDate, totalPrice)
+		if err != nil {
+			return entities.Booking{}, err
+		}
+	}
+
+	booking := entities.Booking{
+		Reference:       reference,
+		UserID:          userID,
+		TrainCode:       req.TrainCode,
+		ServiceDate:     req.ServiceDate,
+		FromStationCode: req.FromStationCode,
+		ToStationCode:   req.ToStationCode,
+		FromOrder:       fromOrder,
+		ToOrder:         toOrder,
+		SeatCount:       req.SeatCount,
+		TotalPrice:      totalPrice - discount,
+		PromotionCode:   promotionCode,
+		Discount:        discount,
+		Status:          status,
+		Tickets:         tickets,
+		ModifyBy:        userID,
+	}
+
+	return booking, nil
+}
+
+// GetBooking returns the booking if it belongs to the user. Admins may read any booking.
+func (s *BookingService) GetBooking(id, userID uint, role string) (entities.Booking, error) {
+	booking, err := s.repo.GetBookingByID(id)
+	if err != nil {
+		return entities.Booking{}, err
+	}
+
+	if booking.UserID != userID && role != "admin" {
+		return entities.Booking{}, interfaces.ErrBookingNotFound
+	}
+
+	return booking, nil
+}
+
+func (s *BookingService) GetBookings(userID uint, offset, limit int) ([]entities.Booking, int64, error) {
+	return s.repo.GetBookingsByUser(userID, offset, limit)
+}
+
+// Cancellation is a cancelled booking, the refund it was quoted and the refund created
+// for the payment subsystem, if anything is due back.
+type Cancellation struct {
+	Booking entities.Booking `json:"booking"`
+	Quote   RefundQuote      `json:"quote"`
+	Refund  *entities.Refund `json:"refund,omitempty"`
+}
+
+// QuoteCancellation returns what cancelling the user's booking now would refund, without
+// cancelling it.
+func (s *BookingService) QuoteCancellation(id, userID uint, role string) (RefundQuote, error) {
+	booking, err := s.cancellableBooking(id, userID, role)
+	if err != nil {
+		return RefundQuote{}, err
+	}
+	return s.refunds.Quote(booking, time.Now())
+}
+
+// CancelBooking cancels the booking. The refund is quoted again as of now and, when
+// anything was paid and is due back, created pending together with the cancellation.
+func (s *BookingService) CancelBooking(id, userID uint, role string) (Cancellation, error) {
+	booking, err := s.cancellableBooking(id, userID, role)
+	if err != nil {
+		return Cancellation{}, err
+	}
+	return s.cancel(booking, userID, "")
+}
+
+// cancel cancels the booking and creates its refund, if any.
+func (s *BookingService) cancel(booking entities.Booking, userID uint, reason string) (Cancellation, error) {
+	quote, err := s.refunds.Quote(booking, time.Now())
+	if err != nil {
+		return Cancellation{}, err
+	}
+
+	var refund *entities.Refund
+	if quote.Amount > 0 {
+		refund = &entities.Refund{
+			PaymentID: quote.PaymentID,
+			Amount:    quote.Amount,
+			Status:    entities.RefundStatusPending,
+			Reason:    "booking cancelled",
+			ModifyBy:  userID,
+		}
+	}
+
+	cancelled, err := s.transitionBooking(booking, entities.BookingStatusCancelled, &userID, reason, refund)
+	if err != nil {
+		return Cancellation{}, err
+	}
+	return Cancellation{Booking: cancelled, Quote: quote, Refund: refund}, nil
+}
+
+// cancellableBooking returns the booking if the user may see it and it can be cancelled.
+// Once the train has left the passenger's station the seats cannot be used by anyone else,
+// so the booking can no longer be cancelled.
+func (s *BookingService) cancellableBooking(id, userID uint, role string) (entities.Booking, error) {
+	booking, err := s.GetBooking(id, userID, role)
+	if err != nil {
+		return entities.Booking{}, err
+	}
+	if !canTransitionBooking(booking.Status, entities.BookingStatusCancelled) {
+		return entities.Booking{}, fmt.Errorf("%w: booking %s is %s and cannot be cancelled",
+			interfaces.ErrInvalidBookingTransition, booking.Reference, booking.Status)
+	}
+
+	departure, err := s.refunds.departure(booking)
+	if err != nil {
+		return entities.Booking{}, err
+	}
+	if !time.Now().Before(departure) {
+		return entities.Booking{}, fmt.Errorf("%w: booking %s cannot be cancelled, its train left at %s",
+			interfaces.ErrInvalidBookingTransition, booking.Reference, departure.Format("2006-01-02 15:04"))
+	}
+	return booking, nil
+}
+
+// GetRefunds returns the refunds of the booking if it belongs to the user. Admins may read
+// the refunds of any booking.
+func (s *BookingService) GetRefunds(id, userID uint, role string) ([]entities.Refund, error) {
+	if _, err := s.GetBooking(id, userID, role); err != nil {
+		return nil, err
+	}
+	return s.refunds.GetRefunds(id)
+}
+
+// GetAvailableSeats returns how many seats are free between two stops of a train run.
+func (s *BookingService) GetAvailableSeats(trainCode string, serviceDate time.Time, fromStationCode, toStationCode string) (int, error) {
+	if trainCode == "" {
+		return 0, errors.New("train code is required")
+	}
+	if serviceDate.IsZero() {
+		return 0, errors.New("service date is required")
+	}
+
+	if err := s.calendar.EnsureRunsOn(trainCode, serviceDate); err != nil {
+		return 0, err
+	}
+
+	fromOrder, toOrder, err := s.resolveStops(trainCode, fromStationCode, toStationCode)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.inventory.Available(trainCode, serviceDate, fromOrder, toOrder)
+}
+
+// resolveStops looks up the position of both stations on the train's route and makes
+// sure the passenger travels forward along it.
+func (s *BookingService) resolveStops(trainCode, fromStationCode, toStationCode string) (int, int, error) {
+	if fromStationCode == "" || toStationCode == "" {
+		return 0, 0, errors.New("origin and destination stations are required")
+	}
+
+	route, err := s.repo.GetStationOrder(trainCode)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	from, to, err := findStops(route, fromStationCode, toStationCode)
+	if err != nil {
+		return 0, 0, err
+	}
+	return from.Order, to.Order, nil
+}
+
+// findStops returns the stops of the route at both stations, the first before the second.
+func findStops(route entities.StationOrder, fromStationCode, toStationCode string) (entities.StationOrderDetail, entities.StationOrderDetail, error) {
+	var from, to *entities.StationOrderDetail
+	for i := range route.Stations {
+		switch route.Stations[i].StationCode {
+		case fromStationCode:
+			from = &route.Stations[i]
+		case toStationCode:
+			to = &route.Stations[i]
+		}
+	}
+
+	if from == nil {
+		return entities.StationOrderDetail{}, entities.StationOrderDetail{}, fmt.Errorf("train %s does not stop at station %s", route.TrainCode, fromStationCode)
+	}
+	if to == nil {
+		return entities.StationOrderDetail{}, entities.StationOrderDetail{}, fmt.Errorf("train %s does not stop at station %s", route.TrainCode, toStationCode)
+	}
+	if from.Order >= to.Order {
+		return entities.StationOrderDetail{}, entities.StationOrderDetail{}, fmt.Errorf("train %s does not run from %s to %s", route.TrainCode, fromStationCode, toStationCode)
+	}
+
+	return *from, *to, nil
+}
+
+// generateBookingReference returns a random reference such as "BK3F9A1C2D".
+func generateBookingReference() (string, error) {
+	refBytes := make([]byte, 4)
+	if _, err := rand.Read(refBytes); err != nil {
+		return "", fmt.Errorf("failed to generate booking reference: %v", err)
+	}
+	return fmt.Sprintf("BK%X", refBytes), nil
+}
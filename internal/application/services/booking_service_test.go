@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+func TestCancellableBooking(t *testing.T) {
+	route := entities.StationOrder{
+		TrainCode: "SP001",
+		Stations: []entities.StationOrderDetail{
+			{StationCode: "BKK", Order: 1, ArrivalOffset: 480, DepartureOffset: 480},
+			{StationCode: "AYA", Order: 2, ArrivalOffset: 540, DepartureOffset: 545, DistanceKm: 70},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		status      string
+		serviceDate time.Time
+		wantErr     error
+	}{
+		{name: "before departure", status: entities.BookingStatusConfirmed, serviceDate: time.Now().AddDate(0, 0, 2)},
+		{name: "hold before departure", status: entities.BookingStatusHeld, serviceDate: time.Now().AddDate(0, 0, 2)},
+		{
+			name:        "after departure",
+			status:      entities.BookingStatusConfirmed,
+			serviceDate: time.Now().AddDate(0, 0, -2),
+			wantErr:     interfaces.ErrInvalidBookingTransition,
+		},
+		{
+			name:        "already cancelled",
+			status:      entities.BookingStatusCancelled,
+			serviceDate: time.Now().AddDate(0, 0, 2),
+			wantErr:     interfaces.ErrInvalidBookingTransition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booking := entities.Booking{
+				Reference:   "BK00000000",
+				UserID:      7,
+				TrainCode:   "SP001",
+				ServiceDate: tt.serviceDate,
+				FromOrder:   1,
+				ToOrder:     2,
+				Status:      tt.status,
+			}
+			booking.ID = 1
+			service := &BookingService{
+				repo:    &memoryBookingRepository{bookings: map[uint]entities.Booking{1: booking}},
+				refunds: &RefundService{routeRepo: &memoryRouteRepository{route: route}},
+			}
+
+			_, err := service.cancellableBooking(1, 7, "user")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("cancellableBooking() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// bookingTransitions lists the statuses a booking may move to from each status. A hold is
+// paid or, when nothing is due, confirmed right away. Checked in, refunded, expired and no
+// show bookings are final, a cancelled booking may still be refunded.
+var bookingTransitions = map[string][]string{
+	entities.BookingStatusHeld: {
+		entities.BookingStatusPendingPayment, entities.BookingStatusConfirmed,
+		entities.BookingStatusCancelled, entities.BookingStatusExpired,
+	},
+	entities.BookingStatusPendingPayment: {
+		entities.BookingStatusConfirmed, entities.BookingStatusCancelled, entities.BookingStatusExpired,
+	},
+	entities.BookingStatusConfirmed: {
+		entities.BookingStatusCheckedIn, entities.BookingStatusCancelled,
+		entities.BookingStatusRefunded, entities.BookingStatusNoShow,
+	},
+	entities.BookingStatusCancelled: {entities.BookingStatusRefunded},
+}
+
+// canTransitionBooking reports whether a booking may move from one status to another.
+func canTransitionBooking(from, to string) bool {
+	for _, next := range bookingTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// releasesSeats reports whether moving a booking from one status to another gives its
+// seats back. A cancelled booking gave them back already when it was cancelled.
+func releasesSeats(from, to string) bool {
+	switch to {
+	case entities.BookingStatusCancelled, entities.BookingStatusExpired:
+		return true
+	case entities.BookingStatusRefunded:
+		return from != entities.BookingStatusCancelled
+	}
+	return false
+}
+
+// TransitionBooking moves the booking to a new status and records the change in its
+// history. modifyBy is nil for changes made by the system.
+func (s *BookingService) TransitionBooking(id uint, status string, modifyBy *uint, reason string) (entities.Booking, error) {
+	booking, err := s.repo.GetBookingByID(id)
+	if err != nil {
+		return entities.Booking{}, err
+	}
+	return s.transitionBooking(booking, status, modifyBy, reason, nil)
+}
+
+// transitionBooking is where every status change of a booking goes through. A refund, if
+// given, is created together with the change.
+func (s *BookingService) transitionBooking(booking entities.Booking, status string, modifyBy *uint, reason string, refund *entities.Refund) (entities.Booking, error) {
+	if !canTransitionBooking(booking.Status, status) {
+		return entities.Booking{}, fmt.Errorf("%w: booking %s cannot move from %s to %s",
+			interfaces.ErrInvalidBookingTransition, booking.Reference, booking.Status, status)
+	}
+
+	// A hold is only kept while its Redis key lives
+	keepsHold := status == entities.BookingStatusPendingPayment || status == entities.BookingStatusConfirmed
+	if booking.Holding() && keepsHold {
+		alive, err := redisClient.Exists(ctx, holdKey(booking.Reference)).Result()
+		if err != nil {
+			return entities.Booking{}, fmt.Errorf("failed to check seat hold: %v", err)
+		}
+		if alive == 0 {
+			return entities.Booking{}, interfaces.ErrHoldExpired
+		}
+	}
+
+	release := releasesSeats(booking.Status, status)
+	updated, err := s.repo.TransitionBooking(entities.BookingHistory{
+		BookingID:  booking.ID,
+		FromStatus: booking.Status,
+		ToStatus:   status,
+		Reason:     reason,
+		ModifyBy:   modifyBy,
+	}, release, refund)
+	if err != nil {
+		return entities.Booking{}, err
+	}
+
+	if release {
+		s.inventory.Release(updated)
+	}
+	if booking.Holding() && !updated.Holding() {
+		if err := redisClient.Del(ctx, holdKey(booking.Reference)).Err(); err != nil {
+			log.Printf("Failed to remove seat hold %s: %v", booking.Reference, err)
+		}
+	}
+
+	return updated, nil
+}
+
+// UpdateBookingStatus lets staff move a booking to a new status, e.g. to check it in. A
+// booking with something to pay is confirmed by its payment, see
+// PaymentService.ConfirmPayment. A cancellation is refunded like one made by the customer,
+// and a booking becomes refunded only once its refund was paid out.
+func (s *BookingService) UpdateBookingStatus(id uint, status, reason string, modifyBy uint) (entities.Booking, error) {
+	if modifyBy == 0 {
+		return entities.Booking{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+	switch status {
+	case entities.BookingStatusCancelled:
+		booking, err := s.cancellableBooking(id, modifyBy, "admin")
+		if err != nil {
+			return entities.Booking{}, err
+		}
+		cancellation, err := s.cancel(booking, modifyBy, reason)
+		return cancellation.Booking, err
+	case entities.BookingStatusRefunded:
+		return entities.Booking{}, fmt.Errorf("%w: a booking becomes refunded once its refund is paid out",
+			interfaces.ErrInvalidBookingTransition)
+	}
+	booking, err := s.repo.GetBookingByID(id)
+	if err != nil {
+		return entities.Booking{}, err
+	}
+	if status == entities.BookingStatusConfirmed && booking.TotalPrice > 0 {
+		return entities.Booking{}, fmt.Errorf("%w: booking %s costs %d baht", interfaces.ErrPaymentRequired,
+			booking.Reference, booking.TotalPrice)
+	}
+	return s.transitionBooking(booking, status, &modifyBy, reason, nil)
+}
+
+// MarkRefunded moves a cancelled booking to refunded once its refund was paid out. Other
+// bookings are left alone, e.g. a confirmed booking that was paid for twice.
+func (s *BookingService) MarkRefunded(id uint, reason string) error {
+	booking, err := s.repo.GetBookingByID(id)
+	if err != nil {
+		return err
+	}
+	if booking.Status != entities.BookingStatusCancelled {
+		return nil
+	}
+	_, err = s.transitionBooking(booking, entities.BookingStatusRefunded, nil, reason, nil)
+	return err
+}
+
+// GetBookingHistory returns the status changes of the booking if it belongs to the user.
+// Admins may read the history of any booking.
+func (s *BookingService) GetBookingHistory(id, userID uint, role string) ([]entities.BookingHistory, error) {
+	if _, err := s.GetBooking(id, userID, role); err != nil {
+		return nil, err
+	}
+	return s.repo.GetBookingHistory(id)
+}
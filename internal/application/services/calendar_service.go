@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+type CalendarService struct {
+	repo      interfaces.ServiceCalendarRepository
+	trainRepo interfaces.TrainRepository
+}
+
+func NewCalendarService(repo interfaces.ServiceCalendarRepository, trainRepo interfaces.TrainRepository) *CalendarService {
+	return &CalendarService{repo: repo, trainRepo: trainRepo}
+}
+
+func (s *CalendarService) GetCalendar(trainCode string) (entities.ServiceCalendar, error) {
+	return s.repo.GetCalendar(trainCode)
+}
+
+func (s *CalendarService) SaveCalendar(calendar entities.ServiceCalendar) (entities.ServiceCalendar, error) {
+	if calendar.ModifyBy == 0 {
+		return entities.ServiceCalendar{}, errors.New("modifyBy (user ID) is required")
+	}
+	if calendar.StartDate.IsZero() || calendar.EndDate.IsZero() {
+		return entities.ServiceCalendar{}, errors.New("start and end dates are required")
+	}
+	if calendar.EndDate.Before(calendar.StartDate) {
+		return entities.ServiceCalendar{}, errors.New("end date cannot be before start date")
+	}
+	if !(calendar.Monday || calendar.Tuesday || calendar.Wednesday || calendar.Thursday ||
+		calendar.Friday || calendar.Saturday || calendar.Sunday) {
+		return entities.ServiceCalendar{}, errors.New("calendar must run on at least one weekday")
+	}
+
+	if _, err := s.trainRepo.GetTrainByCode(calendar.TrainCode); err != nil {
+		return entities.ServiceCalendar{}, err
+	}
+
+	return s.repo.SaveCalendar(calendar)
+}
+
+func (s *CalendarService) SaveException(trainCode string, exception entities.ServiceCalendarException) (entities.ServiceCalendar, error) {
+	if exception.ModifyBy == 0 {
+		return entities.ServiceCalendar{}, errors.New("modifyBy (user ID) is required")
+	}
+	if exception.Date.IsZero() {
+		return entities.ServiceCalendar{}, errors.New("exception date is required")
+	}
+	if exception.Type != entities.CalendarExceptionAdded && exception.Type != entities.CalendarExceptionRemoved {
+		return entities.ServiceCalendar{}, fmt.Errorf("exception type must be %s or %s",
+			entities.CalendarExceptionAdded, entities.CalendarExceptionRemoved)
+	}
+
+	return s.repo.SaveException(trainCode, exception)
+}
+
+func (s *CalendarService) DeleteException(trainCode string, date time.Time) error {
+	return s.repo.DeleteException(trainCode, date)
+}
+
+// RunsOn reports whether the train runs on the service date. Trains without a calendar
+// run every day.
+func (s *CalendarService) RunsOn(trainCode string, serviceDate time.Time) (bool, error) {
+	calendar, err := s.repo.GetCalendar(trainCode)
+	if errors.Is(err, interfaces.ErrCalendarNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return calendar.RunsOn(serviceDate), nil
+}
+
+// EnsureRunsOn returns interfaces.ErrTrainNotRunning if the train does not run on the service date.
+func (s *CalendarService) EnsureRunsOn(trainCode string, serviceDate time.Time) error {
+	runs, err := s.RunsOn(trainCode, serviceDate)
+	if err != nil {
+		return err
+	}
+	if !runs {
+		return fmt.Errorf("%w: train %s on %s", interfaces.ErrTrainNotRunning, trainCode, serviceDate.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// RunningFilter loads every calendar once and returns a function answering RunsOn for
+// many trains and dates, for searches that look at the whole timetable.
+func (s *CalendarService) RunningFilter() (func(trainCode string, serviceDate time.Time) bool, error) {
+	calendars, err := s.repo.GetCalendars()
+	if err != nil {
+		return nil, err
+	}
+
+	byTrain := make(map[string]entities.ServiceCalendar, len(calendars))
+	for _, calendar := range calendars {
+		byTrain[calendar.TrainCode] = calendar
+	}
+
+	return func(trainCode string, serviceDate time.Time) bool {
+		calendar, ok := byTrain[trainCode]
+		return !ok || calendar.RunsOn(serviceDate)
+	}, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+// Coach (consist) methods
+func (s *TrainService) GetCoaches(trainCode string) ([]entities.Coach, error) {
+	if _, err := s.trainRepo.GetTrainByCode(trainCode); err != nil {
+		return nil, err
+	}
+	return s.coachRepo.GetCoaches(trainCode)
+}
+
+// ReplaceCoaches sets the consist of the train. The number of seats of the train follows
+// the seats of its coaches from then on.
+func (s *TrainService) ReplaceCoaches(trainCode string, coaches []entities.Coach, modifyBy uint) ([]entities.Coach, error) {
+	if modifyBy == 0 {
+		return nil, fmt.Errorf("modifyBy (user ID) is required")
+	}
+
+	if _, err := s.trainRepo.GetTrainByCode(trainCode); err != nil {
+		return nil, err
+	}
+
+	if err := validateCoaches(coaches); err != nil {
+		return nil, err
+	}
+
+	return s.coachRepo.ReplaceCoaches(trainCode, coaches, modifyBy)
+}
+
+// validateCoaches checks that coach numbers are unique, classes exist and every coach has
+// seats with unique numbers and positions.
+func validateCoaches(coaches []entities.Coach) error {
+	if len(coaches) == 0 {
+		return fmt.Errorf("a train needs at least one coach")
+	}
+
+	coachNumbers := make(map[int]bool, len(coaches))
+	for _, coach := range coaches {
+		if coach.Number < 1 {
+			return fmt.Errorf("coach numbers must start at 1")
+		}
+		if coachNumbers[coach.Number] {
+			return fmt.Errorf("coach %d appears more than once", coach.Number)
+		}
+		coachNumbers[coach.Number] = true
+
+		if coach.Class < 1 || coach.Class > 3 {
+			return fmt.Errorf("class of coach %d must be 1, 2 or 3", coach.Number)
+		}
+		if len(coach.Seats) == 0 {
+			return fmt.Errorf("coach %d has no seats", coach.Number)
+		}
+
+		seatNumbers := make(map[string]bool, len(coach.Seats))
+		type seatPosition struct {
+			row, column int
+			berth       string
+		}
+		positions := make(map[seatPosition]bool, len(coach.Seats))
+		for _, seat := range coach.Seats {
+			if seat.Number == "" {
+				return fmt.Errorf("seat number is required in coach %d", coach.Number)
+			}
+			if seatNumbers[seat.Number] {
+				return fmt.Errorf("seat %s appears more than once in coach %d", seat.Number, coach.Number)
+			}
+			seatNumbers[seat.Number] = true
+
+			if seat.Row < 1 || seat.Column < 1 {
+				return fmt.Errorf("row and column of seat %s in coach %d must start at 1", seat.Number, coach.Number)
+			}
+			position := seatPosition{row: seat.Row, column: seat.Column, berth: seat.Berth}
+			if positions[position] {
+				return fmt.Errorf("seat %s in coach %d shares its position with another seat", seat.Number, coach.Number)
+			}
+			positions[position] = true
+
+			switch seat.Berth {
+			case "", entities.BerthUpper, entities.BerthLower:
+			default:
+				return fmt.Errorf("berth of seat %s in coach %d must be %s or %s", seat.Number, coach.Number, entities.BerthUpper, entities.BerthLower)
+			}
+			switch seat.Facing {
+			case "", entities.FacingForward, entities.FacingBackward:
+			default:
+				return fmt.Errorf("facing of seat %s in coach %d must be %s or %s", seat.Number, coach.Number, entities.FacingForward, entities.FacingBackward)
+			}
+		}
+	}
+
+	return nil
+}
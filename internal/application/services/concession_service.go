@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+var concessionCategories = map[string]bool{
+	entities.ConcessionCategoryChild:    true,
+	entities.ConcessionCategorySenior:   true,
+	entities.ConcessionCategoryMonk:     true,
+	entities.ConcessionCategoryDisabled: true,
+}
+
+// Concession rule methods
+func (s *FareService) GetConcessions() ([]entities.ConcessionRule, error) {
+	return s.concessionRepo.GetRules()
+}
+
+func (s *FareService) CreateConcession(rule entities.ConcessionRule) (entities.ConcessionRule, error) {
+	if rule.Code == "" {
+		return entities.ConcessionRule{}, errors.New("concession code is required")
+	}
+	if err := validateConcession(rule); err != nil {
+		return entities.ConcessionRule{}, err
+	}
+	return s.concessionRepo.CreateRule(rule)
+}
+
+func (s *FareService) UpdateConcession(rule entities.ConcessionRule) (entities.ConcessionRule, error) {
+	if err := validateConcession(rule); err != nil {
+		return entities.ConcessionRule{}, err
+	}
+	return s.concessionRepo.UpdateRule(rule)
+}
+
+func (s *FareService) DeleteConcession(code string) error {
+	return s.concessionRepo.DeleteRule(code)
+}
+
+func validateConcession(rule entities.ConcessionRule) error {
+	if rule.ModifyBy == 0 {
+		return errors.New("modifyBy (user ID) is required")
+	}
+	if rule.Name == "" {
+		return errors.New("concession name is required")
+	}
+	if !concessionCategories[rule.Category] {
+		return fmt.Errorf("invalid concession category %q", rule.Category)
+	}
+	if rule.DiscountPercent < 1 || rule.DiscountPercent > 100 {
+		return errors.New("discount must be between 1 and 100 percent")
+	}
+	if rule.MinAge < 0 || rule.MaxAge < 0 || rule.MaxHeightCm < 0 {
+		return errors.New("age and height limits cannot be negative")
+	}
+	if rule.MaxAge > 0 && rule.MaxAge < rule.MinAge {
+		return errors.New("maximum age cannot be below minimum age")
+	}
+	if rule.Proof == "" {
+		return errors.New("proof the passenger shows on board is required")
+	}
+	return nil
+}
+
+// activeConcession returns the rule with the code if it is still sold.
+func (s *FareService) activeConcession(code string) (entities.ConcessionRule, error) {
+	rule, err := s.concessionRepo.GetRuleByCode(code)
+	if err != nil {
+		return entities.ConcessionRule{}, err
+	}
+	if !rule.Active {
+		return entities.ConcessionRule{}, fmt.Errorf("concession %s is no longer sold", code)
+	}
+	return rule, nil
+}
+
+// checkConcession makes sure the passenger may travel on the concession on the service
+// date. A passenger older than the age limit of a rule with a height limit qualifies by
+// the declared height instead, which staff measure on board.
+func (s *FareService) checkConcession(code string, passenger entities.Passenger, heightCm int, serviceDate time.Time) (entities.ConcessionRule, error) {
+	rule, err := s.activeConcession(code)
+	if err != nil {
+		return entities.ConcessionRule{}, err
+	}
+
+	if rule.ThaiOnly && passenger.DocumentType != entities.PassengerDocumentNationalID {
+		return entities.ConcessionRule{}, fmt.Errorf("%s fares need a Thai national ID", rule.Name)
+	}
+
+	age := ageOn(passenger.DateOfBirth, serviceDate)
+	if rule.MinAge > 0 && age < rule.MinAge {
+		return entities.ConcessionRule{}, fmt.Errorf("%s fares are for passengers aged %d and over", rule.Name, rule.MinAge)
+	}
+	if rule.MaxAge > 0 && age > rule.MaxAge {
+		if rule.MaxHeightCm == 0 {
+			return entities.ConcessionRule{}, fmt.Errorf("%s fares are for passengers aged up to %d", rule.Name, rule.MaxAge)
+		}
+		if heightCm <= 0 || heightCm > rule.MaxHeightCm {
+			return entities.ConcessionRule{}, fmt.Errorf("%s fares are for passengers aged up to %d or up to %d cm tall",
+				rule.Name, rule.MaxAge, rule.MaxHeightCm)
+		}
+	}
+	return rule, nil
+}
+
+// ageOn returns the age in full years of someone born on dateOfBirth at the given date.
+func ageOn(dateOfBirth, date time.Time) int {
+	age := date.Year() - dateOfBirth.Year()
+	if date.Month() < dateOfBirth.Month() || (date.Month() == dateOfBirth.Month() && date.Day() < dateOfBirth.Day()) {
+		age--
+	}
+	return age
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// FakePaymentProvider stands in for a payment gateway in local testing. Every payment is
+// paid as soon as its status is queried and refunds always go through. Webhooks are signed
+// like PromptPay ones, so the public webhook route cannot mark payments paid for anyone
+// without the secret.
+type FakePaymentProvider struct {
+	mu            sync.Mutex
+	payments      map[string]int  // Amount by provider reference
+	refunds       map[string]bool // Idempotency keys of the refunds paid out
+	webhookSecret []byte
+}
+
+func NewFakePaymentProvider(webhookSecret string) (*FakePaymentProvider, error) {
+	if webhookSecret == "" {
+		return nil, errors.New("fake payment provider webhook secret is required")
+	}
+	return &FakePaymentProvider{
+		payments:      make(map[string]int),
+		refunds:       make(map[string]bool),
+		webhookSecret: []byte(webhookSecret),
+	}, nil
+}
+
+// Name implements PaymentProvider.
+func (p *FakePaymentProvider) Name() string {
+	return "fake"
+}
+
+// CreateIntent implements PaymentProvider.
+func (p *FakePaymentProvider) CreateIntent(reference string, amount int, expiresAt time.Time) (PaymentIntent, error) {
+	if amount <= 0 {
+		return PaymentIntent{}, errors.New("payment amount must be positive")
+	}
+
+	providerRef, err := paymentReference("FK", reference)
+	if err != nil {
+		return PaymentIntent{}, err
+	}
+
+	p.mu.Lock()
+	p.payments[providerRef] = amount
+	p.mu.Unlock()
+
+	return PaymentIntent{ProviderRef: providerRef, Amount: amount, ExpiresAt: expiresAt}, nil
+}
+
+// QueryStatus implements PaymentProvider.
+func (p *FakePaymentProvider) QueryStatus(providerRef string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.payments[providerRef]; !ok {
+		return "", interfaces.ErrPaymentNotFound
+	}
+	return entities.PaymentStatusSucceeded, nil
+}
+
+// Refund implements PaymentProvider.
+func (p *FakePaymentProvider) Refund(providerRef, idempotencyKey string, amount int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.refunds[idempotencyKey] {
+		return nil
+	}
+
+	paid, ok := p.payments[providerRef]
+	if !ok {
+		return interfaces.ErrPaymentNotFound
+	}
+	if amount > paid {
+		return errors.New("refund exceeds the amount paid")
+	}
+	p.payments[providerRef] = paid - amount
+	p.refunds[idempotencyKey] = true
+	return nil
+}
+
+// VerifyWebhook implements PaymentProvider.
+func (p *FakePaymentProvider) VerifyWebhook(body []byte, header http.Header) (PaymentEvent, error) {
+	return verifyPaymentWebhook(p.webhookSecret, body, header)
+}
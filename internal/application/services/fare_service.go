@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// DefaultFareClass is the class quoted when none is asked for, e.g. by the journey planner.
+const DefaultFareClass = 3
+
+// FareOptions are the travel choices of a passenger that change the fare.
+type FareOptions struct {
+	Class          int    `json:"class"` // 1, 2 or 3
+	AirConditioned bool   `json:"air_conditioned"`
+	Berth          string `json:"berth,omitempty"`      // entities.BerthUpper, entities.BerthLower or empty for a seat
+	Concession     string `json:"concession,omitempty"` // Code of a concession rule
+}
+
+// FareQuote is what a FareCalculator prices: a trip of DistanceKm on the train.
+type FareQuote struct {
+	// Train must have TrainType and TrainProfitType loaded
+	Train      entities.Train
+	DistanceKm int
+	FareOptions
+	// Set when FareOptions.Concession names an active concession rule
+	ConcessionRule *entities.ConcessionRule
+}
+
+// FareItem is one line of a fare breakdown. Discounts have a negative amount.
+type FareItem struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Amount      int    `json:"amount"`
+}
+
+// Fare is the price of one passenger between two stops of a train.
+type Fare struct {
+	TrainCode       string      `json:"train_code"`
+	FromStationCode string      `json:"from_station_code"`
+	ToStationCode   string      `json:"to_station_code"`
+	DistanceKm      int         `json:"distance_km"`
+	Options         FareOptions `json:"options"`
+	Items           []FareItem  `json:"items"`
+	Total           int         `json:"total"`
+}
+
+// FareCalculator prices a quote as a list of line items. Implementations can be swapped,
+// e.g. to apply a new tariff without touching booking.
+type FareCalculator interface {
+	Calculate(quote FareQuote) ([]FareItem, error)
+}
+
+type FareService struct {
+	trainRepo      interfaces.TrainRepository
+	routeRepo      interfaces.StationOrderRepository
+	concessionRepo interfaces.ConcessionRepository
+	calculator     FareCalculator
+}
+
+func NewFareService(trainRepo interfaces.TrainRepository, routeRepo interfaces.StationOrderRepository, concessionRepo interfaces.ConcessionRepository, calculator FareCalculator) *FareService {
+	return &FareService{trainRepo: trainRepo, routeRepo: routeRepo, concessionRepo: concessionRepo, calculator: calculator}
+}
+
+// GetFare prices one passenger travelling on the train from one of its stops to a later one.
+func (s *FareService) GetFare(trainCode, fromStationCode, toStationCode string, options FareOptions) (Fare, error) {
+	if trainCode == "" {
+		return Fare{}, errors.New("train code is required")
+	}
+	if fromStationCode == "" || toStationCode == "" {
+		return Fare{}, errors.New("origin and destination stations are required")
+	}
+	if options.Class == 0 {
+		options.Class = DefaultFareClass
+	}
+
+	train, err := s.trainRepo.GetTrainByCode(trainCode)
+	if err != nil {
+		return Fare{}, err
+	}
+
+	route, err := s.routeRepo.GetStationOrder(trainCode)
+	if err != nil {
+		return Fare{}, err
+	}
+
+	from, to, err := findStops(route, fromStationCode, toStationCode)
+	if err != nil {
+		return Fare{}, err
+	}
+
+	distanceKm := to.DistanceKm - from.DistanceKm
+	items, err := s.calculate(train, distanceKm, options)
+	if err != nil {
+		return Fare{}, err
+	}
+
+	return Fare{
+		TrainCode:       trainCode,
+		FromStationCode: fromStationCode,
+		ToStationCode:   toStationCode,
+		DistanceKm:      distanceKm,
+		Options:         options,
+		Items:           items,
+		Total:           fareTotal(items),
+	}, nil
+}
+
+// Price returns the total fare of a trip of distanceKm on the train.
+func (s *FareService) Price(train entities.Train, distanceKm int, options FareOptions) (int, error) {
+	items, err := s.calculate(train, distanceKm, options)
+	if err != nil {
+		return 0, err
+	}
+	return fareTotal(items), nil
+}
+
+// calculate loads the concession rule of the options, if any, and prices the trip.
+func (s *FareService) calculate(train entities.Train, distanceKm int, options FareOptions) ([]FareItem, error) {
+	quote := FareQuote{Train: train, DistanceKm: distanceKm, FareOptions: options}
+	if options.Concession != "" {
+		rule, err := s.activeConcession(options.Concession)
+		if err != nil {
+			return nil, err
+		}
+		quote.ConcessionRule = &rule
+	}
+	return s.calculator.Calculate(quote)
+}
+
+func fareTotal(items []FareItem) int {
+	total := 0
+	for _, item := range items {
+		total += item.Amount
+	}
+	return total
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// DefaultIdempotencyTTL is how long the response to an idempotent request is replayed.
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// idempotencyLockTTL is how long a key stays reserved for a request still in progress,
+	// so a server stopped halfway does not block the key for the whole TTL.
+	idempotencyLockTTL = time.Minute
+)
+
+// IdempotencyRecord is what is stored for an Idempotency-Key. Until the first request
+// finishes it only holds the fingerprint of that request.
+type IdempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// IdempotencyService remembers the responses to requests sent with an Idempotency-Key, so
+// a client resending a request gets the first response instead of doing it twice. Keys
+// are scoped to the user and kept in Redis, which API instances share.
+type IdempotencyService struct {
+	ttl time.Duration
+}
+
+func NewIdempotencyService(ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{ttl: ttl}
+}
+
+// idempotencyKey is the Redis key of the user's Idempotency-Key.
+func idempotencyKey(userID uint, key string) string {
+	return fmt.Sprintf("idempotency:%d:%s", userID, key)
+}
+
+// Begin reserves the key for a request with the fingerprint. It returns nil when the key
+// is new, otherwise the record stored by the request that used the key first.
+func (s *IdempotencyService) Begin(userID uint, key, fingerprint string) (*IdempotencyRecord, error) {
+	pending, err := json.Marshal(IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+
+	redisKey := idempotencyKey(userID, key)
+	reserved, err := redisClient.SetNX(ctx, redisKey, pending, idempotencyLockTTL).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %v", err)
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	stored, err := redisClient.Get(ctx, redisKey).Bytes()
+	if err == redis.Nil {
+		// The reservation ran out in between, try again with the key free
+		return s.Begin(userID, key, fingerprint)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read idempotency key: %v", err)
+	}
+
+	var record IdempotencyRecord
+	if err := json.Unmarshal(stored, &record); err != nil {
+		return nil, fmt.Errorf("invalid idempotency record: %v", err)
+	}
+	return &record, nil
+}
+
+// Complete stores the response of the request that reserved the key.
+func (s *IdempotencyService) Complete(userID uint, key string, record IdempotencyRecord) error {
+	record.Done = true
+	stored, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := redisClient.Set(ctx, idempotencyKey(userID, key), stored, s.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %v", err)
+	}
+	return nil
+}
+
+// Release frees the key without a response, so the request can be tried again.
+func (s *IdempotencyService) Release(userID uint, key string) error {
+	if err := redisClient.Del(ctx, idempotencyKey(userID, key)).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+const (
+	DefaultMaxTransfers  = 2
+	DefaultMinConnection = 20 * time.Minute
+
+	maxTransfersLimit = 3
+	maxItineraries    = 20
+)
+
+// Journey sort orders
+const (
+	JourneySortArrival = "arrival"
+	JourneySortChanges = "changes"
+	JourneySortFare    = "fare"
+)
+
+// JourneyOptions tunes the journey search.
+type JourneyOptions struct {
+	MaxTransfers  int
+	MinConnection time.Duration
+	SortBy        string
+}
+
+// JourneyLeg is one train ridden as part of an itinerary.
+type JourneyLeg struct {
+	TrainCode       string    `json:"train_code"`
+	TrainName       string    `json:"train_name"`
+	FromStationCode string    `json:"from_station_code"`
+	ToStationCode   string    `json:"to_station_code"`
+	Departure       time.Time `json:"departure"`
+	Arrival         time.Time `json:"arrival"`
+	Fare            int       `json:"fare"` // In DefaultFareClass
+}
+
+// Itinerary is a way to travel from the origin to the destination, possibly changing trains.
+type Itinerary struct {
+	Legs            []JourneyLeg `json:"legs"`
+	Departure       time.Time    `json:"departure"`
+	Arrival         time.Time    `json:"arrival"`
+	DurationMinutes int          `json:"duration_minutes"`
+	Transfers       int          `json:"transfers"`
+	TotalFare       int          `json:"total_fare"`
+}
+
+// tripStop is a call of a trip at a station.
+type tripStop struct {
+	StationCode string
+	Arrival     time.Time
+	Departure   time.Time
+	DistanceKm  int
+}
+
+// trip is a train running its route on a service date, the edges of the journey graph.
+type trip struct {
+	Train entities.Train
+	Stops []tripStop
+}
+
+type JourneyService struct {
+	routeRepo interfaces.StationOrderRepository
+	runs      *TrainRunService
+	fares     *FareService
+}
+
+func NewJourneyService(routeRepo interfaces.StationOrderRepository, runs *TrainRunService, fares *FareService) *JourneyService {
+	return &JourneyService{routeRepo: routeRepo, runs: runs, fares: fares}
+}
+
+// PlanJourney returns the itineraries from one station to another departing on the service
+// date, with at most opts.MaxTransfers changes of train.
+func (s *JourneyService) PlanJourney(fromStationCode, toStationCode string, serviceDate time.Time, opts JourneyOptions) ([]Itinerary, error) {
+	if fromStationCode == "" || toStationCode == "" {
+		return nil, errors.New("origin and destination stations are required")
+	}
+	if fromStationCode == toStationCode {
+		return nil, errors.New("origin and destination stations must be different")
+	}
+	if serviceDate.IsZero() {
+		return nil, errors.New("travel date is required")
+	}
+
+	if opts.MaxTransfers < 0 || opts.MaxTransfers > maxTransfersLimit {
+		return nil, fmt.Errorf("max transfers must be between 0 and %d", maxTransfersLimit)
+	}
+	if opts.MinConnection < 0 {
+		return nil, errors.New("minimum connection time cannot be negative")
+	}
+	switch opts.SortBy {
+	case "":
+		opts.SortBy = JourneySortArrival
+	case JourneySortArrival, JourneySortChanges, JourneySortFare:
+	default:
+		return nil, fmt.Errorf("unknown sort order %s", opts.SortBy)
+	}
+
+	routes, err := s.routeRepo.GetStationOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	runsOn, err := s.runs.RunningFilter(serviceDate.AddDate(0, 0, -1), serviceDate.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	// Overnight trains of the previous day may still call at the origin on the travel date,
+	// and connections may run into the next day
+	trips := buildTrips(routes, runsOn, serviceDate.AddDate(0, 0, -1), serviceDate, serviceDate.AddDate(0, 0, 1))
+
+	// Legs are priced in the default class without extras, as a fare to compare itineraries by
+	fare := func(train entities.Train, distanceKm int) int {
+		price, err := s.fares.Price(train, distanceKm, FareOptions{Class: DefaultFareClass})
+		if err != nil {
+			return 0
+		}
+		return price
+	}
+
+	itineraries := searchItineraries(trips, fromStationCode, toStationCode, serviceDate, opts, fare)
+	sortItineraries(itineraries, opts.SortBy)
+
+	if len(itineraries) > maxItineraries {
+		itineraries = itineraries[:maxItineraries]
+	}
+	return itineraries, nil
+}
+
+// buildTrips turns every route into a trip on each of the given service dates the train
+// runs on.
+func buildTrips(routes []entities.StationOrder, runsOn func(trainCode string, serviceDate time.Time) bool, serviceDates ...time.Time) []trip {
+	trips := make([]trip, 0, len(routes)*len(serviceDates))
+	for _, serviceDate := range serviceDates {
+		for _, route := range routes {
+			if len(route.Stations) < 2 || !runsOn(route.TrainCode, serviceDate) {
+				continue
+			}
+
+			stops := make([]tripStop, len(route.Stations))
+			for i, stop := range stopTimes(route, serviceDate) {
+				stops[i] = tripStop{
+					StationCode: stop.StationCode,
+					Arrival:     stop.Arrival,
+					Departure:   stop.Departure,
+					DistanceKm:  route.Stations[i].DistanceKm,
+				}
+			}
+
+			trips = append(trips, trip{Train: route.Train, Stops: stops})
+		}
+	}
+	return trips
+}
+
+// searchItineraries walks the trip graph depth first. From a station it boards every trip
+// that calls there (after the minimum connection time when changing trains), rides it to
+// each later stop and either reaches the destination or changes again while transfers
+// remain. The first train must leave the origin on the travel date. A trip is used once
+// and a station visited once per itinerary. Each leg is priced with fare.
+func searchItineraries(trips []trip, from, to string, travelDate time.Time, opts JourneyOptions, fare func(train entities.Train, distanceKm int) int) []Itinerary {
+	dayStart := serviceMidnight(travelDate)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	type stopRef struct{ trip, stop int }
+
+	byStation := make(map[string][]stopRef)
+	for i, t := range trips {
+		for j, stop := range t.Stops {
+			byStation[stop.StationCode] = append(byStation[stop.StationCode], stopRef{trip: i, stop: j})
+		}
+	}
+
+	var itineraries []Itinerary
+	usedTrips := make(map[int]bool)
+	visited := map[string]bool{from: true}
+
+	var walk func(station string, readyAt time.Time, legs []JourneyLeg)
+	walk = func(station string, readyAt time.Time, legs []JourneyLeg) {
+		for _, ref := range byStation[station] {
+			if usedTrips[ref.trip] {
+				continue
+			}
+
+			t := trips[ref.trip]
+			board := t.Stops[ref.stop]
+			if len(legs) == 0 && (board.Departure.Before(dayStart) || !board.Departure.Before(dayEnd)) {
+				continue
+			}
+			if len(legs) > 0 && board.Departure.Before(readyAt) {
+				continue
+			}
+
+			usedTrips[ref.trip] = true
+			for k := ref.stop + 1; k < len(t.Stops); k++ {
+				alight := t.Stops[k]
+				if visited[alight.StationCode] {
+					continue
+				}
+
+				next := append(legs[:len(legs):len(legs)], JourneyLeg{
+					TrainCode:       t.Train.Code,
+					TrainName:       t.Train.Name,
+					FromStationCode: board.StationCode,
+					ToStationCode:   alight.StationCode,
+					Departure:       board.Departure,
+					Arrival:         alight.Arrival,
+					Fare:            fare(t.Train, alight.DistanceKm-board.DistanceKm),
+				})
+
+				if alight.StationCode == to {
+					itineraries = append(itineraries, newItinerary(next))
+					break
+				}
+
+				if len(next)-1 < opts.MaxTransfers {
+					visited[alight.StationCode] = true
+					walk(alight.StationCode, alight.Arrival.Add(opts.MinConnection), next)
+					delete(visited, alight.StationCode)
+				}
+			}
+			delete(usedTrips, ref.trip)
+		}
+	}
+
+	walk(from, time.Time{}, nil)
+	return itineraries
+}
+
+func newItinerary(legs []JourneyLeg) Itinerary {
+	itinerary := Itinerary{
+		Legs:      legs,
+		Departure: legs[0].Departure,
+		Arrival:   legs[len(legs)-1].Arrival,
+		Transfers: len(legs) - 1,
+	}
+	for _, leg := range legs {
+		itinerary.TotalFare += leg.Fare
+	}
+	itinerary.DurationMinutes = int(itinerary.Arrival.Sub(itinerary.Departure).Minutes())
+	return itinerary
+}
+
+// sortItineraries orders the itineraries by the requested key and breaks ties with the others.
+func sortItineraries(itineraries []Itinerary, sortBy string) {
+	byArrival := func(a, b Itinerary) int { return a.Arrival.Compare(b.Arrival) }
+	byChanges := func(a, b Itinerary) int { return a.Transfers - b.Transfers }
+	byFare := func(a, b Itinerary) int { return a.TotalFare - b.TotalFare }
+
+	var keys []func(a, b Itinerary) int
+	switch sortBy {
+	case JourneySortChanges:
+		keys = []func(a, b Itinerary) int{byChanges, byArrival, byFare}
+	case JourneySortFare:
+		keys = []func(a, b Itinerary) int{byFare, byArrival, byChanges}
+	default:
+		keys = []func(a, b Itinerary) int{byArrival, byChanges, byFare}
+	}
+
+	sort.SliceStable(itineraries, func(i, j int) bool {
+		for _, key := range keys {
+			if c := key(itineraries[i], itineraries[j]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// maxPassengerAge bounds the date of birth of a passenger.
+const maxPassengerAge = 120
+
+// passengerTitles are the titles a passenger may be addressed with.
+var passengerTitles = map[string]bool{
+	"mr":     true,
+	"mrs":    true,
+	"ms":     true,
+	"miss":   true,
+	"master": true,
+}
+
+var (
+	passportNumberPattern = regexp.MustCompile(`^[A-Z0-9]{6,9}$`)
+	countryCodePattern    = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// PassengerSelection names the passenger of one ticket: a passenger saved on the user's
+// profile, or the Passenger entered with the booking when SavedPassengerID is 0. Concession
+// is the code of the concession rule the passenger travels on, if any.
+type PassengerSelection struct {
+	SavedPassengerID uint
+	Passenger        entities.Passenger
+	Concession       string
+	HeightCm         int // Declared height, for concessions with a height limit
+}
+
+type PassengerService struct {
+	repo interfaces.PassengerRepository
+}
+
+func NewPassengerService(repo interfaces.PassengerRepository) *PassengerService {
+	return &PassengerService{repo: repo}
+}
+
+func (s *PassengerService) GetSavedPassengers(userID uint) ([]entities.Passenger, error) {
+	return s.repo.GetSavedPassengers(userID)
+}
+
+// SavePassenger adds a frequent passenger to the user's profile.
+func (s *PassengerService) SavePassenger(userID uint, passenger entities.Passenger) (entities.Passenger, error) {
+	if userID == 0 {
+		return entities.Passenger{}, errors.New("user ID is required")
+	}
+	if err := normalizePassenger(&passenger); err != nil {
+		return entities.Passenger{}, err
+	}
+
+	passenger.ID = 0
+	passenger.UserID = userID
+	passenger.Saved = true
+	passenger.ModifyBy = userID
+	return s.repo.CreatePassenger(passenger)
+}
+
+// UpdatePassenger changes a passenger saved on the user's profile. Tickets issued to the
+// passenger keep the details they were issued with.
+func (s *PassengerService) UpdatePassenger(id, userID uint, passenger entities.Passenger) (entities.Passenger, error) {
+	existing, err := s.savedPassenger(id, userID)
+	if err != nil {
+		return entities.Passenger{}, err
+	}
+	if err := normalizePassenger(&passenger); err != nil {
+		return entities.Passenger{}, err
+	}
+
+	passenger.Model = existing.Model
+	passenger.UserID = existing.UserID
+	passenger.Saved = true
+	passenger.ModifyBy = userID
+	return s.repo.UpdatePassenger(passenger)
+}
+
+func (s *PassengerService) DeletePassenger(id, userID uint) error {
+	if _, err := s.savedPassenger(id, userID); err != nil {
+		return err
+	}
+	return s.repo.DeletePassenger(id)
+}
+
+// savedPassenger returns the passenger if it is saved on the user's profile.
+func (s *PassengerService) savedPassenger(id, userID uint) (entities.Passenger, error) {
+	passenger, err := s.repo.GetPassenger(id)
+	if err != nil {
+		return entities.Passenger{}, err
+	}
+	if passenger.UserID != userID || !passenger.Saved {
+		return entities.Passenger{}, interfaces.ErrPassengerNotFound
+	}
+	return passenger, nil
+}
+
+// bookingPassengers returns the passengers of a booking's tickets, in order. Each one is a
+// new copy, so the tickets keep their details when a saved passenger is edited later.
+func (s *PassengerService) bookingPassengers(userID uint, selections []PassengerSelection) ([]entities.Passenger, error) {
+	passengers := make([]entities.Passenger, len(selections))
+	for i, selection := range selections {
+		passenger := selection.Passenger
+		if selection.SavedPassengerID != 0 {
+			saved, err := s.savedPassenger(selection.SavedPassengerID, userID)
+			if err != nil {
+				return nil, fmt.Errorf("passenger %d: %w", i+1, err)
+			}
+			passenger = saved
+		}
+		if err := normalizePassenger(&passenger); err != nil {
+			return nil, fmt.Errorf("passenger %d: %w", i+1, err)
+		}
+
+		passenger.Model = entities.Passenger{}.Model
+		passenger.UserID = userID
+		passenger.Saved = false
+		passenger.ModifyBy = userID
+		passenger.User = nil
+		passengers[i] = passenger
+	}
+	return passengers, nil
+}
+
+// normalizePassenger tidies up what was typed in and validates the passenger. Thai
+// national ID holders need their Thai name, passport holders the English name printed in
+// the passport.
+func normalizePassenger(p *entities.Passenger) error {
+	p.Title = strings.ToLower(strings.TrimSpace(p.Title))
+	p.FirstNameTh = strings.TrimSpace(p.FirstNameTh)
+	p.LastNameTh = strings.TrimSpace(p.LastNameTh)
+	p.FirstNameEn = strings.TrimSpace(p.FirstNameEn)
+	p.LastNameEn = strings.TrimSpace(p.LastNameEn)
+	p.DocumentNumber = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(p.DocumentNumber))
+	p.Country = strings.ToUpper(strings.TrimSpace(p.Country))
+
+	if !passengerTitles[p.Title] {
+		return fmt.Errorf("invalid title %q", p.Title)
+	}
+
+	switch p.DocumentType {
+	case entities.PassengerDocumentNationalID:
+		if p.Country == "" {
+			p.Country = "TH"
+		}
+		if p.Country != "TH" {
+			return errors.New("a Thai national ID is issued by TH")
+		}
+		if err := ValidateThaiNationalID(p.DocumentNumber); err != nil {
+			return err
+		}
+		if p.FirstNameTh == "" || p.LastNameTh == "" {
+			return errors.New("Thai first and last name are required for a Thai national ID")
+		}
+	case entities.PassengerDocumentPassport:
+		if !passportNumberPattern.MatchString(p.DocumentNumber) {
+			return errors.New("passport number must be 6 to 9 letters or digits")
+		}
+		if !countryCodePattern.MatchString(p.Country) {
+			return errors.New("passport country must be a two letter country code")
+		}
+		if p.FirstNameEn == "" || p.LastNameEn == "" {
+			return errors.New("English first and last name are required for a passport")
+		}
+	default:
+		return fmt.Errorf("document type must be %s or %s", entities.PassengerDocumentNationalID, entities.PassengerDocumentPassport)
+	}
+
+	today := time.Now()
+	if p.DateOfBirth.IsZero() {
+		return errors.New("date of birth is required")
+	}
+	if p.DateOfBirth.After(today) || p.DateOfBirth.Before(today.AddDate(-maxPassengerAge, 0, 0)) {
+		return errors.New("invalid date of birth")
+	}
+	return nil
+}
+
+// ValidateThaiNationalID checks the 13 digits of a Thai national ID and its check digit:
+// the first 12 digits weighted 13 down to 2, summed, then (11 - sum mod 11) mod 10.
+func ValidateThaiNationalID(id string) error {
+	if len(id) != 13 {
+		return errors.New("Thai national ID must have 13 digits")
+	}
+
+	sum := 0
+	for i, r := range id {
+		if r < '0' || r > '9' {
+			return errors.New("Thai national ID must have 13 digits")
+		}
+		if i < 12 {
+			sum += int(r-'0') * (13 - i)
+		}
+	}
+
+	if (11-sum%11)%10 != int(id[12]-'0') {
+		return errors.New("invalid Thai national ID")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package services
+
+import "testing"
+
+func TestValidateThaiNationalID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantErr bool
+	}{
+		{name: "valid", id: "1101700207366"},
+		{name: "valid with check digit 0", id: "3100600445040"},
+		{name: "bad check digit", id: "1101700207367", wantErr: true},
+		{name: "too short", id: "110170020736", wantErr: true},
+		{name: "too long", id: "11017002073660", wantErr: true},
+		{name: "empty", id: "", wantErr: true},
+		{name: "letters", id: "110170020736A", wantErr: true},
+		{name: "dashes", id: "1-1017-00207-36-6", wantErr: true},
+		{name: "thai digits", id: "๑๑๐๑๗๐๐๒๐๗๓๖๖", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateThaiNationalID(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateThaiNationalID(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"net/http"
+	"time"
+)
+
+// PaymentIntent is a payment the provider is ready to receive. The customer pays it, for
+// example by scanning QRPayload, before ExpiresAt.
+type PaymentIntent struct {
+	ProviderRef string
+	Amount      int
+	QRPayload   string
+	ExpiresAt   time.Time
+}
+
+// PaymentEvent is a change of a payment's status reported by its provider. EventID
+// identifies the report itself, so a report the provider sends again can be recognised.
+type PaymentEvent struct {
+	EventID     string
+	ProviderRef string
+	Status      string // One of the entities.PaymentStatus values
+	Amount      int
+	OccurredAt  time.Time
+}
+
+// PaymentProvider collects money through a payment gateway. Implementations can be
+// swapped, e.g. a fake provider for local testing.
+type PaymentProvider interface {
+	// Name is how payments refer to the provider.
+	Name() string
+	// CreateIntent asks the provider to collect amount baht for the booking reference
+	// until expiresAt.
+	CreateIntent(reference string, amount int, expiresAt time.Time) (PaymentIntent, error)
+	// QueryStatus returns the current status of the payment as an entities.PaymentStatus
+	// value.
+	QueryStatus(providerRef string) (string, error)
+	// Refund sends amount baht of the payment back to the customer. A refund sent again
+	// with the same idempotency key is not paid out twice. It returns
+	// interfaces.ErrRefundNotSupported when the money has to be sent back by hand.
+	Refund(providerRef, idempotencyKey string, amount int) error
+	// VerifyWebhook checks that a webhook came from the provider and returns the event it
+	// reports, or interfaces.ErrInvalidWebhookSignature when the signature does not match.
+	VerifyWebhook(body []byte, header http.Header) (PaymentEvent, error)
+}
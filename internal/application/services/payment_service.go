@@ -0,0 +1,286 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// paymentTransitions lists the statuses a payment may move to from each status. Providers
+// may report out of order, so money reported received after a failure still counts. A
+// payment that succeeded is final, money goes back through refunds.
+var paymentTransitions = map[string][]string{
+	entities.PaymentStatusPending: {
+		entities.PaymentStatusSucceeded, entities.PaymentStatusFailed, entities.PaymentStatusExpired,
+	},
+	entities.PaymentStatusFailed:  {entities.PaymentStatusSucceeded},
+	entities.PaymentStatusExpired: {entities.PaymentStatusSucceeded},
+}
+
+// canTransitionPayment reports whether a payment may move from one status to another.
+func canTransitionPayment(from, to string) bool {
+	for _, next := range paymentTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+type PaymentService struct {
+	repo      interfaces.PaymentRepository
+	bookings  *BookingService
+	refunds   *RefundService
+	providers map[string]PaymentProvider
+	// defaultProvider is used when a payment does not name one
+	defaultProvider string
+}
+
+// NewPaymentService returns a payment service taking payments through the providers. The
+// first provider is the default one.
+func NewPaymentService(repo interfaces.PaymentRepository, bookings *BookingService, refunds *RefundService, providers ...PaymentProvider) *PaymentService {
+	s := &PaymentService{repo: repo, bookings: bookings, refunds: refunds, providers: make(map[string]PaymentProvider)}
+	for _, provider := range providers {
+		if s.defaultProvider == "" {
+			s.defaultProvider = provider.Name()
+		}
+		s.providers[provider.Name()] = provider
+	}
+	return s
+}
+
+// provider returns the provider with the name, or the default provider for an empty name.
+func (s *PaymentService) provider(name string) (PaymentProvider, error) {
+	if name == "" {
+		name = s.defaultProvider
+	}
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", interfaces.ErrPaymentProviderUnknown, name)
+	}
+	return provider, nil
+}
+
+// CreatePayment starts paying for the user's held booking through the provider. The
+// booking waits for the payment until its hold runs out. A booking already waiting for a
+// payment gets a new one, e.g. when the customer switches to another provider.
+func (s *PaymentService) CreatePayment(bookingID, userID uint, role, providerName string) (entities.Payment, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+
+	booking, err := s.bookings.GetBooking(bookingID, userID, role)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	if booking.TotalPrice <= 0 {
+		return entities.Payment{}, fmt.Errorf("booking %s has nothing to pay, confirm the hold instead", booking.Reference)
+	}
+
+	switch booking.Status {
+	case entities.BookingStatusHeld:
+		booking, err = s.bookings.TransitionBooking(booking.ID, entities.BookingStatusPendingPayment, &userID, "payment started")
+		if err != nil {
+			return entities.Payment{}, err
+		}
+	case entities.BookingStatusPendingPayment:
+	case entities.BookingStatusExpired:
+		return entities.Payment{}, interfaces.ErrHoldExpired
+	default:
+		return entities.Payment{}, fmt.Errorf("%w: booking %s is %s and cannot be paid", interfaces.ErrInvalidBookingTransition,
+			booking.Reference, booking.Status)
+	}
+
+	expiresAt := time.Now().Add(DefaultHoldDuration)
+	if booking.HoldExpiresAt != nil {
+		expiresAt = *booking.HoldExpiresAt
+	}
+
+	intent, err := provider.CreateIntent(booking.Reference, booking.TotalPrice, expiresAt)
+	if err != nil {
+		return entities.Payment{}, fmt.Errorf("failed to create %s payment: %v", provider.Name(), err)
+	}
+
+	return s.repo.CreatePayment(entities.Payment{
+		BookingID:   booking.ID,
+		Provider:    provider.Name(),
+		ProviderRef: intent.ProviderRef,
+		Amount:      intent.Amount,
+		Status:      entities.PaymentStatusPending,
+		QRPayload:   intent.QRPayload,
+		ExpiresAt:   &intent.ExpiresAt,
+		ModifyBy:    userID,
+	})
+}
+
+// GetPayment returns the payment if its booking belongs to the user. Admins may read any
+// payment.
+func (s *PaymentService) GetPayment(id, userID uint, role string) (entities.Payment, error) {
+	payment, err := s.repo.GetPaymentByID(id)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	if _, err := s.bookings.GetBooking(payment.BookingID, userID, role); err != nil {
+		if errors.Is(err, interfaces.ErrBookingNotFound) {
+			return entities.Payment{}, interfaces.ErrPaymentNotFound
+		}
+		return entities.Payment{}, err
+	}
+	return payment, nil
+}
+
+// GetPayments returns the payments of the booking if it belongs to the user. Admins may
+// read the payments of any booking.
+func (s *PaymentService) GetPayments(bookingID, userID uint, role string) ([]entities.Payment, error) {
+	if _, err := s.bookings.GetBooking(bookingID, userID, role); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPaymentsByBooking(bookingID)
+}
+
+// RefreshPayment asks the provider for the status of the user's pending payment and
+// applies it. A payment that succeeded already is settled again, in case confirming its
+// booking failed.
+func (s *PaymentService) RefreshPayment(id, userID uint, role string) (entities.Payment, error) {
+	payment, err := s.GetPayment(id, userID, role)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	if payment.Status == entities.PaymentStatusSucceeded {
+		payment, _, err = s.applyStatus(payment, entities.PaymentStatusSucceeded, nil)
+		return payment, err
+	}
+
+	provider, err := s.provider(payment.Provider)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	status, err := provider.QueryStatus(payment.ProviderRef)
+	if err != nil {
+		return entities.Payment{}, fmt.Errorf("failed to query %s payment: %v", provider.Name(), err)
+	}
+
+	payment, _, err = s.applyStatus(payment, status, nil)
+	return payment, err
+}
+
+// ConfirmPayment lets staff mark a payment as received, e.g. a transfer they can see in
+// the bank account but that was never reported to us.
+func (s *PaymentService) ConfirmPayment(id, modifyBy uint) (entities.Payment, error) {
+	if modifyBy == 0 {
+		return entities.Payment{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+	payment, err := s.repo.GetPaymentByID(id)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	payment, _, err = s.applyStatus(payment, entities.PaymentStatusSucceeded, &modifyBy)
+	return payment, err
+}
+
+// applyStatus moves the payment to the status the provider reported and reports whether
+// anything changed. A payment that succeeded confirms its booking. A payment settles only
+// once, a status reported again or too late is ignored. Success reported again settles the
+// booking again, as confirming it may have failed after the payment was saved.
+func (s *PaymentService) applyStatus(payment entities.Payment, status string, modifyBy *uint) (entities.Payment, bool, error) {
+	var paidAt *time.Time
+	if status == entities.PaymentStatusSucceeded {
+		now := time.Now()
+		paidAt = &now
+	}
+
+	for canTransitionPayment(payment.Status, status) {
+		updated, err := s.repo.UpdatePaymentStatus(payment.ID, payment.Status, status, paidAt)
+		if errors.Is(err, interfaces.ErrPaymentStatusChanged) {
+			// Settled in the meantime, e.g. by a webhook racing the status query
+			if payment, err = s.repo.GetPaymentByID(payment.ID); err != nil {
+				return entities.Payment{}, false, err
+			}
+			continue
+		}
+		if err != nil {
+			return entities.Payment{}, false, err
+		}
+
+		if updated.Status == entities.PaymentStatusSucceeded {
+			if err := s.confirmBooking(updated, modifyBy); err != nil {
+				return entities.Payment{}, false, err
+			}
+		}
+		return updated, true, nil
+	}
+
+	if payment.Status == entities.PaymentStatusSucceeded && status == entities.PaymentStatusSucceeded {
+		settled, err := s.settled(payment)
+		if err != nil || settled {
+			return payment, false, err
+		}
+		if err := s.confirmBooking(payment, modifyBy); err != nil {
+			return entities.Payment{}, false, err
+		}
+		return payment, true, nil
+	}
+	return payment, false, nil
+}
+
+// settled reports whether the payment that succeeded has done its part: it is refunded on
+// its own, or it is the payment the booking was confirmed with.
+func (s *PaymentService) settled(payment entities.Payment) (bool, error) {
+	booking, err := s.bookings.repo.GetBookingByID(payment.BookingID)
+	if err != nil {
+		return false, err
+	}
+	refunds, err := s.refunds.GetRefunds(booking.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, refund := range refunds {
+		if refund.PaymentID != nil && *refund.PaymentID == payment.ID {
+			return true, nil
+		}
+	}
+
+	switch booking.Status {
+	case entities.BookingStatusHeld, entities.BookingStatusPendingPayment, entities.BookingStatusExpired:
+		return false, nil
+	}
+	paid, err := s.refunds.bookingPayment(booking.ID)
+	if err != nil {
+		return false, err
+	}
+	return paid != nil && paid.ID == payment.ID, nil
+}
+
+// confirmBooking confirms the booking of a payment that succeeded. When the booking can no
+// longer be confirmed, because its hold ran out, it was cancelled or its promotion was used
+// up while it waited for the payment, the money is owed back and a pending refund is
+// created instead.
+func (s *PaymentService) confirmBooking(payment entities.Payment, modifyBy *uint) error {
+	_, err := s.bookings.TransitionBooking(payment.BookingID, entities.BookingStatusConfirmed, modifyBy,
+		fmt.Sprintf("paid by %s payment %s", payment.Provider, payment.ProviderRef))
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, interfaces.ErrHoldExpired) && !errors.Is(err, interfaces.ErrInvalidBookingTransition) &&
+		!errors.Is(err, interfaces.ErrPromotionExhausted) {
+		return fmt.Errorf("payment %s succeeded but its booking could not be confirmed: %w", payment.ProviderRef, err)
+	}
+
+	log.Printf("Payment %s arrived for a booking that cannot be confirmed, refunding: %v", payment.ProviderRef, err)
+	if _, err := s.refunds.CreateRefund(entities.Refund{
+		BookingID: payment.BookingID,
+		PaymentID: &payment.ID,
+		Amount:    payment.Amount,
+		Status:    entities.RefundStatusPending,
+		Reason:    fmt.Sprintf("payment %s received after the booking could no longer be confirmed", payment.ProviderRef),
+		ModifyBy:  payment.ModifyBy,
+	}); err != nil {
+		return fmt.Errorf("failed to refund payment %s: %w", payment.ProviderRef, err)
+	}
+	return nil
+}
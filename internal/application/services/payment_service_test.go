@@ -0,0 +1,214 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// memoryBookingRepository serves bookings from memory. Status changes fail with
+// transitionErr and change nothing.
+type memoryBookingRepository struct {
+	interfaces.BookingRepository
+	bookings      map[uint]entities.Booking
+	transitionErr error
+}
+
+func (r *memoryBookingRepository) GetBookingByID(id uint) (entities.Booking, error) {
+	booking, ok := r.bookings[id]
+	if !ok {
+		return entities.Booking{}, interfaces.ErrBookingNotFound
+	}
+	return booking, nil
+}
+
+func (r *memoryBookingRepository) TransitionBooking(change entities.BookingHistory, releaseSeats bool, refund *entities.Refund) (entities.Booking, error) {
+	return entities.Booking{}, r.transitionErr
+}
+
+// memoryRefundRepository keeps refunds in memory.
+type memoryRefundRepository struct {
+	interfaces.RefundRepository
+	refunds []entities.Refund
+}
+
+func (r *memoryRefundRepository) CreateRefund(refund entities.Refund) (entities.Refund, error) {
+	refund.ID = uint(len(r.refunds) + 1)
+	r.refunds = append(r.refunds, refund)
+	return refund, nil
+}
+
+func (r *memoryRefundRepository) GetRefundsByBooking(bookingID uint) ([]entities.Refund, error) {
+	var refunds []entities.Refund
+	for _, refund := range r.refunds {
+		if refund.BookingID == bookingID {
+			refunds = append(refunds, refund)
+		}
+	}
+	return refunds, nil
+}
+
+func (r *memoryRefundRepository) GetRefund(id uint) (entities.Refund, error) {
+	if id == 0 || int(id) > len(r.refunds) {
+		return entities.Refund{}, interfaces.ErrRefundNotFound
+	}
+	return r.refunds[id-1], nil
+}
+
+func (r *memoryRefundRepository) UpdateRefund(refund entities.Refund, fromStatus string) (entities.Refund, error) {
+	if r.refunds[refund.ID-1].Status != fromStatus {
+		return entities.Refund{}, interfaces.ErrRefundStatusChanged
+	}
+	r.refunds[refund.ID-1] = refund
+	return refund, nil
+}
+
+func (r *memoryRefundRepository) CreateRefundAttempt(attempt entities.RefundAttempt) (entities.RefundAttempt, error) {
+	return attempt, nil
+}
+
+func TestSucceededPaymentSettlesOnReplay(t *testing.T) {
+	paidAt := time.Now()
+	tests := []struct {
+		name        string
+		status      string // Of the booking
+		wantState   string
+		wantRefunds int
+	}{
+		{
+			// Confirming failed after the payment was saved and the hold ran out since
+			name:        "booking expired",
+			status:      entities.BookingStatusExpired,
+			wantState:   entities.PaymentEventStateApplied,
+			wantRefunds: 1,
+		},
+		{
+			name:      "booking confirmed by the payment",
+			status:    entities.BookingStatusConfirmed,
+			wantState: entities.PaymentEventStateIgnored,
+		},
+		{
+			name:      "booking cancelled after the payment confirmed it",
+			status:    entities.BookingStatusCancelled,
+			wantState: entities.PaymentEventStateIgnored,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewPromptPayProvider(PromptPayConfig{ProxyID: "0812345678", WebhookSecret: "secret"})
+			if err != nil {
+				t.Fatalf("NewPromptPayProvider() error = %v", err)
+			}
+			paymentRepo := &memoryPaymentRepository{}
+			payment, err := paymentRepo.CreatePayment(entities.Payment{
+				BookingID:   1,
+				Provider:    provider.Name(),
+				ProviderRef: "PPBK00000000AABBCCDD",
+				Amount:      150,
+				Status:      entities.PaymentStatusSucceeded,
+				PaidAt:      &paidAt,
+				ModifyBy:    7,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			refundRepo := &memoryRefundRepository{}
+			booking := entities.Booking{Reference: "BK00000000", TotalPrice: 150, Status: tt.status}
+			booking.ID = 1
+			bookings := &BookingService{repo: &memoryBookingRepository{bookings: map[uint]entities.Booking{1: booking}}}
+			refunds := &RefundService{repo: refundRepo, paymentRepo: paymentRepo}
+			service := NewPaymentService(paymentRepo, bookings, refunds, provider)
+
+			for _, eventID := range []string{"evt-1", "evt-2"} {
+				body := []byte(`{"event_id":"` + eventID + `","reference":"PPBK00000000AABBCCDD","status":"succeeded","amount":150}`)
+				event, err := service.HandleWebhook(provider.Name(), body, signedHeader("secret", body))
+				if err != nil {
+					t.Fatalf("HandleWebhook() error = %v", err)
+				}
+				want := tt.wantState
+				if eventID == "evt-2" {
+					// Settled by the first event
+					want = entities.PaymentEventStateIgnored
+				}
+				if event.State != want {
+					t.Errorf("event %s = %s (%s), want %s", eventID, event.State, event.Error, want)
+				}
+			}
+
+			if len(refundRepo.refunds) != tt.wantRefunds {
+				t.Fatalf("%d refunds created, want %d", len(refundRepo.refunds), tt.wantRefunds)
+			}
+			for _, refund := range refundRepo.refunds {
+				if refund.PaymentID == nil || *refund.PaymentID != payment.ID || refund.Amount != payment.Amount {
+					t.Errorf("refund %+v does not give back payment %d", refund, payment.ID)
+				}
+			}
+			if paymentRepo.statusUpdates != 0 {
+				t.Errorf("payment status changed %d times", paymentRepo.statusUpdates)
+			}
+		})
+	}
+}
+
+func TestPromotionUsedUpBeforePayment(t *testing.T) {
+	skipWithoutRedis(t)
+
+	provider, err := NewPromptPayProvider(PromptPayConfig{ProxyID: "0812345678", WebhookSecret: "secret"})
+	if err != nil {
+		t.Fatalf("NewPromptPayProvider() error = %v", err)
+	}
+	paymentRepo := &memoryPaymentRepository{}
+	payment, err := paymentRepo.CreatePayment(entities.Payment{
+		BookingID:   1,
+		Provider:    provider.Name(),
+		ProviderRef: "PPBK00000001AABBCCDD",
+		Amount:      135,
+		Status:      entities.PaymentStatusPending,
+		ModifyBy:    7,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hold is still alive, but the last redemption of its promotion went to another
+	// booking confirmed in the meantime
+	booking := entities.Booking{
+		Reference:     "BK00000001",
+		TotalPrice:    135,
+		Discount:      15,
+		PromotionCode: "SONGKRAN10",
+		Status:        entities.BookingStatusPendingPayment,
+	}
+	booking.ID = 1
+	if err := redisClient.Set(ctx, holdKey(booking.Reference), booking.ID, time.Minute).Err(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redisClient.Del(ctx, holdKey(booking.Reference)) })
+
+	bookingRepo := &memoryBookingRepository{
+		bookings:      map[uint]entities.Booking{1: booking},
+		transitionErr: fmt.Errorf("%w: %s", interfaces.ErrPromotionExhausted, booking.PromotionCode),
+	}
+	refundRepo := &memoryRefundRepository{}
+	service := NewPaymentService(paymentRepo, &BookingService{repo: bookingRepo},
+		&RefundService{repo: refundRepo, paymentRepo: paymentRepo}, provider)
+
+	body := []byte(`{"event_id":"evt-1","reference":"PPBK00000001AABBCCDD","status":"succeeded","amount":135}`)
+	event, err := service.HandleWebhook(provider.Name(), body, signedHeader("secret", body))
+	if err != nil {
+		t.Fatalf("HandleWebhook() error = %v", err)
+	}
+	if event.State != entities.PaymentEventStateApplied {
+		t.Errorf("event = %s (%s), want applied", event.State, event.Error)
+	}
+	if len(refundRepo.refunds) != 1 {
+		t.Fatalf("%d refunds created, want 1", len(refundRepo.refunds))
+	}
+	if refund := refundRepo.refunds[0]; refund.PaymentID == nil || *refund.PaymentID != payment.ID || refund.Amount != payment.Amount {
+		t.Errorf("refund %+v does not give back payment %d", refund, payment.ID)
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// HandleWebhook verifies a notification sent by the provider, stores it and applies it to
+// its payment. An event the provider sent before is not applied again unless it failed
+// last time. An event that cannot be applied is returned in the failed state, it is kept
+// for the provider's retry or an admin's replay.
+func (s *PaymentService) HandleWebhook(providerName string, body []byte, header http.Header) (entities.PaymentWebhookEvent, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return entities.PaymentWebhookEvent{}, fmt.Errorf("%w: %q", interfaces.ErrPaymentProviderUnknown, providerName)
+	}
+
+	reported, err := provider.VerifyWebhook(body, header)
+	if err != nil {
+		return entities.PaymentWebhookEvent{}, err
+	}
+
+	event, created, err := s.repo.CreateWebhookEvent(entities.PaymentWebhookEvent{
+		Provider:    provider.Name(),
+		EventID:     reported.EventID,
+		ProviderRef: reported.ProviderRef,
+		Status:      reported.Status,
+		Amount:      reported.Amount,
+		OccurredAt:  reported.OccurredAt,
+		Payload:     string(body),
+		State:       entities.PaymentEventStateReceived,
+	})
+	if err != nil {
+		return entities.PaymentWebhookEvent{}, err
+	}
+	if !created && event.State != entities.PaymentEventStateFailed {
+		return event, nil
+	}
+
+	return s.processEvent(event, nil)
+}
+
+// ReplayEvent applies a stored event again, e.g. after the payment it could not find was
+// created. An event that was applied already is left as it is.
+func (s *PaymentService) ReplayEvent(id, modifyBy uint) (entities.PaymentWebhookEvent, error) {
+	if modifyBy == 0 {
+		return entities.PaymentWebhookEvent{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+	event, err := s.repo.GetWebhookEvent(id)
+	if err != nil {
+		return entities.PaymentWebhookEvent{}, err
+	}
+	if event.State == entities.PaymentEventStateApplied {
+		return event, nil
+	}
+	return s.processEvent(event, &modifyBy)
+}
+
+func (s *PaymentService) GetWebhookEvents(state string, offset, limit int) ([]entities.PaymentWebhookEvent, int64, error) {
+	return s.repo.GetWebhookEvents(state, offset, limit)
+}
+
+// processEvent applies the event to its payment and records the outcome on the event.
+func (s *PaymentService) processEvent(event entities.PaymentWebhookEvent, modifyBy *uint) (entities.PaymentWebhookEvent, error) {
+	state, err := s.applyEvent(event, modifyBy)
+
+	now := time.Now()
+	event.State = state
+	event.Error = ""
+	event.Attempts++
+	event.ProcessedAt = &now
+	event.ModifyBy = modifyBy
+	if err != nil {
+		event.State = entities.PaymentEventStateFailed
+		event.Error = err.Error()
+	}
+
+	return s.repo.UpdateWebhookEvent(event)
+}
+
+// applyEvent moves the payment of the event to the reported status. It returns whether the
+// event was applied or had nothing left to change.
+func (s *PaymentService) applyEvent(event entities.PaymentWebhookEvent, modifyBy *uint) (string, error) {
+	payment, err := s.repo.GetPaymentByProviderRef(event.ProviderRef)
+	if err != nil {
+		return "", err
+	}
+	if payment.Provider != event.Provider {
+		return "", fmt.Errorf("payment %s was not made through %s", payment.ProviderRef, event.Provider)
+	}
+	if event.Status == entities.PaymentStatusSucceeded && event.Amount != payment.Amount {
+		return "", fmt.Errorf("payment %s was reported paid with %d baht but %d baht are due",
+			payment.ProviderRef, event.Amount, payment.Amount)
+	}
+
+	_, changed, err := s.applyStatus(payment, event.Status, modifyBy)
+	if err != nil {
+		return "", err
+	}
+	if !changed {
+		return entities.PaymentEventStateIgnored, nil
+	}
+	return entities.PaymentEventStateApplied, nil
+}
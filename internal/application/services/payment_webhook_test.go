@@ -0,0 +1,250 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// memoryPaymentRepository keeps payments and webhook events in memory and counts the
+// status changes it made.
+type memoryPaymentRepository struct {
+	mu            sync.Mutex
+	payments      []entities.Payment
+	events        []entities.PaymentWebhookEvent
+	statusUpdates int
+}
+
+func (r *memoryPaymentRepository) CreatePayment(payment entities.Payment) (entities.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	payment.ID = uint(len(r.payments) + 1)
+	r.payments = append(r.payments, payment)
+	return payment, nil
+}
+
+func (r *memoryPaymentRepository) GetPaymentByID(id uint) (entities.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == 0 || int(id) > len(r.payments) {
+		return entities.Payment{}, interfaces.ErrPaymentNotFound
+	}
+	return r.payments[id-1], nil
+}
+
+func (r *memoryPaymentRepository) GetPaymentByProviderRef(providerRef string) (entities.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, payment := range r.payments {
+		if payment.ProviderRef == providerRef {
+			return payment, nil
+		}
+	}
+	return entities.Payment{}, interfaces.ErrPaymentNotFound
+}
+
+func (r *memoryPaymentRepository) GetPaymentsByBooking(bookingID uint) ([]entities.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var payments []entities.Payment
+	for _, payment := range r.payments {
+		if payment.BookingID == bookingID {
+			payments = append(payments, payment)
+		}
+	}
+	return payments, nil
+}
+
+func (r *memoryPaymentRepository) GetPaymentsByProviderRefs(providerRefs []string) ([]entities.Payment, error) {
+	var payments []entities.Payment
+	for _, providerRef := range providerRefs {
+		if payment, err := r.GetPaymentByProviderRef(providerRef); err == nil {
+			payments = append(payments, payment)
+		}
+	}
+	return payments, nil
+}
+
+func (r *memoryPaymentRepository) GetSucceededPayments(provider string, from, to time.Time) ([]entities.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var payments []entities.Payment
+	for _, payment := range r.payments {
+		if payment.Provider == provider && payment.Status == entities.PaymentStatusSucceeded &&
+			payment.PaidAt != nil && !payment.PaidAt.Before(from) && payment.PaidAt.Before(to) {
+			payments = append(payments, payment)
+		}
+	}
+	return payments, nil
+}
+
+func (r *memoryPaymentRepository) UpdatePaymentStatus(id uint, fromStatus, toStatus string, paidAt *time.Time) (entities.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	payment := &r.payments[id-1]
+	if payment.Status != fromStatus {
+		return entities.Payment{}, interfaces.ErrPaymentStatusChanged
+	}
+	payment.Status = toStatus
+	payment.PaidAt = paidAt
+	r.statusUpdates++
+	return *payment, nil
+}
+
+func (r *memoryPaymentRepository) CreateWebhookEvent(event entities.PaymentWebhookEvent) (entities.PaymentWebhookEvent, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.events {
+		if stored.Provider == event.Provider && stored.EventID == event.EventID {
+			return stored, false, nil
+		}
+	}
+	event.ID = uint(len(r.events) + 1)
+	r.events = append(r.events, event)
+	return event, true, nil
+}
+
+func (r *memoryPaymentRepository) UpdateWebhookEvent(event entities.PaymentWebhookEvent) (entities.PaymentWebhookEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[event.ID-1] = event
+	return event, nil
+}
+
+func (r *memoryPaymentRepository) GetWebhookEvent(id uint) (entities.PaymentWebhookEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == 0 || int(id) > len(r.events) {
+		return entities.PaymentWebhookEvent{}, interfaces.ErrPaymentEventNotFound
+	}
+	return r.events[id-1], nil
+}
+
+func (r *memoryPaymentRepository) GetWebhookEvents(state string, offset, limit int) ([]entities.PaymentWebhookEvent, int64, error) {
+	return nil, 0, nil
+}
+
+// signedHeader returns the header a provider signing with the secret sends with the body.
+func signedHeader(secret string, body []byte) http.Header {
+	header := http.Header{}
+	header.Set(webhookSignatureHeader, hex.EncodeToString(signBody([]byte(secret), body)))
+	return header
+}
+
+func TestPromptPayVerifyWebhook(t *testing.T) {
+	provider, err := NewPromptPayProvider(PromptPayConfig{ProxyID: "0812345678", WebhookSecret: "secret"})
+	if err != nil {
+		t.Fatalf("NewPromptPayProvider() error = %v", err)
+	}
+	body := []byte(`{"event_id":"evt-1","reference":"PPBK00000000AABBCCDD","status":"succeeded","amount":150}`)
+
+	tests := []struct {
+		name    string
+		body    []byte
+		header  http.Header
+		wantErr error
+	}{
+		{name: "signed", body: body, header: signedHeader("secret", body)},
+		{name: "unsigned", body: body, header: http.Header{}, wantErr: interfaces.ErrInvalidWebhookSignature},
+		{name: "wrong secret", body: body, header: signedHeader("other", body), wantErr: interfaces.ErrInvalidWebhookSignature},
+		{
+			name:    "body changed after signing",
+			body:    []byte(`{"event_id":"evt-1","reference":"PPBK00000000AABBCCDD","status":"succeeded","amount":1}`),
+			header:  signedHeader("secret", body),
+			wantErr: interfaces.ErrInvalidWebhookSignature,
+		},
+		{
+			name:    "signature not hex",
+			body:    body,
+			header:  http.Header{webhookSignatureHeader: []string{"not-a-signature"}},
+			wantErr: interfaces.ErrInvalidWebhookSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := provider.VerifyWebhook(tt.body, tt.header)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (event.EventID != "evt-1" || event.Amount != 150) {
+				t.Errorf("VerifyWebhook() = %+v", event)
+			}
+		})
+	}
+}
+
+func TestFakeProviderRequiresSignature(t *testing.T) {
+	if _, err := NewFakePaymentProvider(""); err == nil {
+		t.Fatal("NewFakePaymentProvider() accepted an empty webhook secret")
+	}
+
+	provider, err := NewFakePaymentProvider("secret")
+	if err != nil {
+		t.Fatalf("NewFakePaymentProvider() error = %v", err)
+	}
+	body := []byte(`{"event_id":"evt-1","reference":"FKBK00000000AABBCCDD","status":"succeeded","amount":150}`)
+	if _, err := provider.VerifyWebhook(body, http.Header{}); !errors.Is(err, interfaces.ErrInvalidWebhookSignature) {
+		t.Errorf("VerifyWebhook() of an unsigned body error = %v, want %v", err, interfaces.ErrInvalidWebhookSignature)
+	}
+	if _, err := provider.VerifyWebhook(body, signedHeader("secret", body)); err != nil {
+		t.Errorf("VerifyWebhook() of a signed body error = %v", err)
+	}
+}
+
+func TestHandleWebhookDeduplicatesEvents(t *testing.T) {
+	provider, err := NewPromptPayProvider(PromptPayConfig{ProxyID: "0812345678", WebhookSecret: "secret"})
+	if err != nil {
+		t.Fatalf("NewPromptPayProvider() error = %v", err)
+	}
+	repo := &memoryPaymentRepository{}
+	if _, err := repo.CreatePayment(entities.Payment{
+		BookingID:   1,
+		Provider:    provider.Name(),
+		ProviderRef: "PPBK00000000AABBCCDD",
+		Amount:      150,
+		Status:      entities.PaymentStatusPending,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	service := NewPaymentService(repo, nil, nil, provider)
+
+	send := func(body string) entities.PaymentWebhookEvent {
+		t.Helper()
+		event, err := service.HandleWebhook(provider.Name(), []byte(body), signedHeader("secret", []byte(body)))
+		if err != nil {
+			t.Fatalf("HandleWebhook() error = %v", err)
+		}
+		return event
+	}
+
+	failed := `{"event_id":"evt-1","reference":"PPBK00000000AABBCCDD","status":"failed"}`
+	first := send(failed)
+	if first.State != entities.PaymentEventStateApplied || first.Attempts != 1 {
+		t.Errorf("first delivery = %s after %d attempts, want applied after 1", first.State, first.Attempts)
+	}
+
+	again := send(failed)
+	if again.ID != first.ID || again.Attempts != 1 {
+		t.Errorf("resent event was processed again: %+v", again)
+	}
+
+	// A new event reporting the same status has nothing left to change
+	other := send(`{"event_id":"evt-2","reference":"PPBK00000000AABBCCDD","status":"failed"}`)
+	if other.State != entities.PaymentEventStateIgnored {
+		t.Errorf("second event = %s, want ignored", other.State)
+	}
+
+	if repo.statusUpdates != 1 {
+		t.Errorf("payment status changed %d times, want 1", repo.statusUpdates)
+	}
+	if len(repo.events) != 2 {
+		t.Errorf("stored %d events, want 2", len(repo.events))
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// PromotionService runs marketing's discount campaigns. Whether a booking qualifies is
+// checked here, the usage caps are enforced again when the booking is confirmed, in the
+// same transaction that redeems the code.
+type PromotionService struct {
+	repo      interfaces.PromotionRepository
+	trainRepo interfaces.TrainRepository
+}
+
+func NewPromotionService(repo interfaces.PromotionRepository, trainRepo interfaces.TrainRepository) *PromotionService {
+	return &PromotionService{repo: repo, trainRepo: trainRepo}
+}
+
+// Promotion methods
+func (s *PromotionService) GetPromotions() ([]entities.Promotion, error) {
+	return s.repo.GetPromotions()
+}
+
+func (s *PromotionService) GetPromotion(code string) (entities.Promotion, error) {
+	return s.repo.GetPromotionByCode(normalizePromotionCode(code))
+}
+
+func (s *PromotionService) CreatePromotion(promotion entities.Promotion) (entities.Promotion, error) {
+	promotion.Code = normalizePromotionCode(promotion.Code)
+	if promotion.Code == "" {
+		return entities.Promotion{}, errors.New("promotion code is required")
+	}
+	if err := s.validatePromotion(promotion); err != nil {
+		return entities.Promotion{}, err
+	}
+	return s.repo.CreatePromotion(promotion)
+}
+
+func (s *PromotionService) UpdatePromotion(promotion entities.Promotion) (entities.Promotion, error) {
+	promotion.Code = normalizePromotionCode(promotion.Code)
+	if err := s.validatePromotion(promotion); err != nil {
+		return entities.Promotion{}, err
+	}
+	return s.repo.UpdatePromotion(promotion)
+}
+
+func (s *PromotionService) DeletePromotion(code string) error {
+	return s.repo.DeletePromotion(normalizePromotionCode(code))
+}
+
+func (s *PromotionService) validatePromotion(promotion entities.Promotion) error {
+	if promotion.ModifyBy == 0 {
+		return errors.New("modifyBy (user ID) is required")
+	}
+	if promotion.Description == "" {
+		return errors.New("promotion description is required")
+	}
+
+	switch promotion.DiscountType {
+	case entities.PromotionDiscountPercent:
+		if promotion.DiscountValue < 1 || promotion.DiscountValue > 100 {
+			return errors.New("percentage discount must be between 1 and 100")
+		}
+	case entities.PromotionDiscountFixed:
+		if promotion.DiscountValue < 1 {
+			return errors.New("fixed discount must be at least 1 baht")
+		}
+	default:
+		return fmt.Errorf("discount type must be %s or %s", entities.PromotionDiscountPercent, entities.PromotionDiscountFixed)
+	}
+
+	if promotion.MaxDiscount < 0 || promotion.MinSpend < 0 || promotion.TotalLimit < 0 || promotion.PerUserLimit < 0 {
+		return errors.New("limits cannot be negative")
+	}
+	if promotion.TravelFrom != nil && promotion.TravelTo != nil && promotion.TravelTo.Before(*promotion.TravelFrom) {
+		return errors.New("travel period must end after it starts")
+	}
+	if promotion.BookingFrom != nil && promotion.BookingTo != nil && promotion.BookingTo.Before(*promotion.BookingFrom) {
+		return errors.New("booking period must end after it starts")
+	}
+
+	if promotion.TrainTypeCode != "" {
+		if _, err := s.trainRepo.GetTrainTypeByCode(promotion.TrainTypeCode); err != nil {
+			return err
+		}
+	}
+	if promotion.TrainLineCode != "" {
+		if _, err := s.trainRepo.GetTrainLineByCode(promotion.TrainLineCode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discount checks that a booking of subtotal baht on the train and service date qualifies
+// for the promotion and returns the code as stored and the baht taken off.
+func (s *PromotionService) discount(code string, userID uint, trainCode string, serviceDate time.Time, subtotal int) (string, int, error) {
+	promotion, err := s.GetPromotion(code)
+	if err != nil {
+		return "", 0, err
+	}
+	if !promotion.Active {
+		return "", 0, fmt.Errorf("promotion %s is not active", promotion.Code)
+	}
+
+	now := time.Now()
+	if (promotion.BookingFrom != nil && now.Before(*promotion.BookingFrom)) ||
+		(promotion.BookingTo != nil && now.After(*promotion.BookingTo)) {
+		return "", 0, fmt.Errorf("promotion %s cannot be used today", promotion.Code)
+	}
+	if (promotion.TravelFrom != nil && serviceDate.Before(*promotion.TravelFrom)) ||
+		(promotion.TravelTo != nil && serviceDate.After(*promotion.TravelTo)) {
+		return "", 0, fmt.Errorf("promotion %s is not valid for travel on %s", promotion.Code, serviceDate.Format(time.DateOnly))
+	}
+
+	if promotion.TrainLineCode != "" || promotion.TrainTypeCode != "" {
+		train, err := s.trainRepo.GetTrainByCode(trainCode)
+		if err != nil {
+			return "", 0, err
+		}
+		if promotion.TrainLineCode != "" && train.TrainLineCode != promotion.TrainLineCode {
+			return "", 0, fmt.Errorf("promotion %s is not valid on train %s", promotion.Code, trainCode)
+		}
+		if promotion.TrainTypeCode != "" && train.TrainTypeCode != promotion.TrainTypeCode {
+			return "", 0, fmt.Errorf("promotion %s is not valid on train %s", promotion.Code, trainCode)
+		}
+	}
+
+	if subtotal < promotion.MinSpend {
+		return "", 0, fmt.Errorf("promotion %s needs a booking of at least %d baht", promotion.Code, promotion.MinSpend)
+	}
+
+	// Checked early to fail fast, redeeming the code checks the caps again
+	if promotion.TotalLimit > 0 && promotion.RedeemedCount >= promotion.TotalLimit {
+		return "", 0, fmt.Errorf("%w: %s", interfaces.ErrPromotionExhausted, promotion.Code)
+	}
+	if promotion.PerUserLimit > 0 {
+		used, err := s.repo.CountRedemptions(promotion.Code, userID)
+		if err != nil {
+			return "", 0, err
+		}
+		if used >= int64(promotion.PerUserLimit) {
+			return "", 0, fmt.Errorf("%w: %s may be used %d times per user", interfaces.ErrPromotionExhausted,
+				promotion.Code, promotion.PerUserLimit)
+		}
+	}
+
+	return promotion.Code, discountAmount(promotion, subtotal), nil
+}
+
+// discountAmount returns the baht the promotion takes off a subtotal, at most MaxDiscount
+// and never more than the subtotal.
+func discountAmount(promotion entities.Promotion, subtotal int) int {
+	amount := promotion.DiscountValue
+	if promotion.DiscountType == entities.PromotionDiscountPercent {
+		amount = subtotal * promotion.DiscountValue / 100
+	}
+	if promotion.MaxDiscount > 0 && amount > promotion.MaxDiscount {
+		amount = promotion.MaxDiscount
+	}
+	if amount > subtotal {
+		amount = subtotal
+	}
+	return amount
+}
+
+// normalizePromotionCode makes codes case insensitive, as customers type them in.
+func normalizePromotionCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
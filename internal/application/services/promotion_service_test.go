@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+func TestDiscountAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion entities.Promotion
+		subtotal  int
+		want      int
+	}{
+		{
+			name:      "percent",
+			promotion: entities.Promotion{DiscountType: entities.PromotionDiscountPercent, DiscountValue: 10},
+			subtotal:  1250,
+			want:      125,
+		},
+		{
+			name:      "percent rounds down",
+			promotion: entities.Promotion{DiscountType: entities.PromotionDiscountPercent, DiscountValue: 15},
+			subtotal:  99,
+			want:      14,
+		},
+		{
+			name:      "percent capped",
+			promotion: entities.Promotion{DiscountType: entities.PromotionDiscountPercent, DiscountValue: 50, MaxDiscount: 300},
+			subtotal:  1000,
+			want:      300,
+		},
+		{
+			name:      "percent just under the cap",
+			promotion: entities.Promotion{DiscountType: entities.PromotionDiscountPercent, DiscountValue: 50, MaxDiscount: 300},
+			subtotal:  598,
+			want:      299,
+		},
+		{
+			name:      "fixed",
+			promotion: entities.Promotion{DiscountType: entities.PromotionDiscountFixed, DiscountValue: 100},
+			subtotal:  450,
+			want:      100,
+		},
+		{
+			name:      "fixed capped",
+			promotion: entities.Promotion{DiscountType: entities.PromotionDiscountFixed, DiscountValue: 100, MaxDiscount: 80},
+			subtotal:  450,
+			want:      80,
+		},
+		{
+			name:      "fixed above the subtotal",
+			promotion: entities.Promotion{DiscountType: entities.PromotionDiscountFixed, DiscountValue: 500},
+			subtotal:  320,
+			want:      320,
+		},
+		{
+			name:      "full percent",
+			promotion: entities.Promotion{DiscountType: entities.PromotionDiscountPercent, DiscountValue: 100},
+			subtotal:  320,
+			want:      320,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := discountAmount(tt.promotion, tt.subtotal); got != tt.want {
+				t.Errorf("discountAmount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+const (
+	// promptPayAID is the application ID of PromptPay credit transfers in a QR payload.
+	promptPayAID = "A000000677010111"
+	// webhookSignatureHeader carries the hex HMAC-SHA256 of the webhook body.
+	webhookSignatureHeader = "X-Signature"
+)
+
+// PromptPayConfig configures the PromptPay provider. ProxyID is the phone number, tax ID
+// or e-wallet ID the money is transferred to. WebhookSecret signs the payment
+// notifications our bank sends.
+type PromptPayConfig struct {
+	ProxyID       string
+	WebhookSecret string
+}
+
+// PromptPayProvider collects payments as PromptPay transfers. Customers scan a dynamic QR
+// code carrying the amount and the payment reference, and the receiving bank notifies us
+// of the transfer through a signed webhook.
+type PromptPayProvider struct {
+	proxyTag      string
+	proxyValue    string
+	webhookSecret []byte
+}
+
+func NewPromptPayProvider(config PromptPayConfig) (*PromptPayProvider, error) {
+	tag, value, err := promptPayProxy(config.ProxyID)
+	if err != nil {
+		return nil, err
+	}
+	if config.WebhookSecret == "" {
+		return nil, errors.New("PromptPay webhook secret is required")
+	}
+	return &PromptPayProvider{proxyTag: tag, proxyValue: value, webhookSecret: []byte(config.WebhookSecret)}, nil
+}
+
+// Name implements PaymentProvider.
+func (p *PromptPayProvider) Name() string {
+	return "promptpay"
+}
+
+// CreateIntent implements PaymentProvider. The payment reference goes into the QR code so
+// the bank's notification can be matched to the payment.
+func (p *PromptPayProvider) CreateIntent(reference string, amount int, expiresAt time.Time) (PaymentIntent, error) {
+	if amount <= 0 {
+		return PaymentIntent{}, errors.New("payment amount must be positive")
+	}
+
+	providerRef, err := paymentReference("PP", reference)
+	if err != nil {
+		return PaymentIntent{}, err
+	}
+
+	return PaymentIntent{
+		ProviderRef: providerRef,
+		Amount:      amount,
+		QRPayload:   p.qrPayload(providerRef, amount),
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// QueryStatus implements PaymentProvider. PromptPay transfers cannot be looked up, a
+// payment stays pending until the bank reports it through the webhook.
+func (p *PromptPayProvider) QueryStatus(providerRef string) (string, error) {
+	return entities.PaymentStatusPending, nil
+}
+
+// Refund implements PaymentProvider. A PromptPay transfer can only be sent back by hand.
+func (p *PromptPayProvider) Refund(providerRef, idempotencyKey string, amount int) error {
+	return interfaces.ErrRefundNotSupported
+}
+
+// VerifyWebhook implements PaymentProvider.
+func (p *PromptPayProvider) VerifyWebhook(body []byte, header http.Header) (PaymentEvent, error) {
+	return verifyPaymentWebhook(p.webhookSecret, body, header)
+}
+
+// qrPayload builds the EMVCo merchant-presented QR payload of a PromptPay transfer.
+func (p *PromptPayProvider) qrPayload(providerRef string, amount int) string {
+	payload := emvField("00", "01") + // Payload format indicator
+		emvField("01", "12") + // Dynamic QR, for one payment only
+		emvField("29", emvField("00", promptPayAID)+emvField(p.proxyTag, p.proxyValue)) +
+		emvField("53", "764") + // Thai baht
+		emvField("54", fmt.Sprintf("%d.00", amount)) +
+		emvField("58", "TH") +
+		emvField("62", emvField("05", providerRef)) + // Reference label
+		"6304" // The CRC covers its own tag and length
+	return payload + fmt.Sprintf("%04X", crc16CCITT([]byte(payload)))
+}
+
+// promptPayProxy returns the QR sub-tag and the value of a PromptPay proxy ID. Phone
+// numbers are written with the 0066 country code.
+func promptPayProxy(proxyID string) (string, string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, proxyID)
+
+	switch {
+	case len(digits) == 10 && digits[0] == '0':
+		return "01", "0066" + digits[1:], nil
+	case len(digits) == 11 && strings.HasPrefix(digits, "66"):
+		return "01", "00" + digits, nil
+	case len(digits) == 13:
+		return "02", digits, nil
+	case len(digits) == 15:
+		return "03", digits, nil
+	}
+	return "", "", fmt.Errorf("PromptPay ID %q is not a phone number, tax ID or e-wallet ID", proxyID)
+}
+
+// emvField encodes one EMVCo data object as its ID, a two digit length and the value.
+func emvField(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// crc16CCITT returns the CRC-16/CCITT-FALSE checksum EMVCo QR payloads end with.
+func crc16CCITT(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// paymentReference returns a reference such as "PPBK3F9A1C2D5E6F7A80" for a new payment of
+// the booking. Every payment gets its own, a booking may be paid for more than once.
+func paymentReference(prefix, bookingReference string) (string, error) {
+	refBytes := make([]byte, 4)
+	if _, err := rand.Read(refBytes); err != nil {
+		return "", fmt.Errorf("failed to generate payment reference: %v", err)
+	}
+	return fmt.Sprintf("%s%s%X", prefix, bookingReference, refBytes), nil
+}
+
+// signBody returns the HMAC-SHA256 of a webhook body.
+func signBody(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// verifyPaymentWebhook checks the signature of a payment notification signed with the
+// secret and reads its event.
+func verifyPaymentWebhook(secret, body []byte, header http.Header) (PaymentEvent, error) {
+	signature, err := hex.DecodeString(header.Get(webhookSignatureHeader))
+	if err != nil || !hmac.Equal(signature, signBody(secret, body)) {
+		return PaymentEvent{}, interfaces.ErrInvalidWebhookSignature
+	}
+	return parsePaymentEvent(body)
+}
+
+// paymentWebhook is the body of a payment notification.
+type paymentWebhook struct {
+	EventID    string    `json:"event_id"`
+	Reference  string    `json:"reference"`
+	Status     string    `json:"status"`
+	Amount     int       `json:"amount"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// parsePaymentEvent reads the event of a payment notification.
+func parsePaymentEvent(body []byte) (PaymentEvent, error) {
+	var webhook paymentWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return PaymentEvent{}, fmt.Errorf("invalid payment notification: %v", err)
+	}
+	if webhook.EventID == "" || webhook.Reference == "" {
+		return PaymentEvent{}, errors.New("payment notification needs an event ID and a reference")
+	}
+	switch webhook.Status {
+	case entities.PaymentStatusPending, entities.PaymentStatusSucceeded,
+		entities.PaymentStatusFailed, entities.PaymentStatusExpired:
+	default:
+		return PaymentEvent{}, fmt.Errorf("invalid payment status %q", webhook.Status)
+	}
+
+	return PaymentEvent{
+		EventID:     webhook.EventID,
+		ProviderRef: webhook.Reference,
+		Status:      webhook.Status,
+		Amount:      webhook.Amount,
+		OccurredAt:  webhook.OccurredAt,
+	}, nil
+}
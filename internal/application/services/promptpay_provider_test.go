@@ -0,0 +1,62 @@
+package services
+
+import "testing"
+
+func TestCRC16CCITT(t *testing.T) {
+	// The check value of CRC-16/CCITT-FALSE
+	if got := crc16CCITT([]byte("123456789")); got != 0x29B1 {
+		t.Errorf("crc16CCITT(%q) = %04X, want 29B1", "123456789", got)
+	}
+}
+
+func TestPromptPayQRPayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		proxyID     string
+		providerRef string
+		amount      int
+		want        string
+	}{
+		{
+			name:        "phone number",
+			proxyID:     "081-234-5678",
+			providerRef: "PPBK00000000AABBCCDD",
+			amount:      150,
+			want:        "00020101021229370016A0000006770101110113006681234567853037645406150.005802TH62240520PPBK00000000AABBCCDD6304968F",
+		},
+		{
+			name:        "phone number with country code",
+			proxyID:     "+66 81 234 5678",
+			providerRef: "PPBK00000000AABBCCDD",
+			amount:      150,
+			want:        "00020101021229370016A0000006770101110113006681234567853037645406150.005802TH62240520PPBK00000000AABBCCDD6304968F",
+		},
+		{
+			name:        "national ID",
+			proxyID:     "1-1017-00207-36-6",
+			providerRef: "PPBK00000001DEADBEEF",
+			amount:      2480,
+			want:        "00020101021229370016A00000067701011102131101700207366530376454072480.005802TH62240520PPBK00000001DEADBEEF63040787",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewPromptPayProvider(PromptPayConfig{ProxyID: tt.proxyID, WebhookSecret: "secret"})
+			if err != nil {
+				t.Fatalf("NewPromptPayProvider() error = %v", err)
+			}
+			if got := provider.qrPayload(tt.providerRef, tt.amount); got != tt.want {
+				t.Errorf("qrPayload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptPayProxyInvalid(t *testing.T) {
+	for _, proxyID := range []string{"", "12345", "1812345678", "123456789012"} {
+		if _, _, err := promptPayProxy(proxyID); err == nil {
+			t.Errorf("promptPayProxy(%q) accepted an invalid proxy ID", proxyID)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// settlementRow is one row of a provider settlement file. err is set when the row cannot
+// be read.
+type settlementRow struct {
+	line          int
+	reference     string
+	transactionID string
+	amount        int
+	err           error
+}
+
+type ReconciliationService struct {
+	repo        interfaces.ReconciliationRepository
+	paymentRepo interfaces.PaymentRepository
+}
+
+func NewReconciliationService(repo interfaces.ReconciliationRepository, paymentRepo interfaces.PaymentRepository) *ReconciliationService {
+	return &ReconciliationService{repo: repo, paymentRepo: paymentRepo}
+}
+
+// Import matches the settlement file of the provider against our payments and stores the
+// report. The file is a CSV with a header row naming at least the reference and amount
+// columns, amounts are in baht. Every payment through the provider that succeeded on the
+// settlement date, in Asia/Bangkok, is expected in the file.
+func (s *ReconciliationService) Import(provider string, settlementDate time.Time, fileName string, file io.Reader, modifyBy uint) (entities.ReconciliationReport, error) {
+	if provider == "" {
+		return entities.ReconciliationReport{}, errors.New("provider is required")
+	}
+	if settlementDate.IsZero() {
+		return entities.ReconciliationReport{}, errors.New("settlement date is required")
+	}
+	if modifyBy == 0 {
+		return entities.ReconciliationReport{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+
+	rows, err := parseSettlement(file)
+	if err != nil {
+		return entities.ReconciliationReport{}, err
+	}
+
+	references := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.err == nil {
+			references = append(references, row.reference)
+		}
+	}
+	payments, err := s.paymentRepo.GetPaymentsByProviderRefs(references)
+	if err != nil {
+		return entities.ReconciliationReport{}, err
+	}
+	byReference := make(map[string]entities.Payment, len(payments))
+	for _, payment := range payments {
+		byReference[payment.ProviderRef] = payment
+	}
+
+	report := entities.ReconciliationReport{
+		Provider:       provider,
+		SettlementDate: settlementDate,
+		FileName:       fileName,
+		RowCount:       len(rows),
+		ModifyBy:       modifyBy,
+	}
+
+	// firstLine remembers where each reference was settled first
+	firstLine := make(map[string]int)
+	for _, row := range rows {
+		item := entities.ReconciliationItem{
+			Line:          row.line,
+			ProviderRef:   row.reference,
+			TransactionID: row.transactionID,
+			SettledAmount: row.amount,
+		}
+
+		switch payment, found := byReference[row.reference]; {
+		case row.err != nil:
+			item.Result = entities.ReconciliationInvalid
+			item.Note = row.err.Error()
+		case firstLine[row.reference] != 0:
+			item.Result = entities.ReconciliationDuplicate
+			item.Note = fmt.Sprintf("already settled on line %d", firstLine[row.reference])
+		case !found:
+			item.Result = entities.ReconciliationMissingPayment
+			item.Note = "no payment has this reference"
+		default:
+			describePayment(&item, payment)
+			switch {
+			case payment.Provider != provider:
+				item.Result = entities.ReconciliationProviderMismatch
+				item.Note = fmt.Sprintf("the payment was made through %s", payment.Provider)
+			case row.amount != payment.Amount:
+				item.Result = entities.ReconciliationAmountMismatch
+				item.Note = fmt.Sprintf("settled %d baht, %d baht were paid", row.amount, payment.Amount)
+			case payment.Status != entities.PaymentStatusSucceeded:
+				item.Result = entities.ReconciliationStatusMismatch
+				item.Note = fmt.Sprintf("the payment is %s", payment.Status)
+			default:
+				item.Result = entities.ReconciliationMatched
+			}
+		}
+
+		if row.err == nil {
+			report.SettledTotal += row.amount
+			if firstLine[row.reference] == 0 {
+				firstLine[row.reference] = row.line
+			}
+		}
+		report.Items = append(report.Items, item)
+	}
+
+	// Payments we took that the provider did not settle
+	from := serviceMidnight(settlementDate)
+	succeeded, err := s.paymentRepo.GetSucceededPayments(provider, from, from.AddDate(0, 0, 1))
+	if err != nil {
+		return entities.ReconciliationReport{}, err
+	}
+	for _, payment := range succeeded {
+		if firstLine[payment.ProviderRef] != 0 {
+			continue
+		}
+		item := entities.ReconciliationItem{
+			ProviderRef: payment.ProviderRef,
+			Result:      entities.ReconciliationMissingSettlement,
+			Note:        "paid but not in the settlement file",
+		}
+		describePayment(&item, payment)
+		report.Items = append(report.Items, item)
+	}
+
+	for _, item := range report.Items {
+		if item.Result == entities.ReconciliationMatched {
+			report.MatchedCount++
+		} else {
+			report.IssueCount++
+		}
+	}
+
+	return s.repo.CreateReport(report)
+}
+
+func (s *ReconciliationService) GetReport(id uint) (entities.ReconciliationReport, error) {
+	return s.repo.GetReport(id)
+}
+
+func (s *ReconciliationService) GetReports(offset, limit int) ([]entities.ReconciliationReport, int64, error) {
+	return s.repo.GetReports(offset, limit)
+}
+
+// ExportReport writes the items of the report as CSV, one row per item.
+func (s *ReconciliationService) ExportReport(id uint, w io.Writer) error {
+	report, err := s.repo.GetReport(id)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{
+		"line", "provider_ref", "transaction_id", "settled_amount", "payment_id", "payment_status",
+		"expected_amount", "booking_reference", "booking_status", "result", "note",
+	}); err != nil {
+		return err
+	}
+	for _, item := range report.Items {
+		line, paymentID := "", ""
+		if item.Line > 0 {
+			line = strconv.Itoa(item.Line)
+		}
+		if item.PaymentID != nil {
+			paymentID = strconv.FormatUint(uint64(*item.PaymentID), 10)
+		}
+		if err := writer.Write([]string{
+			line, item.ProviderRef, item.TransactionID, strconv.Itoa(item.SettledAmount), paymentID, item.PaymentStatus,
+			strconv.Itoa(item.ExpectedAmount), item.BookingReference, item.BookingStatus, item.Result, item.Note,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// describePayment copies what the item was matched to onto it.
+func describePayment(item *entities.ReconciliationItem, payment entities.Payment) {
+	paymentID := payment.ID
+	item.PaymentID = &paymentID
+	item.PaymentStatus = payment.Status
+	item.ExpectedAmount = payment.Amount
+	if payment.Booking != nil {
+		bookingID := payment.Booking.ID
+		item.BookingID = &bookingID
+		item.BookingReference = payment.Booking.Reference
+		item.BookingStatus = payment.Booking.Status
+	}
+}
+
+// parseSettlement reads the rows of a settlement file. The header names the columns, in
+// any order: reference (or provider_ref), amount and optionally transaction_id. A row that
+// cannot be read is returned with its error, so it shows up in the report.
+func parseSettlement(file io.Reader) ([]settlementRow, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("settlement file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid settlement file: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "provider_ref" {
+			name = "reference"
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"reference", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("settlement file has no %s column", required)
+		}
+	}
+
+	column := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []settlementRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			// csv.ParseError leaves the reader usable for the following rows
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("invalid settlement file: %v", err)
+			}
+			rows = append(rows, settlementRow{line: parseErr.Line, err: err})
+			continue
+		}
+
+		row := settlementRow{
+			line:          line,
+			reference:     column(record, "reference"),
+			transactionID: column(record, "transaction_id"),
+		}
+		if row.reference == "" {
+			row.err = errors.New("reference is missing")
+		} else if row.amount, err = parseBaht(column(record, "amount")); err != nil {
+			row.err = err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseBaht reads an amount such as "1,250.00" in whole baht.
+func parseBaht(amount string) (int, error) {
+	amount = strings.ReplaceAll(amount, ",", "")
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if strings.Trim(fraction, "0") != "" || len(fraction) > 2 {
+		return 0, fmt.Errorf("amount %q is not in whole baht", amount)
+	}
+	baht, err := strconv.Atoi(whole)
+	if err != nil || baht < 0 {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	return baht, nil
+}
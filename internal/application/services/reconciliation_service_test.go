@@ -0,0 +1,175 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+func TestParseBaht(t *testing.T) {
+	tests := []struct {
+		amount  string
+		want    int
+		wantErr bool
+	}{
+		{amount: "1,250.00", want: 1250},
+		{amount: "1,250", want: 1250},
+		{amount: "150", want: 150},
+		{amount: "150.0", want: 150},
+		{amount: "0.00", want: 0},
+		{amount: "12.50", wantErr: true},
+		{amount: "12.005", wantErr: true},
+		{amount: "150.000", wantErr: true},
+		{amount: "-5.00", wantErr: true},
+		{amount: "", wantErr: true},
+		{amount: "THB 150", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			got, err := parseBaht(tt.amount)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBaht(%q) error = %v, wantErr %v", tt.amount, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseBaht(%q) = %d, want %d", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSettlement(t *testing.T) {
+	// row leaves out the error, rows are compared by whether they have one
+	type row struct {
+		line          int
+		reference     string
+		transactionID string
+		amount        int
+		invalid       bool
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		want    []row
+		wantErr bool
+	}{
+		{
+			name: "columns in any order",
+			file: "amount,transaction_id,reference\n\"1,250.00\",T1,PPBK1\n150,T2,PPBK2\n",
+			want: []row{
+				{line: 2, reference: "PPBK1", transactionID: "T1", amount: 1250},
+				{line: 3, reference: "PPBK2", transactionID: "T2", amount: 150},
+			},
+		},
+		{
+			name: "byte order mark and provider_ref column",
+			file: "\xef\xbb\xbfProvider_Ref, Amount\nPPBK1, 150.00\n",
+			want: []row{{line: 2, reference: "PPBK1", amount: 150}},
+		},
+		{
+			name: "duplicates are kept for the report",
+			file: "reference,amount\nPPBK1,150\nPPBK1,150\n",
+			want: []row{
+				{line: 2, reference: "PPBK1", amount: 150},
+				{line: 3, reference: "PPBK1", amount: 150},
+			},
+		},
+		{
+			name: "unreadable rows",
+			file: "reference,amount\nPPBK1,12.50\n,150\nPPBK3\nPPBK4,80\n",
+			want: []row{
+				{line: 2, reference: "PPBK1", invalid: true},
+				{line: 3, invalid: true},
+				{line: 4, reference: "PPBK3", invalid: true},
+				{line: 5, reference: "PPBK4", amount: 80},
+			},
+		},
+		{name: "missing amount column", file: "reference,total\nPPBK1,150\n", wantErr: true},
+		{name: "missing reference column", file: "amount\n150\n", wantErr: true},
+		{name: "empty file", file: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseSettlement(strings.NewReader(tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSettlement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var got []row
+			for _, r := range rows {
+				got = append(got, row{
+					line:          r.line,
+					reference:     r.reference,
+					transactionID: r.transactionID,
+					amount:        r.amount,
+					invalid:       r.err != nil,
+				})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSettlement() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// memoryReconciliationRepository returns reports as they are stored.
+type memoryReconciliationRepository struct {
+	interfaces.ReconciliationRepository
+}
+
+func (r memoryReconciliationRepository) CreateReport(report entities.ReconciliationReport) (entities.ReconciliationReport, error) {
+	return report, nil
+}
+
+func TestReconciliationImport(t *testing.T) {
+	settlementDate := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	paidAt := time.Date(2026, 3, 14, 10, 0, 0, 0, bangkokLocation)
+
+	paymentRepo := &memoryPaymentRepository{}
+	for _, payment := range []entities.Payment{
+		{ProviderRef: "PP1", Provider: "promptpay", Amount: 150, Status: entities.PaymentStatusSucceeded, PaidAt: &paidAt},
+		{ProviderRef: "PP2", Provider: "promptpay", Amount: 300, Status: entities.PaymentStatusSucceeded, PaidAt: &paidAt},
+		{ProviderRef: "PP3", Provider: "promptpay", Amount: 80, Status: entities.PaymentStatusPending},
+		{ProviderRef: "FK1", Provider: "fake", Amount: 90, Status: entities.PaymentStatusSucceeded, PaidAt: &paidAt},
+		{ProviderRef: "PP4", Provider: "promptpay", Amount: 500, Status: entities.PaymentStatusSucceeded, PaidAt: &paidAt},
+	} {
+		if _, err := paymentRepo.CreatePayment(payment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	service := NewReconciliationService(memoryReconciliationRepository{}, paymentRepo)
+
+	file := "reference,amount\nPP1,150\nPP1,150\nPP2,250\nPP3,80\nFK1,90\nXX9,40\nPP5,abc\n"
+	report, err := service.Import("promptpay", settlementDate, "settlement.csv", strings.NewReader(file), 1)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	var got []string
+	for _, item := range report.Items {
+		got = append(got, item.ProviderRef+" "+item.Result)
+	}
+	want := []string{
+		"PP1 " + entities.ReconciliationMatched,
+		"PP1 " + entities.ReconciliationDuplicate,
+		"PP2 " + entities.ReconciliationAmountMismatch,
+		"PP3 " + entities.ReconciliationStatusMismatch,
+		"FK1 " + entities.ReconciliationProviderMismatch,
+		"XX9 " + entities.ReconciliationMissingPayment,
+		"PP5 " + entities.ReconciliationInvalid,
+		"PP4 " + entities.ReconciliationMissingSettlement,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Import() items = %v, want %v", got, want)
+	}
+	if report.RowCount != 7 || report.MatchedCount != 1 || report.IssueCount != 7 || report.SettledTotal != 760 {
+		t.Errorf("Import() = %d rows, %d matched, %d issues, %d baht settled, want 7, 1, 7, 760",
+			report.RowCount, report.MatchedCount, report.IssueCount, report.SettledTotal)
+	}
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+var fareTypes = map[string]bool{
+	entities.FareTypeStandard:   true,
+	entities.FareTypeConcession: true,
+	entities.FareTypePromotion:  true,
+}
+
+// RefundItem is the refund of one ticket.
+type RefundItem struct {
+	TicketNumber  string `json:"ticket_number"`
+	FareType      string `json:"fare_type"`
+	Paid          int    `json:"paid"`
+	Rule          string `json:"rule,omitempty"` // Empty when no rule applies and nothing is refunded
+	RefundPercent int    `json:"refund_percent"`
+	Fee           int    `json:"fee"`
+	Amount        int    `json:"amount"`
+}
+
+// RefundQuote is what cancelling a booking at QuotedAt gives back.
+type RefundQuote struct {
+	BookingID   uint         `json:"booking_id"`
+	Reference   string       `json:"reference"`
+	Departure   time.Time    `json:"departure"`
+	QuotedAt    time.Time    `json:"quoted_at"`
+	HoursBefore float64      `json:"hours_before_departure"`
+	Paid        int          `json:"paid"`
+	PaymentID   *uint        `json:"payment_id,omitempty"` // The payment the refund goes back to
+	Items       []RefundItem `json:"items"`
+	Amount      int          `json:"amount"`
+}
+
+type RefundService struct {
+	repo        interfaces.RefundRepository
+	trainRepo   interfaces.TrainRepository
+	routeRepo   interfaces.StationOrderRepository
+	paymentRepo interfaces.PaymentRepository
+}
+
+func NewRefundService(repo interfaces.RefundRepository, trainRepo interfaces.TrainRepository, routeRepo interfaces.StationOrderRepository, paymentRepo interfaces.PaymentRepository) *RefundService {
+	return &RefundService{repo: repo, trainRepo: trainRepo, routeRepo: routeRepo, paymentRepo: paymentRepo}
+}
+
+// Refund rule methods
+func (s *RefundService) GetRules() ([]entities.RefundRule, error) {
+	return s.repo.GetRules()
+}
+
+func (s *RefundService) CreateRule(rule entities.RefundRule) (entities.RefundRule, error) {
+	if err := s.validateRule(rule); err != nil {
+		return entities.RefundRule{}, err
+	}
+	rule.ID = 0
+	return s.repo.CreateRule(rule)
+}
+
+func (s *RefundService) UpdateRule(rule entities.RefundRule) (entities.RefundRule, error) {
+	if err := s.validateRule(rule); err != nil {
+		return entities.RefundRule{}, err
+	}
+	return s.repo.UpdateRule(rule)
+}
+
+func (s *RefundService) DeleteRule(id uint) error {
+	return s.repo.DeleteRule(id)
+}
+
+func (s *RefundService) validateRule(rule entities.RefundRule) error {
+	if rule.ModifyBy == 0 {
+		return errors.New("modifyBy (user ID) is required")
+	}
+	if rule.Name == "" {
+		return errors.New("refund rule name is required")
+	}
+	if rule.FareType != "" && !fareTypes[rule.FareType] {
+		return fmt.Errorf("invalid fare type %q", rule.FareType)
+	}
+	if rule.MinHoursBefore < 0 {
+		return errors.New("cut-off cannot be after departure")
+	}
+	if rule.RefundPercent < 0 || rule.RefundPercent > 100 {
+		return errors.New("refund must be between 0 and 100 percent")
+	}
+	if rule.FeePerTicket < 0 {
+		return errors.New("fee cannot be negative")
+	}
+	if rule.TrainTypeCode != "" {
+		if _, err := s.trainRepo.GetTrainTypeByCode(rule.TrainTypeCode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *RefundService) GetRefunds(bookingID uint) ([]entities.Refund, error) {
+	return s.repo.GetRefundsByBooking(bookingID)
+}
+
+// CreateRefund records money owed back for a booking outside of a cancellation.
+func (s *RefundService) CreateRefund(refund entities.Refund) (entities.Refund, error) {
+	if refund.Amount <= 0 {
+		return entities.Refund{}, errors.New("refund amount must be positive")
+	}
+	if refund.Status == "" {
+		refund.Status = entities.RefundStatusPending
+	}
+	return s.repo.CreateRefund(refund)
+}
+
+// Quote prices cancelling the booking at the given time. Only confirmed bookings with a
+// payment that succeeded were paid for, the others get nothing back. Each ticket is
+// refunded by the rule that matches its train type and fare type most closely and has the
+// latest cut-off already reached; after departure no rule applies.
+func (s *RefundService) Quote(booking entities.Booking, at time.Time) (RefundQuote, error) {
+	departure, err := s.departure(booking)
+	if err != nil {
+		return RefundQuote{}, err
+	}
+
+	quote := RefundQuote{
+		BookingID:   booking.ID,
+		Reference:   booking.Reference,
+		Departure:   departure,
+		QuotedAt:    at,
+		HoursBefore: departure.Sub(at).Hours(),
+		Items:       []RefundItem{},
+	}
+	if booking.Status != entities.BookingStatusConfirmed {
+		return quote, nil
+	}
+	payment, err := s.bookingPayment(booking.ID)
+	if err != nil {
+		return RefundQuote{}, err
+	}
+	if payment == nil {
+		return quote, nil
+	}
+	quote.PaymentID = &payment.ID
+
+	train, err := s.trainRepo.GetTrainByCode(booking.TrainCode)
+	if err != nil {
+		return RefundQuote{}, err
+	}
+	rules, err := s.repo.GetActiveRules()
+	if err != nil {
+		return RefundQuote{}, err
+	}
+
+	paid := ticketsPaid(booking)
+	for i, ticket := range booking.Tickets {
+		item := RefundItem{
+			TicketNumber: ticket.TicketNumber,
+			FareType:     ticketFareType(booking, ticket),
+			Paid:         paid[i],
+		}
+		if rule := matchRefundRule(rules, train.TrainTypeCode, item.FareType, departure.Sub(at)); rule != nil {
+			item.Rule = rule.Name
+			item.RefundPercent = rule.RefundPercent
+			item.Fee = rule.FeePerTicket
+			item.Amount = item.Paid*rule.RefundPercent/100 - rule.FeePerTicket
+			if item.Amount < 0 {
+				item.Amount = 0
+			}
+		}
+
+		quote.Items = append(quote.Items, item)
+		quote.Paid += item.Paid
+		quote.Amount += item.Amount
+	}
+	if quote.Amount > payment.Amount {
+		quote.Amount = payment.Amount
+	}
+	return quote, nil
+}
+
+// bookingPayment returns the payment that paid for the booking: the first one that
+// succeeded and is not already being refunded, e.g. as a second payment for the same
+// booking. It is nil when nothing was paid.
+func (s *RefundService) bookingPayment(bookingID uint) (*entities.Payment, error) {
+	payments, err := s.paymentRepo.GetPaymentsByBooking(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := s.repo.GetRefundsByBooking(bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	refunded := make(map[uint]bool, len(refunds))
+	for _, refund := range refunds {
+		if refund.PaymentID != nil {
+			refunded[*refund.PaymentID] = true
+		}
+	}
+	for i, payment := range payments {
+		if payment.Status == entities.PaymentStatusSucceeded && !refunded[payment.ID] {
+			return &payments[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// departure returns when the train leaves the station the booking starts at.
+func (s *RefundService) departure(booking entities.Booking) (time.Time, error) {
+	route, err := s.routeRepo.GetStationOrder(booking.TrainCode)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, stop := range stopTimes(route, booking.ServiceDate) {
+		if stop.Order == booking.FromOrder {
+			return stop.Departure, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("train %s no longer stops at station %s", booking.TrainCode, booking.FromStationCode)
+}
+
+// matchRefundRule returns the rule for a ticket cancelled the given time before departure,
+// or nil. A rule naming the train type beats one naming the fare type, which beats one
+// naming neither; among those the one with the latest cut-off wins.
+func matchRefundRule(rules []entities.RefundRule, trainTypeCode, fareType string, beforeDeparture time.Duration) *entities.RefundRule {
+	var best *entities.RefundRule
+	bestScore := -1
+	for i, rule := range rules {
+		if rule.TrainTypeCode != "" && rule.TrainTypeCode != trainTypeCode {
+			continue
+		}
+		if rule.FareType != "" && rule.FareType != fareType {
+			continue
+		}
+		if beforeDeparture < time.Duration(rule.MinHoursBefore)*time.Hour {
+			continue
+		}
+
+		score := 0
+		if rule.TrainTypeCode != "" {
+			score += 2
+		}
+		if rule.FareType != "" {
+			score++
+		}
+		if score > bestScore || (score == bestScore && rule.MinHoursBefore > best.MinHoursBefore) {
+			best = &rules[i]
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// ticketFareType returns what kind of fare the ticket was sold at.
+func ticketFareType(booking entities.Booking, ticket entities.Ticket) string {
+	switch {
+	case ticket.ConcessionCode != "":
+		return entities.FareTypeConcession
+	case booking.PromotionCode != "":
+		return entities.FareTypePromotion
+	}
+	return entities.FareTypeStandard
+}
+
+// ticketsPaid splits what was paid for the booking over its tickets: each ticket's fare
+// less its share of the discount. The last ticket takes the rounding.
+func ticketsPaid(booking entities.Booking) []int {
+	paid := make([]int, len(booking.Tickets))
+	subtotal := booking.TotalPrice + booking.Discount
+	remaining := booking.Discount
+	for i, ticket := range booking.Tickets {
+		share := 0
+		if subtotal > 0 {
+			share = booking.Discount * ticket.Price / subtotal
+		}
+		if i == len(booking.Tickets)-1 {
+			share = remaining
+		}
+		remaining -= share
+		paid[i] = ticket.Price - share
+	}
+	return paid
+}
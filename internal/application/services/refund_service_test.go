@@ -0,0 +1,130 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+func TestMatchRefundRule(t *testing.T) {
+	rules := []entities.RefundRule{
+		{Name: "early", MinHoursBefore: 24, RefundPercent: 80},
+		{Name: "late", MinHoursBefore: 0, RefundPercent: 50},
+		{Name: "express", TrainTypeCode: "EXP", MinHoursBefore: 0, RefundPercent: 30},
+		{Name: "promotion", FareType: entities.FareTypePromotion, MinHoursBefore: 2, RefundPercent: 0},
+	}
+
+	tests := []struct {
+		name            string
+		trainTypeCode   string
+		fareType        string
+		beforeDeparture time.Duration
+		want            string // Empty when no rule applies
+	}{
+		{name: "two days before", trainTypeCode: "ORD", fareType: entities.FareTypeStandard, beforeDeparture: 48 * time.Hour, want: "early"},
+		{name: "exactly 24 hours before", trainTypeCode: "ORD", fareType: entities.FareTypeStandard, beforeDeparture: 24 * time.Hour, want: "early"},
+		{name: "just under 24 hours before", trainTypeCode: "ORD", fareType: entities.FareTypeStandard, beforeDeparture: 24*time.Hour - time.Second, want: "late"},
+		{name: "at departure", trainTypeCode: "ORD", fareType: entities.FareTypeStandard, beforeDeparture: 0, want: "late"},
+		{name: "after departure", trainTypeCode: "ORD", fareType: entities.FareTypeStandard, beforeDeparture: -time.Minute},
+		{name: "train type beats fare type", trainTypeCode: "EXP", fareType: entities.FareTypePromotion, beforeDeparture: 48 * time.Hour, want: "express"},
+		{name: "train type after departure", trainTypeCode: "EXP", fareType: entities.FareTypeStandard, beforeDeparture: -time.Minute},
+		{name: "fare type beats the general rule", trainTypeCode: "ORD", fareType: entities.FareTypePromotion, beforeDeparture: 48 * time.Hour, want: "promotion"},
+		{name: "fare type cut-off not reached", trainTypeCode: "ORD", fareType: entities.FareTypePromotion, beforeDeparture: time.Hour, want: "late"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if rule := matchRefundRule(rules, tt.trainTypeCode, tt.fareType, tt.beforeDeparture); rule != nil {
+				got = rule.Name
+			}
+			if got != tt.want {
+				t.Errorf("matchRefundRule() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTicketsPaid(t *testing.T) {
+	tickets := func(prices ...int) []entities.Ticket {
+		result := make([]entities.Ticket, len(prices))
+		for i, price := range prices {
+			result[i].Price = price
+		}
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		booking entities.Booking
+		want    []int
+	}{
+		{
+			name:    "no discount",
+			booking: entities.Booking{TotalPrice: 700, Tickets: tickets(300, 400)},
+			want:    []int{300, 400},
+		},
+		{
+			name:    "discount split by fare",
+			booking: entities.Booking{TotalPrice: 630, Discount: 70, Tickets: tickets(300, 400)},
+			want:    []int{270, 360},
+		},
+		{
+			name:    "last ticket takes the rounding",
+			booking: entities.Booking{TotalPrice: 200, Discount: 100, Tickets: tickets(100, 100, 100)},
+			want:    []int{67, 67, 66},
+		},
+		{
+			name:    "whole booking discounted",
+			booking: entities.Booking{TotalPrice: 0, Discount: 500, Tickets: tickets(200, 300)},
+			want:    []int{0, 0},
+		},
+		{
+			name:    "free tickets",
+			booking: entities.Booking{Tickets: tickets(0, 0)},
+			want:    []int{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ticketsPaid(tt.booking)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ticketsPaid() = %v, want %v", got, tt.want)
+			}
+			sum := 0
+			for _, paid := range got {
+				sum += paid
+			}
+			if sum != tt.booking.TotalPrice {
+				t.Errorf("tickets paid %d baht in total, the booking %d", sum, tt.booking.TotalPrice)
+			}
+		})
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    entities.RefundRule
+		wantErr bool
+	}{
+		{name: "valid", rule: entities.RefundRule{Name: "early", MinHoursBefore: 24, RefundPercent: 80, ModifyBy: 1}},
+		{name: "cut-off at departure", rule: entities.RefundRule{Name: "late", RefundPercent: 50, ModifyBy: 1}},
+		{name: "cut-off after departure", rule: entities.RefundRule{Name: "no show", MinHoursBefore: -2, RefundPercent: 20, ModifyBy: 1}, wantErr: true},
+		{name: "percent above 100", rule: entities.RefundRule{Name: "bonus", RefundPercent: 120, ModifyBy: 1}, wantErr: true},
+		{name: "negative fee", rule: entities.RefundRule{Name: "fee", RefundPercent: 50, FeePerTicket: -10, ModifyBy: 1}, wantErr: true},
+		{name: "unknown fare type", rule: entities.RefundRule{Name: "fare", FareType: "vip", RefundPercent: 50, ModifyBy: 1}, wantErr: true},
+	}
+
+	service := &RefundService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := service.validateRule(tt.rule); (err != nil) != tt.wantErr {
+				t.Errorf("validateRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+const (
+	// DefaultRefundWorkerInterval is how often pending refunds are paid out.
+	DefaultRefundWorkerInterval = time.Minute
+	// refundWorkerBatch limits how many refunds one run pays out.
+	refundWorkerBatch = 50
+	// maxRefundAttempts is how often a refund is tried before staff have to look at it.
+	maxRefundAttempts = 5
+	// refundRetryDelay is the wait after the first failed attempt, it doubles after every
+	// further one.
+	refundRetryDelay = time.Minute
+	// stuckRefundAfter is how long a refund may be processing before it counts as stuck,
+	// e.g. because the server stopped during the provider call.
+	stuckRefundAfter = 15 * time.Minute
+)
+
+// RefundWorker pays out pending refunds through the payment provider the booking was paid
+// with. Failed attempts are retried with exponential backoff until maxRefundAttempts, then
+// the refund waits in failed_manual_review.
+type RefundWorker struct {
+	refundRepo  interfaces.RefundRepository
+	paymentRepo interfaces.PaymentRepository
+	bookings    *BookingService
+	payments    *PaymentService
+}
+
+func NewRefundWorker(refundRepo interfaces.RefundRepository, paymentRepo interfaces.PaymentRepository, bookings *BookingService, payments *PaymentService) *RefundWorker {
+	return &RefundWorker{refundRepo: refundRepo, paymentRepo: paymentRepo, bookings: bookings, payments: payments}
+}
+
+// Start pays out due refunds right away and then every interval.
+func (w *RefundWorker) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			paid, err := w.ProcessDueRefunds()
+			if err != nil {
+				log.Printf("Failed to process refunds: %v", err)
+			}
+			if paid > 0 {
+				log.Printf("Paid out %d refunds.", paid)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ProcessDueRefunds tries every pending refund whose next attempt is due and returns how
+// many were paid out.
+func (w *RefundWorker) ProcessDueRefunds() (int, error) {
+	refunds, err := w.refundRepo.GetDueRefunds(time.Now(), refundWorkerBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	paid := 0
+	for _, refund := range refunds {
+		processed, err := w.process(refund, entities.RefundStatusPending)
+		if errors.Is(err, interfaces.ErrRefundStatusChanged) {
+			// Claimed by another instance
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to process refund %d: %v", refund.ID, err)
+			continue
+		}
+		if processed.Status == entities.RefundStatusSucceeded {
+			paid++
+		}
+	}
+	return paid, nil
+}
+
+// GetStuckRefunds returns the refunds staff have to look at: those the worker gave up on
+// and those processing for longer than stuckRefundAfter.
+func (w *RefundWorker) GetStuckRefunds() ([]entities.Refund, error) {
+	return w.refundRepo.GetStuckRefunds(time.Now().Add(-stuckRefundAfter))
+}
+
+// GetRefund returns the refund with its attempts.
+func (w *RefundWorker) GetRefund(id uint) (entities.Refund, error) {
+	return w.refundRepo.GetRefund(id)
+}
+
+// RetryRefund tries a stuck refund again right away, with a fresh set of attempts. A refund
+// stuck in processing may have reached the provider; it is sent with the same idempotency
+// key, so the provider pays it out at most once.
+func (w *RefundWorker) RetryRefund(id, modifyBy uint) (entities.Refund, error) {
+	if modifyBy == 0 {
+		return entities.Refund{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+	refund, err := w.refundRepo.GetRefund(id)
+	if err != nil {
+		return entities.Refund{}, err
+	}
+	if err := retryable(refund); err != nil {
+		return entities.Refund{}, err
+	}
+
+	refund.Attempts = 0
+	refund.NextAttemptAt = nil
+	refund.ModifyBy = modifyBy
+	return w.process(refund, refund.Status)
+}
+
+// ResolveRefund records that staff sent a stuck refund back by hand, e.g. a PromptPay
+// transfer, which the provider cannot refund automatically.
+func (w *RefundWorker) ResolveRefund(id, modifyBy uint, note string) (entities.Refund, error) {
+	if modifyBy == 0 {
+		return entities.Refund{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+	refund, err := w.refundRepo.GetRefund(id)
+	if err != nil {
+		return entities.Refund{}, err
+	}
+	if err := retryable(refund); err != nil {
+		return entities.Refund{}, err
+	}
+
+	fromStatus := refund.Status
+	now := time.Now()
+	refund.Status = entities.RefundStatusSucceeded
+	refund.NextAttemptAt = nil
+	refund.LastError = ""
+	refund.ProcessedAt = &now
+	refund.ModifyBy = modifyBy
+	resolved, err := w.refundRepo.UpdateRefund(refund, fromStatus)
+	if err != nil {
+		return entities.Refund{}, err
+	}
+
+	if _, err := w.refundRepo.CreateRefundAttempt(entities.RefundAttempt{
+		RefundID:  refund.ID,
+		Attempt:   refund.Attempts + 1,
+		Provider:  "manual",
+		Amount:    refund.Amount,
+		Succeeded: true,
+		Error:     note,
+	}); err != nil {
+		log.Printf("Failed to record manual refund %d: %v", refund.ID, err)
+	}
+
+	w.markBookingRefunded(resolved)
+	return w.refundRepo.GetRefund(id)
+}
+
+// retryable checks that staff may act on the refund: it waits for them, or it has been
+// processing for too long. The worker never picks up a processing refund by itself; a stuck
+// one is only sent again by staff, with the idempotency key of its earlier attempts.
+func retryable(refund entities.Refund) error {
+	switch refund.Status {
+	case entities.RefundStatusFailedManualReview, entities.RefundStatusPending:
+		return nil
+	case entities.RefundStatusProcessing:
+		if time.Since(refund.UpdatedAt) >= stuckRefundAfter {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: refund %d is %s", interfaces.ErrRefundStatusChanged, refund.ID, refund.Status)
+}
+
+// process claims the refund, which must be in fromStatus, and pays it out once. A failed
+// attempt is scheduled again, or left for staff when it cannot succeed by retrying.
+func (w *RefundWorker) process(refund entities.Refund, fromStatus string) (entities.Refund, error) {
+	refund.Status = entities.RefundStatusProcessing
+	refund, err := w.refundRepo.UpdateRefund(refund, fromStatus)
+	if err != nil {
+		return entities.Refund{}, err
+	}
+
+	attempt := entities.RefundAttempt{
+		RefundID: refund.ID,
+		Attempt:  refund.Attempts + 1,
+		Amount:   refund.Amount,
+	}
+	retry, err := w.attempt(&refund, &attempt)
+	attempt.Succeeded = err == nil
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	if _, recordErr := w.refundRepo.CreateRefundAttempt(attempt); recordErr != nil {
+		log.Printf("Failed to record attempt %d of refund %d: %v", attempt.Attempt, refund.ID, recordErr)
+	}
+
+	now := time.Now()
+	refund.Attempts++
+	refund.NextAttemptAt = nil
+	refund.LastError = ""
+	switch {
+	case err == nil:
+		refund.Status = entities.RefundStatusSucceeded
+		refund.ProcessedAt = &now
+	case !retry || refund.Attempts >= maxRefundAttempts:
+		refund.Status = entities.RefundStatusFailedManualReview
+		refund.LastError = err.Error()
+	default:
+		next := now.Add(refundRetryDelay << (refund.Attempts - 1))
+		refund.Status = entities.RefundStatusPending
+		refund.NextAttemptAt = &next
+		refund.LastError = err.Error()
+	}
+
+	updated, err := w.refundRepo.UpdateRefund(refund, entities.RefundStatusProcessing)
+	if err != nil {
+		return entities.Refund{}, err
+	}
+	if updated.Status == entities.RefundStatusSucceeded {
+		w.markBookingRefunded(updated)
+	}
+	return updated, nil
+}
+
+// attempt calls the provider to send the refund back to the payment it is for. It reports
+// whether a failure may go away by trying again.
+func (w *RefundWorker) attempt(refund *entities.Refund, attempt *entities.RefundAttempt) (bool, error) {
+	payment, err := w.refundPayment(*refund)
+	if err != nil {
+		return false, err
+	}
+	refund.PaymentID = &payment.ID
+	attempt.Provider = payment.Provider
+	attempt.ProviderRef = payment.ProviderRef
+
+	provider, err := w.payments.provider(payment.Provider)
+	if err != nil {
+		return false, err
+	}
+	if err := provider.Refund(payment.ProviderRef, refundIdempotencyKey(*refund), refund.Amount); err != nil {
+		return !errors.Is(err, interfaces.ErrRefundNotSupported), err
+	}
+	return true, nil
+}
+
+// refundIdempotencyKey is the same for every attempt of a refund, so a provider that got an
+// attempt whose answer was lost does not pay the refund out again.
+func refundIdempotencyKey(refund entities.Refund) string {
+	return fmt.Sprintf("refund-%d", refund.ID)
+}
+
+// refundPayment returns the payment the refund goes back to. Unless the refund names one,
+// it is the latest payment of the booking that succeeded and covers the amount.
+func (w *RefundWorker) refundPayment(refund entities.Refund) (entities.Payment, error) {
+	if refund.PaymentID != nil {
+		return w.paymentRepo.GetPaymentByID(*refund.PaymentID)
+	}
+
+	payments, err := w.paymentRepo.GetPaymentsByBooking(refund.BookingID)
+	if err != nil {
+		return entities.Payment{}, err
+	}
+	for i := len(payments) - 1; i >= 0; i-- {
+		if payments[i].Status == entities.PaymentStatusSucceeded && payments[i].Amount >= refund.Amount {
+			return payments[i], nil
+		}
+	}
+	return entities.Payment{}, fmt.Errorf("booking %d has no payment of at least %d baht to refund", refund.BookingID, refund.Amount)
+}
+
+// markBookingRefunded moves the cancelled booking of a paid out refund to refunded.
+func (w *RefundWorker) markBookingRefunded(refund entities.Refund) {
+	if err := w.bookings.MarkRefunded(refund.BookingID, fmt.Sprintf("refund %d paid out", refund.ID)); err != nil {
+		log.Printf("Failed to mark booking %d refunded: %v", refund.BookingID, err)
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+func TestRetryStuckRefundPaysOutOnce(t *testing.T) {
+	provider, err := NewFakePaymentProvider("secret")
+	if err != nil {
+		t.Fatalf("NewFakePaymentProvider() error = %v", err)
+	}
+	intent, err := provider.CreateIntent("BK00000000", 150, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paymentRepo := &memoryPaymentRepository{}
+	payment, err := paymentRepo.CreatePayment(entities.Payment{
+		BookingID:   1,
+		Provider:    provider.Name(),
+		ProviderRef: intent.ProviderRef,
+		Amount:      150,
+		Status:      entities.PaymentStatusSucceeded,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The server stopped after the provider paid the refund out, before it was saved
+	refundRepo := &memoryRefundRepository{}
+	refund, err := refundRepo.CreateRefund(entities.Refund{
+		BookingID: 1,
+		PaymentID: &payment.ID,
+		Amount:    50,
+		Status:    entities.RefundStatusProcessing,
+		UpdatedAt: time.Now().Add(-stuckRefundAfter - time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.Refund(intent.ProviderRef, refundIdempotencyKey(refund), refund.Amount); err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+
+	booking := entities.Booking{Reference: "BK00000000", TotalPrice: 150, Status: entities.BookingStatusCancelled}
+	booking.ID = 1
+	bookings := &BookingService{repo: &memoryBookingRepository{bookings: map[uint]entities.Booking{1: booking}}}
+	worker := NewRefundWorker(refundRepo, paymentRepo, bookings, NewPaymentService(paymentRepo, bookings, nil, provider))
+
+	retried, err := worker.RetryRefund(refund.ID, 9)
+	if err != nil {
+		t.Fatalf("RetryRefund() error = %v", err)
+	}
+	if retried.Status != entities.RefundStatusSucceeded {
+		t.Errorf("refund = %s (%s), want succeeded", retried.Status, retried.LastError)
+	}
+	if left := provider.payments[intent.ProviderRef]; left != 100 {
+		t.Errorf("%d baht left of the payment, want 100", left)
+	}
+}
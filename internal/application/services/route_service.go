@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+// Route (StationOrder) methods
+func (s *TrainService) GetRoute(trainCode string) (entities.StationOrder, error) {
+	return s.routeRepo.GetStationOrder(trainCode)
+}
+
+func (s *TrainService) CreateRoute(trainCode string, stops []entities.StationOrderDetail, modifyBy uint) (entities.StationOrder, error) {
+	if modifyBy == 0 {
+		return entities.StationOrder{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+
+	stops, err := s.validateRoute(trainCode, stops)
+	if err != nil {
+		return entities.StationOrder{}, err
+	}
+
+	for i := range stops {
+		stops[i].ModifyBy = modifyBy
+	}
+
+	return s.routeRepo.CreateStationOrder(entities.StationOrder{
+		TrainCode: trainCode,
+		Stations:  stops,
+		ModifyBy:  modifyBy,
+	})
+}
+
+func (s *TrainService) ReplaceRoute(trainCode string, stops []entities.StationOrderDetail, modifyBy uint) (entities.StationOrder, error) {
+	if modifyBy == 0 {
+		return entities.StationOrder{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+
+	stops, err := s.validateRoute(trainCode, stops)
+	if err != nil {
+		return entities.StationOrder{}, err
+	}
+
+	return s.routeRepo.ReplaceStationOrder(trainCode, stops, modifyBy)
+}
+
+// ReorderRoute puts the stops of the route in a new order. Moving a stop changes when the
+// train reaches it and how far along the track it is, so every stop comes with its new
+// times and distance. The new order must contain exactly the same stations as the current
+// route.
+func (s *TrainService) ReorderRoute(trainCode string, stops []entities.StationOrderDetail, modifyBy uint) (entities.StationOrder, error) {
+	route, err := s.routeRepo.GetStationOrder(trainCode)
+	if err != nil {
+		return entities.StationOrder{}, err
+	}
+
+	if len(stops) != len(route.Stations) {
+		return entities.StationOrder{}, fmt.Errorf("reorder must list all %d stops of the route", len(route.Stations))
+	}
+
+	current := make(map[string]bool, len(route.Stations))
+	for _, stop := range route.Stations {
+		current[stop.StationCode] = true
+	}
+	for _, stop := range stops {
+		if !current[stop.StationCode] {
+			return entities.StationOrder{}, fmt.Errorf("station %s is not a stop of train %s", stop.StationCode, trainCode)
+		}
+	}
+
+	return s.ReplaceRoute(trainCode, stops, modifyBy)
+}
+
+func (s *TrainService) DeleteRoute(trainCode string) error {
+	return s.routeRepo.DeleteStationOrder(trainCode)
+}
+
+// validateRoute checks the stops against the train and returns them sorted by Order.
+// A route must start at the train's origin, end at its destination, visit every station
+// once, number its stops 1..n without gaps and move forward in time and distance from stop
+// to stop, starting at kilometre 0.
+func (s *TrainService) validateRoute(trainCode string, stops []entities.StationOrderDetail) ([]entities.StationOrderDetail, error) {
+	train, err := s.trainRepo.GetTrainByCode(trainCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stops) < 2 {
+		return nil, fmt.Errorf("a route needs at least two stops")
+	}
+
+	sorted := make([]entities.StationOrderDetail, len(stops))
+	copy(sorted, stops)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+
+	seen := make(map[string]bool, len(sorted))
+	for i, stop := range sorted {
+		if stop.StationCode == "" {
+			return nil, fmt.Errorf("station code of stop %d is required", stop.Order)
+		}
+		if stop.Order != i+1 {
+			return nil, fmt.Errorf("stop orders must run from 1 to %d without gaps or duplicates", len(sorted))
+		}
+		if seen[stop.StationCode] {
+			return nil, fmt.Errorf("station %s appears more than once in the route", stop.StationCode)
+		}
+		seen[stop.StationCode] = true
+
+		if stop.ArrivalOffset < 0 || stop.DepartureOffset < 0 {
+			return nil, fmt.Errorf("times of stop %d cannot be negative", stop.Order)
+		}
+		if stop.DepartureOffset < stop.ArrivalOffset {
+			return nil, fmt.Errorf("train departs stop %d before it arrives", stop.Order)
+		}
+		if i > 0 && stop.ArrivalOffset <= sorted[i-1].DepartureOffset {
+			return nil, fmt.Errorf("train must arrive at stop %d after it departs stop %d", stop.Order, sorted[i-1].Order)
+		}
+		if i == 0 && stop.DistanceKm != 0 {
+			return nil, fmt.Errorf("distance of the first stop must be 0")
+		}
+		if i > 0 && stop.DistanceKm <= sorted[i-1].DistanceKm {
+			return nil, fmt.Errorf("stop %d must be further along the track than stop %d", stop.Order, sorted[i-1].Order)
+		}
+	}
+
+	if sorted[0].StationCode != train.FromStationCode {
+		return nil, fmt.Errorf("route must start at the train's origin station %s", train.FromStationCode)
+	}
+	if sorted[len(sorted)-1].StationCode != train.ToStationCode {
+		return nil, fmt.Errorf("route must end at the train's destination station %s", train.ToStationCode)
+	}
+
+	// Soft-deleted stations are not found by GetTrainStationByCode, so they are rejected here
+	for _, stop := range sorted {
+		if _, err := s.repo.GetTrainStationByCode(stop.StationCode); err != nil {
+			return nil, fmt.Errorf("train station with code %s not found or deleted", stop.StationCode)
+		}
+	}
+
+	return sorted, nil
+}
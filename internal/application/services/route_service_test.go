@@ -0,0 +1,131 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// memoryRouteRepository keeps the route of one train in memory.
+type memoryRouteRepository struct {
+	interfaces.StationOrderRepository
+	route entities.StationOrder
+}
+
+func (r *memoryRouteRepository) GetStationOrder(trainCode string) (entities.StationOrder, error) {
+	return r.route, nil
+}
+
+func (r *memoryRouteRepository) ReplaceStationOrder(trainCode string, stops []entities.StationOrderDetail, modifyBy uint) (entities.StationOrder, error) {
+	r.route.Stations = stops
+	return r.route, nil
+}
+
+// memoryTrainRepository serves a single train.
+type memoryTrainRepository struct {
+	interfaces.TrainRepository
+	train entities.Train
+}
+
+func (r memoryTrainRepository) GetTrainByCode(code string) (entities.Train, error) {
+	return r.train, nil
+}
+
+// memoryStationRepository finds every station.
+type memoryStationRepository struct {
+	interfaces.StationTypeRepository
+}
+
+func (r memoryStationRepository) GetTrainStationByCode(code string) (entities.TrainStation, error) {
+	return entities.TrainStation{Code: code}, nil
+}
+
+func TestReorderRoute(t *testing.T) {
+	stop := func(code string, order, arrival, departure, distance int) entities.StationOrderDetail {
+		return entities.StationOrderDetail{
+			StationCode:     code,
+			Order:           order,
+			ArrivalOffset:   arrival,
+			DepartureOffset: departure,
+			DistanceKm:      distance,
+		}
+	}
+	route := entities.StationOrder{
+		TrainCode: "SP001",
+		Stations: []entities.StationOrderDetail{
+			stop("BKK", 1, 480, 480, 0),
+			stop("AYA", 2, 540, 545, 70),
+			stop("LBI", 3, 600, 605, 130),
+			stop("NKS", 4, 720, 720, 250),
+		},
+	}
+
+	tests := []struct {
+		name    string
+		stops   []entities.StationOrderDetail
+		wantErr bool
+	}{
+		{
+			name: "two intermediate stops swapped",
+			stops: []entities.StationOrderDetail{
+				stop("BKK", 1, 480, 480, 0),
+				stop("LBI", 2, 550, 555, 75),
+				stop("AYA", 3, 610, 615, 140),
+				stop("NKS", 4, 720, 720, 250),
+			},
+		},
+		{
+			name: "swapped stops keep their old times",
+			stops: []entities.StationOrderDetail{
+				stop("BKK", 1, 480, 480, 0),
+				stop("LBI", 2, 600, 605, 130),
+				stop("AYA", 3, 540, 545, 70),
+				stop("NKS", 4, 720, 720, 250),
+			},
+			wantErr: true,
+		},
+		{
+			name: "station not on the route",
+			stops: []entities.StationOrderDetail{
+				stop("BKK", 1, 480, 480, 0),
+				stop("SRI", 2, 550, 555, 75),
+				stop("AYA", 3, 610, 615, 140),
+				stop("NKS", 4, 720, 720, 250),
+			},
+			wantErr: true,
+		},
+		{
+			name: "stop left out",
+			stops: []entities.StationOrderDetail{
+				stop("BKK", 1, 480, 480, 0),
+				stop("AYA", 2, 540, 545, 70),
+				stop("NKS", 3, 720, 720, 250),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routeRepo := &memoryRouteRepository{route: route}
+			trains := memoryTrainRepository{train: entities.Train{Code: "SP001", FromStationCode: "BKK", ToStationCode: "NKS"}}
+			service := NewTrainService(memoryStationRepository{}, trains, routeRepo, nil)
+
+			got, err := service.ReorderRoute("SP001", tt.stops, 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReorderRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !reflect.DeepEqual(routeRepo.route, route) {
+					t.Errorf("rejected reorder changed the route to %+v", routeRepo.route.Stations)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got.Stations, tt.stops) {
+				t.Errorf("ReorderRoute() = %+v, want %+v", got.Stations, tt.stops)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// SeatAllocator picks seats for passengers who did not choose them. Implementations can be
+// swapped without touching booking.
+type SeatAllocator interface {
+	// Allocate picks count seats out of coaches, which only hold the seats that are free
+	// on every leg of the trip. It returns interfaces.ErrSeatsUnavailable when there are
+	// not enough of them.
+	Allocate(coaches []entities.Coach, count int) ([]SeatSelection, error)
+}
+
+// GroupSeatAllocator keeps a party together. It looks for, in order:
+//  1. a row of neighbouring seats in one coach,
+//  2. a block of consecutive rows in one coach,
+//  3. any seats in one coach,
+//  4. any seats on the train.
+//
+// Ties go to the lowest coach, row and column, so the same seat map always gives the same
+// seats.
+type GroupSeatAllocator struct{}
+
+func NewGroupSeatAllocator() *GroupSeatAllocator {
+	return &GroupSeatAllocator{}
+}
+
+// Allocate implements SeatAllocator.
+func (a *GroupSeatAllocator) Allocate(coaches []entities.Coach, count int) ([]SeatSelection, error) {
+	if count < 1 {
+		return nil, nil
+	}
+
+	coaches = sortedCoaches(coaches)
+
+	// Prefer the tightest fitting run so larger runs stay free for larger parties
+	var best []entities.Seat
+	bestCoach := 0
+	for _, coach := range coaches {
+		for _, run := range seatRuns(coach.Seats) {
+			if len(run) >= count && (best == nil || len(run) < len(best)) {
+				best, bestCoach = run, coach.Number
+			}
+		}
+	}
+	if best != nil {
+		return seatSelections(bestCoach, best[:count]), nil
+	}
+
+	for _, coach := range coaches {
+		if seats := rowBlock(coach.Seats, count); seats != nil {
+			return seatSelections(coach.Number, seats), nil
+		}
+	}
+
+	for _, coach := range coaches {
+		if len(coach.Seats) >= count {
+			return seatSelections(coach.Number, coach.Seats[:count]), nil
+		}
+	}
+
+	var selections []SeatSelection
+	for _, coach := range coaches {
+		for _, seat := range coach.Seats {
+			selections = append(selections, SeatSelection{CoachNumber: coach.Number, SeatNumber: seat.Number})
+			if len(selections) == count {
+				return selections, nil
+			}
+		}
+	}
+	return nil, interfaces.ErrSeatsUnavailable
+}
+
+// sortedCoaches copies the coaches in coach number order with their seats in layout order.
+func sortedCoaches(coaches []entities.Coach) []entities.Coach {
+	sorted := make([]entities.Coach, len(coaches))
+	for i, coach := range coaches {
+		seats := append([]entities.Seat(nil), coach.Seats...)
+		sort.SliceStable(seats, func(i, j int) bool {
+			if seats[i].Row != seats[j].Row {
+				return seats[i].Row < seats[j].Row
+			}
+			if seats[i].Column != seats[j].Column {
+				return seats[i].Column < seats[j].Column
+			}
+			return seats[i].Berth < seats[j].Berth // lower before upper
+		})
+		coach.Seats = seats
+		sorted[i] = coach
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Number < sorted[j].Number
+	})
+	return sorted
+}
+
+// seatRuns splits sorted seats into runs of neighbours: seats in the same row whose columns
+// are at most one apart. Berths above each other share a column and so are neighbours.
+func seatRuns(seats []entities.Seat) [][]entities.Seat {
+	var runs [][]entities.Seat
+	for i, seat := range seats {
+		if i > 0 && seat.Row == seats[i-1].Row && seat.Column-seats[i-1].Column <= 1 {
+			runs[len(runs)-1] = append(runs[len(runs)-1], seat)
+			continue
+		}
+		runs = append(runs, []entities.Seat{seat})
+	}
+	return runs
+}
+
+// rowBlock returns the first count seats of the first block of consecutive rows, each with
+// a free seat, that holds at least count seats.
+func rowBlock(seats []entities.Seat, count int) []entities.Seat {
+	for start := 0; start < len(seats); {
+		end := start
+		for end < len(seats) && seats[end].Row == seats[start].Row {
+			end++
+		}
+		// Grow the block one row at a time while rows follow each other
+		block := end
+		for block-start < count && block < len(seats) && seats[block].Row == seats[block-1].Row+1 {
+			row := seats[block].Row
+			for block < len(seats) && seats[block].Row == row {
+				block++
+			}
+		}
+		if block-start >= count {
+			return seats[start : start+count]
+		}
+		start = end
+	}
+	return nil
+}
+
+func seatSelections(coachNumber int, seats []entities.Seat) []SeatSelection {
+	selections := make([]SeatSelection, len(seats))
+	for i, seat := range seats {
+		selections[i] = SeatSelection{CoachNumber: coachNumber, SeatNumber: seat.Number}
+	}
+	return selections
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// sampleCoach lays out rows of seats numbered "<row><column letter>", e.g. 1A, 1B, leaving
+// out the seats listed in taken.
+func sampleCoach(number, rows, columns int, taken ...string) entities.Coach {
+	skip := make(map[string]bool, len(taken))
+	for _, seat := range taken {
+		skip[seat] = true
+	}
+
+	coach := entities.Coach{Number: number, Class: 2}
+	for row := 1; row <= rows; row++ {
+		for column := 1; column <= columns; column++ {
+			seatNumber := fmt.Sprintf("%d%c", row, 'A'+column-1)
+			if skip[seatNumber] {
+				continue
+			}
+			coach.Seats = append(coach.Seats, entities.Seat{Number: seatNumber, Row: row, Column: column})
+		}
+	}
+	return coach
+}
+
+func selections(coachNumber int, seatNumbers ...string) []SeatSelection {
+	result := make([]SeatSelection, len(seatNumbers))
+	for i, seatNumber := range seatNumbers {
+		result[i] = SeatSelection{CoachNumber: coachNumber, SeatNumber: seatNumber}
+	}
+	return result
+}
+
+func TestGroupSeatAllocator(t *testing.T) {
+	tests := []struct {
+		name    string
+		coaches []entities.Coach
+		count   int
+		want    []SeatSelection
+		wantErr error
+	}{
+		{
+			name:    "empty coach fills the first row",
+			coaches: []entities.Coach{sampleCoach(1, 10, 4)},
+			count:   4,
+			want:    selections(1, "1A", "1B", "1C", "1D"),
+		},
+		{
+			name:    "skips rows that are broken up",
+			coaches: []entities.Coach{sampleCoach(1, 3, 4, "1B", "2C")},
+			count:   4,
+			want:    selections(1, "3A", "3B", "3C", "3D"),
+		},
+		{
+			name:    "prefers the tightest row so full rows stay free",
+			coaches: []entities.Coach{sampleCoach(1, 3, 4, "1A", "1B")},
+			count:   2,
+			want:    selections(1, "1C", "1D"),
+		},
+		{
+			name:    "prefers seats together in a later coach",
+			coaches: []entities.Coach{sampleCoach(1, 2, 4, "1B", "2C"), sampleCoach(2, 2, 4)},
+			count:   3,
+			want:    selections(2, "1A", "1B", "1C"),
+		},
+		{
+			name:    "uses consecutive rows when no row is wide enough",
+			coaches: []entities.Coach{sampleCoach(1, 4, 2, "1A", "1B", "2A")},
+			count:   4,
+			want:    selections(1, "2B", "3A", "3B", "4A"),
+		},
+		{
+			name:    "falls back to scattered seats in one coach",
+			coaches: []entities.Coach{sampleCoach(1, 4, 2, "1B", "2A", "2B", "3A", "3B"), sampleCoach(2, 1, 2, "1A")},
+			count:   3,
+			want:    selections(1, "1A", "4A", "4B"),
+		},
+		{
+			name:    "spreads over coaches as a last resort",
+			coaches: []entities.Coach{sampleCoach(1, 1, 2, "1A"), sampleCoach(2, 1, 2, "1B")},
+			count:   2,
+			want:    []SeatSelection{{CoachNumber: 1, SeatNumber: "1B"}, {CoachNumber: 2, SeatNumber: "1A"}},
+		},
+		{
+			name:    "orders coaches and seats by layout",
+			coaches: []entities.Coach{reversed(sampleCoach(2, 1, 2)), reversed(sampleCoach(1, 1, 2))},
+			count:   2,
+			want:    selections(1, "1A", "1B"),
+		},
+		{
+			name:    "fails when too few seats are free",
+			coaches: []entities.Coach{sampleCoach(1, 1, 4, "1A", "1B", "1C")},
+			count:   2,
+			wantErr: interfaces.ErrSeatsUnavailable,
+		},
+	}
+
+	allocator := NewGroupSeatAllocator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := allocator.Allocate(tt.coaches, tt.count)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Allocate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Allocate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupSeatAllocatorBerths(t *testing.T) {
+	coach := entities.Coach{Number: 3, Class: 2, Seats: []entities.Seat{
+		{Number: "2", Row: 1, Column: 1, Berth: entities.BerthUpper},
+		{Number: "1", Row: 1, Column: 1, Berth: entities.BerthLower},
+		{Number: "4", Row: 2, Column: 1, Berth: entities.BerthUpper},
+		{Number: "3", Row: 2, Column: 1, Berth: entities.BerthLower},
+	}}
+
+	got, err := NewGroupSeatAllocator().Allocate([]entities.Coach{coach}, 2)
+	if err != nil {
+		t.Fatalf("Allocate() error = %v", err)
+	}
+	if want := selections(3, "1", "2"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Allocate() = %v, want %v", got, want)
+	}
+}
+
+func reversed(coach entities.Coach) entities.Coach {
+	seats := make([]entities.Seat, len(coach.Seats))
+	for i, seat := range coach.Seats {
+		seats[len(seats)-1-i] = seat
+	}
+	coach.Seats = seats
+	return coach
+}
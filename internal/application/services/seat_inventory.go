@@ -0,0 +1,615 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+const (
+	// DefaultInventoryFlushInterval is how often seat counter changes are written to Postgres.
+	DefaultInventoryFlushInterval = time.Second
+	// DefaultInventoryReconcileInterval is how often the Redis counters are checked against Postgres.
+	DefaultInventoryReconcileInterval = 5 * time.Minute
+
+	// Runs with pending changes and runs loaded into Redis
+	inventoryDirtyKey    = "seat_inventory:dirty"
+	inventoryRunsKey     = "seat_inventory:runs"
+	inventoryFlushSeqKey = "seat_inventory:flush_seq"
+	flushIDField         = "flush_id"
+)
+
+// Per run keys, with {leg} being "<from order>:<to order>":
+//
+//	seat_inventory:<run>:legs          hash {leg} -> free seats
+//	seat_inventory:<run>:seats:{leg}   set of seat IDs taken on the leg
+//	seat_inventory:<run>:pending       hash {leg} -> change not yet written to Postgres
+//	seat_inventory:<run>:flushing      pending changes being written, plus their flush_id
+//
+// The scripts build the seat set keys from a prefix, so the keys of a run must live on a
+// single Redis node.
+type inventoryKeys struct {
+	legs, pending, flushing, seats string
+}
+
+func runInventoryKeys(trainRunID uint) inventoryKeys {
+	prefix := fmt.Sprintf("seat_inventory:%d:", trainRunID)
+	return inventoryKeys{
+		legs:     prefix + "legs",
+		pending:  prefix + "pending",
+		flushing: prefix + "flushing",
+		seats:    prefix + "seats:",
+	}
+}
+
+func legField(fromOrder, toOrder int) string {
+	return fmt.Sprintf("%d:%d", fromOrder, toOrder)
+}
+
+// reserveScript takes count seats, and the given seat IDs, on every leg from ARGV[3] to
+// ARGV[4]. It returns 1 when reserved, 0 when a leg has too few seats, -2 when one of the
+// seats is taken and -1 when the run is not loaded or its legs do not match the trip.
+var reserveScript = redis.NewScript(`
+local fields = redis.call('HGETALL', KEYS[1])
+if #fields == 0 then return -1 end
+local legs = {}
+for i = 1, #fields, 2 do
+  local from, to = string.match(fields[i], '^(%d+):(%d+)$')
+  legs[tonumber(from)] = {field = fields[i], to = tonumber(to), available = tonumber(fields[i + 1])}
+end
+
+local count = tonumber(ARGV[5])
+local stop, last = tonumber(ARGV[3]), tonumber(ARGV[4])
+local covered = {}
+while stop < last do
+  local leg = legs[stop]
+  if leg == nil then return -1 end
+  if leg.available < count then return 0 end
+  for j = 6, #ARGV do
+    if redis.call('SISMEMBER', ARGV[2] .. leg.field, ARGV[j]) == 1 then return -2 end
+  end
+  table.insert(covered, leg.field)
+  stop = leg.to
+end
+if stop ~= last then return -1 end
+
+for _, field in ipairs(covered) do
+  redis.call('HINCRBY', KEYS[1], field, -count)
+  redis.call('HINCRBY', KEYS[2], field, -count)
+  for j = 6, #ARGV do
+    redis.call('SADD', ARGV[2] .. field, ARGV[j])
+  end
+end
+redis.call('SADD', KEYS[3], ARGV[1])
+return 1
+`)
+
+// releaseScript gives back what reserveScript took. With ARGV[5] set to 1 the change is also
+// taken off the pending writes, for a reservation that never reached Postgres. It returns 0
+// when the run is not loaded, Postgres then already has the truth.
+var releaseScript = redis.NewScript(`
+local fields = redis.call('HGETALL', KEYS[1])
+if #fields == 0 then return 0 end
+local legs = {}
+for i = 1, #fields, 2 do
+  local from, to = string.match(fields[i], '^(%d+):(%d+)$')
+  legs[tonumber(from)] = {field = fields[i], to = tonumber(to)}
+end
+
+local count = tonumber(ARGV[4])
+local stop, last = tonumber(ARGV[2]), tonumber(ARGV[3])
+while stop < last do
+  local leg = legs[stop]
+  if leg == nil then return -1 end
+  redis.call('HINCRBY', KEYS[1], leg.field, count)
+  if ARGV[5] == '1' then
+    redis.call('HINCRBY', KEYS[2], leg.field, count)
+  end
+  for j = 7, #ARGV do
+    redis.call('SREM', ARGV[1] .. leg.field, ARGV[j])
+  end
+  stop = leg.to
+end
+if ARGV[5] == '1' then
+  redis.call('SADD', KEYS[3], ARGV[6])
+end
+return 1
+`)
+
+// loadScript copies a Postgres snapshot of a run into Redis unless it is loaded already.
+// Changes still waiting to be written are added on top of the snapshot. ARGV[4] is the
+// number of legs, followed by leg, free seats and comma separated seat IDs for each.
+var loadScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then return 0 end
+local legs = tonumber(ARGV[4])
+for i = 0, legs - 1 do
+  local field, seats = ARGV[5 + i * 3], ARGV[7 + i * 3]
+  local available = tonumber(ARGV[6 + i * 3])
+  available = available + tonumber(redis.call('HGET', KEYS[2], field) or '0')
+  available = available + tonumber(redis.call('HGET', KEYS[3], field) or '0')
+  redis.call('HSET', KEYS[1], field, available)
+
+  local key = ARGV[2] .. field
+  redis.call('DEL', key)
+  for seat in string.gmatch(seats, '%d+') do
+    redis.call('SADD', key, seat)
+  end
+  redis.call('EXPIREAT', key, ARGV[3])
+end
+redis.call('EXPIREAT', KEYS[1], ARGV[3])
+redis.call('SADD', KEYS[4], ARGV[1])
+return 1
+`)
+
+// claimScript moves the pending changes of a run aside for writing and tags them with a
+// flush ID. A batch left over by a failed write is returned again with its old ID, the run
+// then stays dirty for the changes made since.
+var claimScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 0 then
+  if redis.call('EXISTS', KEYS[1]) == 0 then
+    redis.call('SREM', KEYS[3], ARGV[1])
+    return {}
+  end
+  redis.call('RENAME', KEYS[1], KEYS[2])
+  redis.call('HSET', KEYS[2], 'flush_id', ARGV[1] .. '-' .. redis.call('INCR', KEYS[4]))
+end
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  redis.call('SREM', KEYS[3], ARGV[1])
+end
+return redis.call('HGETALL', KEYS[2])
+`)
+
+// invalidateScript drops a run from Redis so the next booking loads it from Postgres again.
+// Runs with changes not written yet are kept, as Postgres does not have them.
+var invalidateScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 or redis.call('EXISTS', KEYS[3]) == 1 then return 0 end
+for _, field in ipairs(redis.call('HKEYS', KEYS[1])) do
+  redis.call('DEL', ARGV[2] .. field)
+end
+redis.call('DEL', KEYS[1])
+redis.call('SREM', KEYS[4], ARGV[1])
+return 1
+`)
+
+// InventoryMismatch is a leg whose free seats in Redis differ from Postgres plus the
+// changes not written to it yet, or whose taken seats differ from the seat reservations.
+type InventoryMismatch struct {
+	TrainRunID   uint   `json:"train_run_id"`
+	Leg          string `json:"leg"`
+	Redis        int    `json:"redis"`
+	Postgres     int    `json:"postgres"`
+	Pending      int    `json:"pending"`
+	MissingSeats []uint `json:"missing_seats,omitempty"` // Reserved in Postgres but free in Redis
+	ExtraSeats   []uint `json:"extra_seats,omitempty"`   // Taken in Redis without a reservation
+	Repaired     bool   `json:"repaired"`
+}
+
+// SeatInventory keeps the seat counters of train runs in Redis, so bookings of the same run
+// are checked and counted atomically by a Lua script instead of queueing on leg row locks.
+// Postgres stays the record: bookings are inserted right away and the leg counters follow
+// through a write-behind flush. A run is loaded into Redis on its first booking. Changes
+// made to legs in Postgres only, e.g. a new consist, reach Redis through Reconcile.
+type SeatInventory struct {
+	repo    interfaces.BookingRepository
+	runRepo interfaces.TrainRunRepository
+}
+
+func NewSeatInventory(repo interfaces.BookingRepository, runRepo interfaces.TrainRunRepository) *SeatInventory {
+	return &SeatInventory{repo: repo, runRepo: runRepo}
+}
+
+// Reserve takes the seats of the booking from its run and sets booking.TrainRunID. Once it
+// succeeds the booking must be saved, or handed to Undo.
+func (i *SeatInventory) Reserve(booking *entities.Booking) error {
+	run, err := i.runRepo.GetRun(booking.TrainCode, booking.ServiceDate)
+	if err != nil {
+		return err
+	}
+	if !run.Bookable() {
+		return fmt.Errorf("%w: train %s on %s is %s", interfaces.ErrTrainRunNotBookable,
+			run.TrainCode, run.ServiceDate.Format(time.DateOnly), run.Status)
+	}
+	booking.TrainRunID = run.ID
+
+	seatIDs := ticketSeatIDs(booking.Tickets)
+	for attempt := 0; attempt < 2; attempt++ {
+		result, err := i.reserve(run.ID, booking.FromOrder, booking.ToOrder, booking.SeatCount, seatIDs)
+		if err != nil {
+			return err
+		}
+		switch result {
+		case 1:
+			return nil
+		case 0:
+			return interfaces.ErrSeatsUnavailable
+		case -2:
+			return interfaces.ErrSeatsTaken
+		}
+
+		// Not loaded yet, or loaded before the route changed
+		if attempt == 0 {
+			if err := i.load(run); err != nil {
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("seat inventory of train %s on %s is out of date, try again later",
+		run.TrainCode, run.ServiceDate.Format(time.DateOnly))
+}
+
+// Undo gives back the seats of a reserved booking that could not be saved.
+func (i *SeatInventory) Undo(booking entities.Booking) {
+	if err := i.release(booking, true); err != nil {
+		log.Printf("Failed to undo seat reservation of booking %s: %v", booking.Reference, err)
+	}
+}
+
+// Release gives back the seats of a booking that Postgres already released, e.g. when it
+// is cancelled.
+func (i *SeatInventory) Release(booking entities.Booking) {
+	if err := i.release(booking, false); err != nil {
+		log.Printf("Failed to release seats of booking %s: %v", booking.Reference, err)
+	}
+}
+
+// Available returns the free seats from stop fromOrder to stop toOrder of a train run. Runs
+// loaded into Redis are read from there, as their Postgres legs lag behind by up to one
+// flush.
+func (i *SeatInventory) Available(trainCode string, serviceDate time.Time, fromOrder, toOrder int) (int, error) {
+	run, err := i.runRepo.GetRun(trainCode, serviceDate)
+	if err != nil {
+		return 0, err
+	}
+	if !run.Bookable() {
+		return 0, nil
+	}
+
+	fields, err := redisClient.HGetAll(ctx, runInventoryKeys(run.ID).legs).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read seat inventory: %v", err)
+	}
+
+	available := -1
+	for field, value := range fields {
+		from, to, ok := parseLegField(field)
+		if !ok || from < fromOrder || to > toOrder {
+			continue
+		}
+		if seats := atoi(value); available < 0 || seats < available {
+			available = seats
+		}
+	}
+	if available < 0 {
+		return i.repo.GetAvailableSeats(trainCode, serviceDate, fromOrder, toOrder)
+	}
+	return available, nil
+}
+
+// Flush writes the pending seat counter changes of every run to Postgres and returns how
+// many runs were written.
+func (i *SeatInventory) Flush() (int, error) {
+	runIDs, err := redisClient.SMembers(ctx, inventoryDirtyKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read runs to flush: %v", err)
+	}
+
+	flushed := 0
+	for _, member := range runIDs {
+		id, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			redisClient.SRem(ctx, inventoryDirtyKey, member)
+			continue
+		}
+		if err := i.flushRun(uint(id)); err != nil {
+			// Try again on the next flush
+			redisClient.SAdd(ctx, inventoryDirtyKey, member)
+			return flushed, err
+		}
+		flushed++
+	}
+	return flushed, nil
+}
+
+// StartWriteBehind flushes pending seat counter changes every interval.
+func (i *SeatInventory) StartWriteBehind(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if _, err := i.Flush(); err != nil {
+				log.Printf("Failed to flush seat inventory: %v", err)
+			}
+		}
+	}()
+}
+
+// Reconcile compares the free seats of every run in Redis with Postgres plus the changes
+// not written yet, and the seats taken in Redis with the seat reservations. A booking in
+// flight may show up as an extra seat for a moment. A mismatched run without pending
+// changes is dropped from Redis, so it is loaded again from Postgres on its next booking.
+func (i *SeatInventory) Reconcile() ([]InventoryMismatch, error) {
+	runIDs, err := redisClient.SMembers(ctx, inventoryRunsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read loaded runs: %v", err)
+	}
+
+	var mismatches []InventoryMismatch
+	for _, member := range runIDs {
+		id, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			redisClient.SRem(ctx, inventoryRunsKey, member)
+			continue
+		}
+		runMismatches, err := i.reconcileRun(uint(id))
+		if err != nil {
+			return mismatches, err
+		}
+		mismatches = append(mismatches, runMismatches...)
+	}
+	return mismatches, nil
+}
+
+// StartReconciler runs Reconcile every interval and logs what it finds.
+func (i *SeatInventory) StartReconciler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			mismatches, err := i.Reconcile()
+			if err != nil {
+				log.Printf("Failed to reconcile seat inventory: %v", err)
+			}
+			for _, m := range mismatches {
+				log.Printf("Seat inventory mismatch on run %d leg %s: redis %d, postgres %d, pending %d, missing seats %v, extra seats %v, repaired %t",
+					m.TrainRunID, m.Leg, m.Redis, m.Postgres, m.Pending, m.MissingSeats, m.ExtraSeats, m.Repaired)
+			}
+		}
+	}()
+}
+
+func (i *SeatInventory) reserve(trainRunID uint, fromOrder, toOrder, count int, seatIDs []uint) (int64, error) {
+	keys := runInventoryKeys(trainRunID)
+	args := []interface{}{trainRunID, keys.seats, fromOrder, toOrder, count}
+	for _, id := range seatIDs {
+		args = append(args, id)
+	}
+
+	result, err := reserveScript.Run(ctx, redisClient, []string{keys.legs, keys.pending, inventoryDirtyKey}, args...).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve seats: %v", err)
+	}
+	return result, nil
+}
+
+func (i *SeatInventory) release(booking entities.Booking, undoPending bool) error {
+	keys := runInventoryKeys(booking.TrainRunID)
+	pending := 0
+	if undoPending {
+		pending = 1
+	}
+	args := []interface{}{keys.seats, booking.FromOrder, booking.ToOrder, booking.SeatCount, pending, booking.TrainRunID}
+	for _, id := range ticketSeatIDs(booking.Tickets) {
+		args = append(args, id)
+	}
+
+	result, err := releaseScript.Run(ctx, redisClient, []string{keys.legs, keys.pending, inventoryDirtyKey}, args...).Int64()
+	if err != nil {
+		return err
+	}
+	if result < 0 {
+		return fmt.Errorf("legs of run %d do not match the booking", booking.TrainRunID)
+	}
+	return nil
+}
+
+// load copies the legs and seat reservations of the run from Postgres into Redis. The keys
+// expire the day after the run, when it can no longer be booked.
+func (i *SeatInventory) load(run entities.TrainRun) error {
+	reservations, err := i.repo.GetSeatReservations(run.ID)
+	if err != nil {
+		return err
+	}
+	return i.loadLegs(run.ID, run.ServiceDate.AddDate(0, 0, 2), run.Legs, reservations)
+}
+
+func (i *SeatInventory) loadLegs(trainRunID uint, expireAt time.Time, legs []entities.LegInventory, reservations []entities.SeatReservation) error {
+	keys := runInventoryKeys(trainRunID)
+	args := []interface{}{trainRunID, keys.seats, expireAt.Unix(), len(legs)}
+	for _, leg := range legs {
+		var seats []string
+		for _, reservation := range reservations {
+			if reservation.FromOrder <= leg.FromOrder && reservation.ToOrder >= leg.ToOrder {
+				seats = append(seats, strconv.FormatUint(uint64(reservation.SeatID), 10))
+			}
+		}
+		args = append(args, legField(leg.FromOrder, leg.ToOrder), leg.AvailableSeats, strings.Join(seats, ","))
+	}
+
+	err := loadScript.Run(ctx, redisClient, []string{keys.legs, keys.pending, keys.flushing, inventoryRunsKey}, args...).Err()
+	if err != nil {
+		return fmt.Errorf("failed to load seat inventory of run %d: %v", trainRunID, err)
+	}
+	return nil
+}
+
+func (i *SeatInventory) flushRun(trainRunID uint) error {
+	keys := runInventoryKeys(trainRunID)
+	fields, err := claimScript.Run(ctx, redisClient,
+		[]string{keys.pending, keys.flushing, inventoryDirtyKey, inventoryFlushSeqKey}, trainRunID).StringSlice()
+	if err != nil {
+		return fmt.Errorf("failed to claim pending seats of run %d: %v", trainRunID, err)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	flushID := ""
+	deltas := make(map[int]int)
+	for j := 0; j+1 < len(fields); j += 2 {
+		if fields[j] == flushIDField {
+			flushID = fields[j+1]
+			continue
+		}
+		from, _, ok := parseLegField(fields[j])
+		if !ok {
+			continue
+		}
+		delta, _ := strconv.Atoi(fields[j+1])
+		deltas[from] += delta
+	}
+
+	if err := i.repo.ApplyLegDeltas(trainRunID, flushID, deltas); err != nil {
+		return err
+	}
+	return redisClient.Del(ctx, keys.flushing).Err()
+}
+
+func (i *SeatInventory) reconcileRun(trainRunID uint) ([]InventoryMismatch, error) {
+	keys := runInventoryKeys(trainRunID)
+	cached, err := redisClient.HGetAll(ctx, keys.legs).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seat inventory of run %d: %v", trainRunID, err)
+	}
+	if len(cached) == 0 {
+		// Expired after the run
+		redisClient.SRem(ctx, inventoryRunsKey, trainRunID)
+		return nil, nil
+	}
+	pending, err := redisClient.HGetAll(ctx, keys.pending).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending seats of run %d: %v", trainRunID, err)
+	}
+	flushing, err := redisClient.HGetAll(ctx, keys.flushing).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read flushing seats of run %d: %v", trainRunID, err)
+	}
+
+	legs, err := i.repo.GetLegInventory(trainRunID)
+	if err != nil {
+		return nil, err
+	}
+	// Read before the seat sets: a reservation is taken in Redis before it is inserted and
+	// released in Postgres before Redis, so a booking in flight shows up as extra, not missing
+	reservations, err := i.repo.GetSeatReservations(trainRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []InventoryMismatch
+	for _, leg := range legs {
+		field := legField(leg.FromOrder, leg.ToOrder)
+		notWritten := atoi(pending[field]) + atoi(flushing[field])
+		value, ok := cached[field]
+		delete(cached, field)
+
+		taken, err := redisClient.SMembers(ctx, keys.seats+field).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read taken seats of run %d: %v", trainRunID, err)
+		}
+		missing, extra := compareSeats(taken, leg, reservations)
+
+		if ok && atoi(value) == leg.AvailableSeats+notWritten && len(missing) == 0 && len(extra) == 0 {
+			continue
+		}
+		mismatches = append(mismatches, InventoryMismatch{
+			TrainRunID:   trainRunID,
+			Leg:          field,
+			Redis:        atoi(value),
+			Postgres:     leg.AvailableSeats,
+			Pending:      notWritten,
+			MissingSeats: missing,
+			ExtraSeats:   extra,
+		})
+	}
+	// Legs that are no longer on the route
+	for field, value := range cached {
+		mismatches = append(mismatches, InventoryMismatch{TrainRunID: trainRunID, Leg: field, Redis: atoi(value)})
+	}
+
+	if len(mismatches) == 0 {
+		return nil, nil
+	}
+
+	dropped, err := invalidateScript.Run(ctx, redisClient,
+		[]string{keys.legs, keys.pending, keys.flushing, inventoryRunsKey}, trainRunID, keys.seats).Int64()
+	if err != nil {
+		return mismatches, fmt.Errorf("failed to drop seat inventory of run %d: %v", trainRunID, err)
+	}
+	for j := range mismatches {
+		mismatches[j].Repaired = dropped == 1
+	}
+	return mismatches, nil
+}
+
+// compareSeats compares the seat IDs taken on the leg in Redis with the reservations
+// covering the leg. It returns the reserved seats Redis would sell again, and the seats
+// Redis holds without a reservation, both in ascending order.
+func compareSeats(taken []string, leg entities.LegInventory, reservations []entities.SeatReservation) ([]uint, []uint) {
+	inRedis := make(map[uint]bool, len(taken))
+	for _, member := range taken {
+		if id, err := strconv.ParseUint(member, 10, 64); err == nil {
+			inRedis[uint(id)] = true
+		}
+	}
+
+	var missing, extra []uint
+	reserved := make(map[uint]bool)
+	for _, reservation := range reservations {
+		if reservation.FromOrder > leg.FromOrder || reservation.ToOrder < leg.ToOrder {
+			continue
+		}
+		reserved[reservation.SeatID] = true
+		if !inRedis[reservation.SeatID] {
+			missing = append(missing, reservation.SeatID)
+		}
+	}
+	for id := range inRedis {
+		if !reserved[id] {
+			extra = append(extra, id)
+		}
+	}
+
+	sort.Slice(missing, func(a, b int) bool { return missing[a] < missing[b] })
+	sort.Slice(extra, func(a, b int) bool { return extra[a] < extra[b] })
+	return missing, extra
+}
+
+func ticketSeatIDs(tickets []entities.Ticket) []uint {
+	var seatIDs []uint
+	for _, ticket := range tickets {
+		if ticket.SeatID != nil {
+			seatIDs = append(seatIDs, *ticket.SeatID)
+		}
+	}
+	return seatIDs
+}
+
+func parseLegField(field string) (int, int, bool) {
+	from, to, found := strings.Cut(field, ":")
+	if !found {
+		return 0, 0, false
+	}
+	fromOrder, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, false
+	}
+	toOrder, err := strconv.Atoi(to)
+	if err != nil {
+		return 0, 0, false
+	}
+	return fromOrder, toOrder, true
+}
+
+func atoi(value string) int {
+	n, _ := strconv.Atoi(value)
+	return n
+}
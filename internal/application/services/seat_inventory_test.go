@@ -0,0 +1,328 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// benchmarkRunID keeps the benchmark's keys apart from real runs.
+const benchmarkRunID = 4_000_000_000
+
+// BenchmarkSeatInventoryReserve books the same train run from many goroutines at once,
+// the way a sale opening does. It needs the Redis server used by the services and is
+// skipped without one. Run it with:
+//
+//	go test ./internal/application/services -run '^$' -bench SeatInventory -cpu 1,8,32
+func BenchmarkSeatInventoryReserve(b *testing.B) {
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		b.Skipf("redis is not available: %v", err)
+	}
+
+	// Three stops, booked end to end, with enough seats to never run out
+	legs := []entities.LegInventory{
+		{FromOrder: 1, ToOrder: 2, AvailableSeats: 1 << 30},
+		{FromOrder: 2, ToOrder: 3, AvailableSeats: 1 << 30},
+	}
+
+	b.Run("seat count", func(b *testing.B) {
+		inventory := loadBenchmarkRun(b, legs)
+
+		b.SetParallelism(16)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if result, err := inventory.reserve(benchmarkRunID, 1, 3, 1, nil); err != nil || result != 1 {
+					b.Errorf("reserve() = %d, %v", result, err)
+					return
+				}
+			}
+		})
+		b.StopTimer()
+
+		checkBenchmarkRun(b, legs, b.N)
+	})
+
+	b.Run("picked seats", func(b *testing.B) {
+		inventory := loadBenchmarkRun(b, legs)
+
+		var nextSeat atomic.Uint64
+		b.SetParallelism(16)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				seatID := uint(nextSeat.Add(1))
+				if result, err := inventory.reserve(benchmarkRunID, 1, 3, 1, []uint{seatID}); err != nil || result != 1 {
+					b.Errorf("reserve() = %d, %v", result, err)
+					return
+				}
+			}
+		})
+		b.StopTimer()
+
+		checkBenchmarkRun(b, legs, b.N)
+	})
+}
+
+func loadBenchmarkRun(b *testing.B, legs []entities.LegInventory) *SeatInventory {
+	b.Helper()
+	return loadTestRun(b, NewSeatInventory(nil, nil), benchmarkRunID, legs)
+}
+
+// loadTestRun loads the legs of a run made up for a test into Redis, and removes it again
+// when the test ends.
+func loadTestRun(tb testing.TB, inventory *SeatInventory, trainRunID uint, legs []entities.LegInventory) *SeatInventory {
+	tb.Helper()
+	cleanTestRun(tb, trainRunID, legs)
+	tb.Cleanup(func() { cleanTestRun(tb, trainRunID, legs) })
+
+	if err := inventory.loadLegs(trainRunID, time.Now().Add(time.Hour), legs, nil); err != nil {
+		tb.Fatal(err)
+	}
+	return inventory
+}
+
+// checkBenchmarkRun makes sure every reservation was counted exactly once, both in the
+// seat counters and in the changes waiting to be written to Postgres.
+func checkBenchmarkRun(b *testing.B, legs []entities.LegInventory, reserved int) {
+	b.Helper()
+	keys := runInventoryKeys(benchmarkRunID)
+	for _, leg := range legs {
+		field := legField(leg.FromOrder, leg.ToOrder)
+		available, err := redisClient.HGet(ctx, keys.legs, field).Int()
+		if err != nil {
+			b.Fatal(err)
+		}
+		pending, err := redisClient.HGet(ctx, keys.pending, field).Int()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if available != leg.AvailableSeats-reserved || pending != -reserved {
+			b.Fatalf("leg %s: %d free and %d pending after %d reservations", field, available, pending, reserved)
+		}
+	}
+	b.ReportMetric(float64(reserved)/b.Elapsed().Seconds(), "bookings/s")
+}
+
+func cleanTestRun(tb testing.TB, trainRunID uint, legs []entities.LegInventory) {
+	tb.Helper()
+	keys := runInventoryKeys(trainRunID)
+	toDelete := []string{keys.legs, keys.pending, keys.flushing}
+	for _, leg := range legs {
+		toDelete = append(toDelete, keys.seats+legField(leg.FromOrder, leg.ToOrder))
+	}
+	if err := redisClient.Del(ctx, toDelete...).Err(); err != nil {
+		tb.Fatal(err)
+	}
+	redisClient.SRem(ctx, inventoryDirtyKey, trainRunID)
+	redisClient.SRem(ctx, inventoryRunsKey, trainRunID)
+}
+
+// skipWithoutRedis skips tests that need the Redis server used by the services.
+func skipWithoutRedis(t *testing.T) {
+	t.Helper()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		t.Skipf("redis is not available: %v", err)
+	}
+}
+
+func TestSeatInventoryNoOverbooking(t *testing.T) {
+	skipWithoutRedis(t)
+
+	const seats, customers = 10, 50
+	legs := []entities.LegInventory{
+		{FromOrder: 1, ToOrder: 2, AvailableSeats: seats},
+		{FromOrder: 2, ToOrder: 3, AvailableSeats: seats},
+	}
+
+	t.Run("seat count", func(t *testing.T) {
+		inventory := loadTestRun(t, NewSeatInventory(nil, nil), benchmarkRunID+1, legs)
+
+		// Half book the whole trip, half only the second leg
+		var reserved atomic.Int64
+		var wg sync.WaitGroup
+		for customer := 0; customer < customers; customer++ {
+			wg.Add(1)
+			go func(fromOrder int) {
+				defer wg.Done()
+				result, err := inventory.reserve(benchmarkRunID+1, fromOrder, 3, 1, nil)
+				if err != nil {
+					t.Error(err)
+				}
+				if result == 1 {
+					reserved.Add(1)
+				}
+			}(1 + customer%2)
+		}
+		wg.Wait()
+
+		if reserved.Load() != seats {
+			t.Errorf("%d customers got a seat on the second leg, it has %d", reserved.Load(), seats)
+		}
+		keys := runInventoryKeys(benchmarkRunID + 1)
+		for _, leg := range legs {
+			field := legField(leg.FromOrder, leg.ToOrder)
+			available, err := redisClient.HGet(ctx, keys.legs, field).Int()
+			if err != nil {
+				t.Fatal(err)
+			}
+			pending, err := redisClient.HGet(ctx, keys.pending, field).Int()
+			if err != nil && err != redis.Nil {
+				t.Fatal(err)
+			}
+			if available < 0 || available != leg.AvailableSeats+pending {
+				t.Errorf("leg %s: %d free with %d pending", field, available, pending)
+			}
+		}
+	})
+
+	t.Run("picked seat", func(t *testing.T) {
+		inventory := loadTestRun(t, NewSeatInventory(nil, nil), benchmarkRunID+2, legs)
+
+		var reserved atomic.Int64
+		var wg sync.WaitGroup
+		for customer := 0; customer < customers; customer++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := inventory.reserve(benchmarkRunID+2, 1, 3, 1, []uint{7})
+				if err != nil {
+					t.Error(err)
+				}
+				if result == 1 {
+					reserved.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if reserved.Load() != 1 {
+			t.Errorf("seat 7 was sold %d times", reserved.Load())
+		}
+	})
+}
+
+// flushRecorder is a booking repository that applies leg deltas once per flush ID, like
+// the inventory_flushes table does. The first failReplies writes are applied but report an
+// error, as when the connection drops before the commit is acknowledged.
+type flushRecorder struct {
+	interfaces.BookingRepository
+
+	mu          sync.Mutex
+	applied     map[string]bool
+	legs        map[int]int
+	calls       int
+	failReplies int
+}
+
+func (r *flushRecorder) ApplyLegDeltas(trainRunID uint, flushID string, deltas map[int]int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls++
+	if !r.applied[flushID] {
+		r.applied[flushID] = true
+		for fromOrder, delta := range deltas {
+			r.legs[fromOrder] += delta
+		}
+	}
+	if r.failReplies > 0 {
+		r.failReplies--
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+func TestSeatInventoryFlushAppliesOnce(t *testing.T) {
+	skipWithoutRedis(t)
+
+	const trainRunID = benchmarkRunID + 3
+	legs := []entities.LegInventory{
+		{FromOrder: 1, ToOrder: 2, AvailableSeats: 20},
+		{FromOrder: 2, ToOrder: 3, AvailableSeats: 20},
+	}
+	repo := &flushRecorder{applied: make(map[string]bool), legs: make(map[int]int), failReplies: 1}
+	inventory := loadTestRun(t, NewSeatInventory(repo, nil), trainRunID, legs)
+	// Other runs may be dirty in a shared Redis, flush only this one
+	flush := func() error {
+		t.Helper()
+		return inventory.flushRun(trainRunID)
+	}
+
+	for n := 0; n < 3; n++ {
+		if result, err := inventory.reserve(trainRunID, 1, 3, 1, nil); err != nil || result != 1 {
+			t.Fatalf("reserve() = %d, %v", result, err)
+		}
+	}
+	if err := flush(); err == nil {
+		t.Fatal("flush with a lost reply succeeded")
+	}
+
+	// Booked while the failed batch waits to be written again
+	if result, err := inventory.reserve(trainRunID, 2, 3, 1, nil); err != nil || result != 1 {
+		t.Fatalf("reserve() = %d, %v", result, err)
+	}
+
+	if err := flush(); err != nil {
+		t.Fatalf("retried flush: %v", err)
+	}
+	dirty, err := redisClient.SIsMember(ctx, inventoryDirtyKey, trainRunID).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dirty {
+		t.Fatal("run with changes not written yet is no longer dirty")
+	}
+	if err := flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if err := flush(); err != nil {
+		t.Fatalf("flush without changes: %v", err)
+	}
+
+	if want := map[int]int{1: -3, 2: -4}; !reflect.DeepEqual(repo.legs, want) {
+		t.Errorf("legs changed by %v, want %v", repo.legs, want)
+	}
+	if repo.calls != 3 || len(repo.applied) != 2 {
+		t.Errorf("%d writes of %d batches, want 3 writes of 2 batches", repo.calls, len(repo.applied))
+	}
+}
+
+func TestCompareSeats(t *testing.T) {
+	leg := entities.LegInventory{FromOrder: 2, ToOrder: 3}
+	reservations := []entities.SeatReservation{
+		{SeatID: 1, FromOrder: 1, ToOrder: 3}, // Covers the leg
+		{SeatID: 2, FromOrder: 2, ToOrder: 4}, // Covers the leg
+		{SeatID: 3, FromOrder: 1, ToOrder: 2}, // Ends before the leg
+		{SeatID: 4, FromOrder: 3, ToOrder: 5}, // Starts after the leg
+	}
+
+	tests := []struct {
+		name        string
+		taken       []string
+		wantMissing []uint
+		wantExtra   []uint
+	}{
+		{name: "in sync", taken: []string{"2", "1"}},
+		{name: "reserved seat free in redis", taken: []string{"1"}, wantMissing: []uint{2}},
+		{name: "seat taken without a reservation", taken: []string{"1", "2", "4", "3"}, wantExtra: []uint{3, 4}},
+		{name: "nothing taken", wantMissing: []uint{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, extra := compareSeats(tt.taken, leg, reservations)
+			if !reflect.DeepEqual(missing, tt.wantMissing) || !reflect.DeepEqual(extra, tt.wantExtra) {
+				t.Errorf("compareSeats() = missing %v, extra %v, want missing %v, extra %v",
+					missing, extra, tt.wantMissing, tt.wantExtra)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// SeatSelection picks a seat by the coach and seat numbers printed on the train.
+type SeatSelection struct {
+	CoachNumber int    `json:"coach"`
+	SeatNumber  string `json:"seat"`
+}
+
+// SeatMapSeat is a seat of a seat map and whether it is free on every leg asked for.
+type SeatMapSeat struct {
+	entities.Seat
+	Available bool `json:"available"`
+}
+
+// SeatMapCoach is a coach of a seat map.
+type SeatMapCoach struct {
+	Number         int           `json:"number"`
+	Class          int           `json:"class"`
+	AirConditioned bool          `json:"air_conditioned"`
+	Seats          []SeatMapSeat `json:"seats"`
+}
+
+// SeatMap lists the seats of a train run between two stops.
+type SeatMap struct {
+	TrainCode       string         `json:"train_code"`
+	ServiceDate     string         `json:"service_date"`
+	FromStationCode string         `json:"from_station_code"`
+	ToStationCode   string         `json:"to_station_code"`
+	Coaches         []SeatMapCoach `json:"coaches"`
+}
+
+// seatChoice is a seat together with the coach it is in.
+type seatChoice struct {
+	Coach entities.Coach
+	Seat  entities.Seat
+}
+
+type SeatService struct {
+	coachRepo interfaces.CoachRepository
+	runRepo   interfaces.TrainRunRepository
+	routeRepo interfaces.StationOrderRepository
+	allocator SeatAllocator
+}
+
+func NewSeatService(coachRepo interfaces.CoachRepository, runRepo interfaces.TrainRunRepository, routeRepo interfaces.StationOrderRepository, allocator SeatAllocator) *SeatService {
+	return &SeatService{coachRepo: coachRepo, runRepo: runRepo, routeRepo: routeRepo, allocator: allocator}
+}
+
+// GetSeatMap returns every seat of the train run and whether it can still be sold for a
+// trip between the two stations.
+func (s *SeatService) GetSeatMap(trainCode string, serviceDate time.Time, fromStationCode, toStationCode string) (SeatMap, error) {
+	if fromStationCode == "" || toStationCode == "" {
+		return SeatMap{}, errors.New("origin and destination stations are required")
+	}
+
+	route, err := s.routeRepo.GetStationOrder(trainCode)
+	if err != nil {
+		return SeatMap{}, err
+	}
+	from, to, err := findStops(route, fromStationCode, toStationCode)
+	if err != nil {
+		return SeatMap{}, err
+	}
+
+	run, err := s.runRepo.GetRun(trainCode, serviceDate)
+	if err != nil {
+		return SeatMap{}, err
+	}
+
+	coaches, err := s.coachRepo.GetCoaches(trainCode)
+	if err != nil {
+		return SeatMap{}, err
+	}
+
+	reserved, err := s.reservedSeats(run.ID, from.Order, to.Order)
+	if err != nil {
+		return SeatMap{}, err
+	}
+
+	seatMap := SeatMap{
+		TrainCode:       trainCode,
+		ServiceDate:     serviceDate.Format(time.DateOnly),
+		FromStationCode: fromStationCode,
+		ToStationCode:   toStationCode,
+		Coaches:         make([]SeatMapCoach, len(coaches)),
+	}
+	for i, coach := range coaches {
+		seats := make([]SeatMapSeat, len(coach.Seats))
+		for j, seat := range coach.Seats {
+			seats[j] = SeatMapSeat{Seat: seat, Available: run.Bookable() && !reserved[seat.ID]}
+		}
+		seatMap.Coaches[i] = SeatMapCoach{
+			Number:         coach.Number,
+			Class:          coach.Class,
+			AirConditioned: coach.AirConditioned,
+			Seats:          seats,
+		}
+	}
+
+	return seatMap, nil
+}
+
+// allocateSeats lets the allocator pick count seats that are free from stop fromOrder to
+// stop toOrder, in coaches matching the fare options. It returns no seats for trains
+// without coaches, which are sold by seat count only.
+func (s *SeatService) allocateSeats(trainCode string, serviceDate time.Time, fromOrder, toOrder, count int, options FareOptions) ([]seatChoice, error) {
+	coaches, err := s.coachRepo.GetCoaches(trainCode)
+	if err != nil {
+		return nil, err
+	}
+	if len(coaches) == 0 {
+		return nil, nil
+	}
+
+	run, err := s.runRepo.GetRun(trainCode, serviceDate)
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := s.reservedSeats(run.ID, fromOrder, toOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Class == 0 {
+		options.Class = DefaultFareClass
+	}
+	var free []entities.Coach
+	for _, coach := range coaches {
+		if coach.Class != options.Class || coach.AirConditioned != options.AirConditioned {
+			continue
+		}
+		var seats []entities.Seat
+		for _, seat := range coach.Seats {
+			if seat.Berth == options.Berth && !reserved[seat.ID] {
+				seats = append(seats, seat)
+			}
+		}
+		coach.Seats = seats
+		free = append(free, coach)
+	}
+
+	selections, err := s.allocator.Allocate(free, count)
+	if err != nil {
+		return nil, err
+	}
+	choices := make([]seatChoice, len(selections))
+	for i, selection := range selections {
+		choice, ok := findSeat(coaches, selection)
+		if !ok {
+			return nil, fmt.Errorf("train %s has no seat %s in coach %d", trainCode, selection.SeatNumber, selection.CoachNumber)
+		}
+		choices[i] = choice
+	}
+	return choices, nil
+}
+
+func (s *SeatService) reservedSeats(trainRunID uint, fromOrder, toOrder int) (map[uint]bool, error) {
+	seatIDs, err := s.coachRepo.GetReservedSeatIDs(trainRunID, fromOrder, toOrder)
+	if err != nil {
+		return nil, err
+	}
+	reserved := make(map[uint]bool, len(seatIDs))
+	for _, id := range seatIDs {
+		reserved[id] = true
+	}
+	return reserved, nil
+}
+
+// resolveSeats finds the seats picked by the passenger on the train's coaches.
+func (s *SeatService) resolveSeats(trainCode string, selections []SeatSelection) ([]seatChoice, error) {
+	coaches, err := s.coachRepo.GetCoaches(trainCode)
+	if err != nil {
+		return nil, err
+	}
+
+	choices := make([]seatChoice, 0, len(selections))
+	picked := make(map[SeatSelection]bool, len(selections))
+	for _, selection := range selections {
+		if picked[selection] {
+			return nil, fmt.Errorf("seat %s in coach %d is selected more than once", selection.SeatNumber, selection.CoachNumber)
+		}
+		picked[selection] = true
+
+		choice, ok := findSeat(coaches, selection)
+		if !ok {
+			return nil, fmt.Errorf("train %s has no seat %s in coach %d", trainCode, selection.SeatNumber, selection.CoachNumber)
+		}
+		choices = append(choices, choice)
+	}
+	return choices, nil
+}
+
+func findSeat(coaches []entities.Coach, selection SeatSelection) (seatChoice, bool) {
+	for _, coach := range coaches {
+		if coach.Number != selection.CoachNumber {
+			continue
+		}
+		for _, seat := range coach.Seats {
+			if seat.Number == selection.SeatNumber {
+				return seatChoice{Coach: coach, Seat: seat}, true
+			}
+		}
+	}
+	return seatChoice{}, false
+}
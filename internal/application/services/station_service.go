@@ -2,24 +2,51 @@ package services
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
 	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
 )
 
 type TrainService struct {
-	repo interfaces.StationTypeRepository
+	repo      interfaces.StationTypeRepository
+	trainRepo interfaces.TrainRepository
+	routeRepo interfaces.StationOrderRepository
+	coachRepo interfaces.CoachRepository
 }
 
-func NewTrainService(repo interfaces.StationTypeRepository) *TrainService {
-	return &TrainService{repo: repo}
+func NewTrainService(repo interfaces.StationTypeRepository, trainRepo interfaces.TrainRepository, routeRepo interfaces.StationOrderRepository, coachRepo interfaces.CoachRepository) *TrainService {
+	return &TrainService{repo: repo, trainRepo: trainRepo, routeRepo: routeRepo, coachRepo: coachRepo}
 }
 
+// stationCodePattern matches SRT station numbers such as "1001" and short codes such as "BKK".
+var stationCodePattern = regexp.MustCompile(`^[A-Z0-9]{2,10}$`)
+
 func (s *TrainService) CreateTrainStation(station entities.TrainStation) (entities.TrainStation, error) {
+	code, err := normalizeStationCode(station.Code)
+	if err != nil {
+		return entities.TrainStation{}, err
+	}
+	station.Code = code
+
 	return s.repo.CreateTrainStation(station)
 }
 
 func (s *TrainService) BulkCreateTrainStation(stations []entities.TrainStation) ([]entities.TrainStation, error) {
+	seen := make(map[string]bool, len(stations))
+	for i := range stations {
+		code, err := normalizeStationCode(stations[i].Code)
+		if err != nil {
+			return nil, err
+		}
+		if seen[code] {
+			return nil, fmt.Errorf("train station code %s appears more than once", code)
+		}
+		seen[code] = true
+		stations[i].Code = code
+	}
+
 	return s.repo.BulkCreateTrainStation(stations)
 }
 
@@ -35,10 +62,26 @@ func (s *TrainService) GetTrainStations(filters map[string]interface{}) ([]entit
 	return s.repo.GetTrainStations(filters)
 }
 
-func (s *TrainService) GetTrainStationByCode(id uint) (entities.TrainStation, error) {
+func (s *TrainService) GetTrainStationById(id uint) (entities.TrainStation, error) {
 	return s.repo.GetTrainStationById(id)
 }
 
+func (s *TrainService) GetTrainStationByCode(code string) (entities.TrainStation, error) {
+	return s.repo.GetTrainStationByCode(strings.ToUpper(code))
+}
+
+// normalizeStationCode upper-cases the code and checks that it is a valid station code.
+func normalizeStationCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return "", fmt.Errorf("train station code is required")
+	}
+	if !stationCodePattern.MatchString(code) {
+		return "", fmt.Errorf("train station code %s must be 2 to 10 letters or digits", code)
+	}
+	return code, nil
+}
+
 // TrainStationType methods
 func (s *TrainService) CreateTrainStationType(stationType entities.StationType) (entities.StationType, error) {
 	// Validate required fields
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+// FareBand charges SatangPerKm for every kilometre of the trip up to UpToKm. The last
+// band of a class has UpToKm 0 and covers the rest of the trip.
+type FareBand struct {
+	UpToKm      int
+	SatangPerKm int
+}
+
+// FareTariff holds the rates of the distance tariff per class. Rates taper with
+// distance, so long trips cost less per kilometre.
+type FareTariff struct {
+	Bands           map[int][]FareBand
+	MinimumFare     map[int]int
+	AirConditionFee map[int]int
+	BerthFees       map[int]map[string]int
+}
+
+// DefaultFareTariff follows the structure of the SRT passenger tariff. Amounts are in
+// baht, except for the per kilometre rates which are in satang.
+var DefaultFareTariff = FareTariff{
+	Bands: map[int][]FareBand{
+		1: {{UpToKm: 100, SatangPerKm: 110}, {UpToKm: 300, SatangPerKm: 100}, {UpToKm: 500, SatangPerKm: 90}, {SatangPerKm: 80}},
+		2: {{UpToKm: 100, SatangPerKm: 50}, {UpToKm: 300, SatangPerKm: 45}, {UpToKm: 500, SatangPerKm: 40}, {SatangPerKm: 35}},
+		3: {{UpToKm: 100, SatangPerKm: 24}, {UpToKm: 300, SatangPerKm: 22}, {UpToKm: 500, SatangPerKm: 20}, {SatangPerKm: 18}},
+	},
+	MinimumFare: map[int]int{1: 50, 2: 20, 3: 5},
+	// First class is always air-conditioned, so it has no extra fee
+	AirConditionFee: map[int]int{1: 0, 2: 140, 3: 60},
+	BerthFees: map[int]map[string]int{
+		1: {entities.BerthUpper: 400, entities.BerthLower: 520},
+		2: {entities.BerthUpper: 150, entities.BerthLower: 200},
+	},
+}
+
+// TariffFareCalculator prices a trip by distance and class, then applies the share of the
+// fare set by the train's profit type and the passenger's concession and adds the train
+// type, air-conditioning and berth fees.
+type TariffFareCalculator struct {
+	tariff FareTariff
+}
+
+func NewTariffFareCalculator(tariff FareTariff) *TariffFareCalculator {
+	return &TariffFareCalculator{tariff: tariff}
+}
+
+// Calculate implements FareCalculator.
+func (c *TariffFareCalculator) Calculate(quote FareQuote) ([]FareItem, error) {
+	bands, ok := c.tariff.Bands[quote.Class]
+	if !ok {
+		return nil, fmt.Errorf("class %d is not sold", quote.Class)
+	}
+	if quote.DistanceKm <= 0 {
+		return nil, errors.New("distance of the trip must be positive")
+	}
+
+	satang := 0
+	bandStart := 0
+	for _, band := range bands {
+		km := quote.DistanceKm - bandStart
+		if band.UpToKm > 0 && band.UpToKm-bandStart < km {
+			km = band.UpToKm - bandStart
+		}
+		if km <= 0 {
+			break
+		}
+		satang += km * band.SatangPerKm
+		if band.UpToKm == 0 {
+			break
+		}
+		bandStart = band.UpToKm
+	}
+
+	distanceFare := (satang + 99) / 100
+	if minimum := c.tariff.MinimumFare[quote.Class]; distanceFare < minimum {
+		distanceFare = minimum
+	}
+
+	items := []FareItem{{
+		Code:        "distance",
+		Description: fmt.Sprintf("Class %d fare for %d km", quote.Class, quote.DistanceKm),
+		Amount:      distanceFare,
+	}}
+
+	profitType := quote.Train.TrainProfitType
+	if profitType.FarePercent > 0 && profitType.FarePercent != 100 {
+		charged := (distanceFare*profitType.FarePercent + 99) / 100
+		items = append(items, FareItem{
+			Code:        "profit_type",
+			Description: fmt.Sprintf("%s rate %d%%", profitType.Name, profitType.FarePercent),
+			Amount:      charged - distanceFare,
+		})
+	}
+
+	// A concession takes its share off the fare of the trip, not off the fees below
+	if rule := quote.ConcessionRule; rule != nil && rule.DiscountPercent > 0 {
+		baseFare := fareTotal(items)
+		items = append(items, FareItem{
+			Code:        "concession",
+			Description: fmt.Sprintf("%s %d%% discount, show %s", rule.Name, rule.DiscountPercent, rule.Proof),
+			Amount:      -baseFare * rule.DiscountPercent / 100,
+		})
+	}
+
+	if trainType := quote.Train.TrainType; trainType.Fee > 0 {
+		items = append(items, FareItem{
+			Code:        "train_type",
+			Description: fmt.Sprintf("%s fee", trainType.Name),
+			Amount:      trainType.Fee,
+		})
+	}
+
+	if quote.AirConditioned {
+		fee, ok := c.tariff.AirConditionFee[quote.Class]
+		if !ok {
+			return nil, fmt.Errorf("class %d has no air-conditioned cars", quote.Class)
+		}
+		if fee > 0 {
+			items = append(items, FareItem{
+				Code:        "air_condition",
+				Description: "Air-conditioning fee",
+				Amount:      fee,
+			})
+		}
+	}
+
+	if quote.Berth != "" {
+		fee, ok := c.tariff.BerthFees[quote.Class][quote.Berth]
+		if !ok {
+			return nil, fmt.Errorf("class %d has no %s berths", quote.Class, quote.Berth)
+		}
+		items = append(items, FareItem{
+			Code:        "berth",
+			Description: fmt.Sprintf("%s berth fee", quote.Berth),
+			Amount:      fee,
+		})
+	}
+
+	return items, nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// bangkokLocation is the time zone every timetable is expressed in.
+var bangkokLocation = loadBangkokLocation()
+
+func loadBangkokLocation() *time.Location {
+	location, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		// Thailand has no daylight saving time, so a fixed offset is equivalent
+		return time.FixedZone("ICT", 7*60*60)
+	}
+	return location
+}
+
+// StopTime is the scheduled call of a train at a station on a service date.
+type StopTime struct {
+	Order       int       `json:"order"`
+	StationCode string    `json:"station_code"`
+	Arrival     time.Time `json:"arrival"`
+	Departure   time.Time `json:"departure"`
+}
+
+// Timetable lists the stop times of a train on a service date.
+type Timetable struct {
+	TrainCode   string     `json:"train_code"`
+	ServiceDate string     `json:"service_date"`
+	Stops       []StopTime `json:"stops"`
+}
+
+type TimetableService struct {
+	routeRepo interfaces.StationOrderRepository
+}
+
+func NewTimetableService(routeRepo interfaces.StationOrderRepository) *TimetableService {
+	return &TimetableService{routeRepo: routeRepo}
+}
+
+// GetTimetable returns the arrival and departure time of the train at each of its stops
+// when it runs on the service date.
+func (s *TimetableService) GetTimetable(trainCode string, serviceDate time.Time) (Timetable, error) {
+	if trainCode == "" {
+		return Timetable{}, errors.New("train code is required")
+	}
+	if serviceDate.IsZero() {
+		return Timetable{}, errors.New("service date is required")
+	}
+
+	route, err := s.routeRepo.GetStationOrder(trainCode)
+	if err != nil {
+		return Timetable{}, err
+	}
+
+	return Timetable{
+		TrainCode:   trainCode,
+		ServiceDate: serviceDate.Format(time.DateOnly),
+		Stops:       stopTimes(route, serviceDate),
+	}, nil
+}
+
+// stopTimes resolves the stop offsets of the route against the service date.
+func stopTimes(route entities.StationOrder, serviceDate time.Time) []StopTime {
+	midnight := serviceMidnight(serviceDate)
+
+	stops := make([]StopTime, len(route.Stations))
+	for i, stop := range route.Stations {
+		stops[i] = StopTime{
+			Order:       stop.Order,
+			StationCode: stop.StationCode,
+			Arrival:     midnight.Add(time.Duration(stop.ArrivalOffset) * time.Minute),
+			Departure:   midnight.Add(time.Duration(stop.DepartureOffset) * time.Minute),
+		}
+	}
+	return stops
+}
+
+// serviceMidnight returns the start of the service date in Asia/Bangkok.
+func serviceMidnight(serviceDate time.Time) time.Time {
+	year, month, day := serviceDate.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, bangkokLocation)
+}
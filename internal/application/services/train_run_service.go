@@ -0,0 +1,214 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+const (
+	// DefaultBookingWindowDays is how many service dates, today included, have runs open for booking.
+	DefaultBookingWindowDays = 90
+	// DefaultRunGenerationInterval is how often the booking window is rolled forward.
+	DefaultRunGenerationInterval = time.Hour
+
+	maxRunListDays = 92
+)
+
+// trainRunTransitions lists the statuses a run may move to from each status. Cancelled and
+// arrived runs are final.
+var trainRunTransitions = map[string][]string{
+	entities.TrainRunStatusScheduled: {entities.TrainRunStatusDelayed, entities.TrainRunStatusCancelled, entities.TrainRunStatusDeparted},
+	entities.TrainRunStatusDelayed:   {entities.TrainRunStatusDelayed, entities.TrainRunStatusCancelled, entities.TrainRunStatusDeparted},
+	entities.TrainRunStatusDeparted:  {entities.TrainRunStatusArrived},
+}
+
+type TrainRunService struct {
+	repo      interfaces.TrainRunRepository
+	routeRepo interfaces.StationOrderRepository
+	calendar  *CalendarService
+}
+
+func NewTrainRunService(repo interfaces.TrainRunRepository, routeRepo interfaces.StationOrderRepository, calendar *CalendarService) *TrainRunService {
+	return &TrainRunService{repo: repo, routeRepo: routeRepo, calendar: calendar}
+}
+
+func (s *TrainRunService) GetRun(trainCode string, serviceDate time.Time) (entities.TrainRun, error) {
+	return s.repo.GetRun(trainCode, serviceDate)
+}
+
+func (s *TrainRunService) GetRuns(trainCode string, from, to time.Time) ([]entities.TrainRun, error) {
+	if to.Before(from) {
+		return nil, errors.New("end date cannot be before start date")
+	}
+	if to.Sub(from) > maxRunListDays*24*time.Hour {
+		return nil, fmt.Errorf("date range cannot be longer than %d days", maxRunListDays)
+	}
+	return s.repo.GetRuns(trainCode, from, to)
+}
+
+// GenerateRuns creates the missing runs of every routed train for the service dates from
+// the given date on, following the service calendars. It returns how many were created.
+func (s *TrainRunService) GenerateRuns(from time.Time, days int) (int, error) {
+	routes, err := s.routeRepo.GetStationOrders()
+	if err != nil {
+		return 0, err
+	}
+
+	runsOn, err := s.calendar.RunningFilter()
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := s.existingRuns(routes, from, from.AddDate(0, 0, days-1))
+	if err != nil {
+		return 0, err
+	}
+
+	var runs []entities.TrainRun
+	for day := 0; day < days; day++ {
+		serviceDate := from.AddDate(0, 0, day)
+		for _, route := range routes {
+			if len(route.Stations) < 2 || !runsOn(route.TrainCode, serviceDate) {
+				continue
+			}
+			if existing[runKey(route.TrainCode, serviceDate)] {
+				continue
+			}
+			runs = append(runs, entities.TrainRun{
+				TrainCode:   route.TrainCode,
+				ServiceDate: serviceDate,
+				Status:      entities.TrainRunStatusScheduled,
+				Seats:       route.Train.Seats,
+			})
+		}
+	}
+
+	return s.repo.CreateRuns(runs)
+}
+
+// GenerateBookingWindow creates the missing runs of the given number of service dates
+// starting today.
+func (s *TrainRunService) GenerateBookingWindow(days int) (int, error) {
+	return s.GenerateRuns(serviceToday(), days)
+}
+
+// StartGenerator generates the runs of the booking window right away and then every
+// interval, so the window keeps rolling forward as days pass.
+func (s *TrainRunService) StartGenerator(days int, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			created, err := s.GenerateBookingWindow(days)
+			if err != nil {
+				log.Printf("Failed to generate train runs: %v", err)
+			} else if created > 0 {
+				log.Printf("Generated %d train runs.", created)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// UpdateRunStatus moves the run to a new status. Departed and arrived runs record the
+// actual time, which defaults to now.
+func (s *TrainRunService) UpdateRunStatus(trainCode string, serviceDate time.Time, status string, delayMinutes int, actualTime *time.Time, modifyBy uint) (entities.TrainRun, error) {
+	if modifyBy == 0 {
+		return entities.TrainRun{}, errors.New("modifyBy (user ID) is required")
+	}
+	if delayMinutes < 0 {
+		return entities.TrainRun{}, errors.New("delay cannot be negative")
+	}
+
+	run, err := s.repo.GetRun(trainCode, serviceDate)
+	if err != nil {
+		return entities.TrainRun{}, err
+	}
+
+	allowed := false
+	for _, next := range trainRunTransitions[run.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return entities.TrainRun{}, fmt.Errorf("train run cannot move from %s to %s", run.Status, status)
+	}
+
+	if actualTime == nil {
+		now := time.Now()
+		actualTime = &now
+	}
+
+	run.Status = status
+	run.ModifyBy = &modifyBy
+	switch status {
+	case entities.TrainRunStatusDelayed:
+		if delayMinutes == 0 {
+			return entities.TrainRun{}, errors.New("delay is required for a delayed run")
+		}
+		run.DelayMinutes = delayMinutes
+	case entities.TrainRunStatusDeparted:
+		run.ActualDeparture = actualTime
+	case entities.TrainRunStatusArrived:
+		run.ActualArrival = actualTime
+	}
+
+	return s.repo.UpdateRun(run)
+}
+
+// RunningFilter returns a function reporting whether a train runs on a service date
+// between from and to: its calendar includes the date and the run is not cancelled.
+func (s *TrainRunService) RunningFilter(from, to time.Time) (func(trainCode string, serviceDate time.Time) bool, error) {
+	runsOn, err := s.calendar.RunningFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	cancelledRuns, err := s.repo.GetRunsByStatus(entities.TrainRunStatusCancelled, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	cancelled := make(map[string]bool, len(cancelledRuns))
+	for _, run := range cancelledRuns {
+		cancelled[runKey(run.TrainCode, run.ServiceDate)] = true
+	}
+
+	return func(trainCode string, serviceDate time.Time) bool {
+		return runsOn(trainCode, serviceDate) && !cancelled[runKey(trainCode, serviceDate)]
+	}, nil
+}
+
+// existingRuns returns the keys of the runs of the routed trains between from and to.
+func (s *TrainRunService) existingRuns(routes []entities.StationOrder, from, to time.Time) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for _, route := range routes {
+		runs, err := s.repo.GetRuns(route.TrainCode, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, run := range runs {
+			existing[runKey(run.TrainCode, run.ServiceDate)] = true
+		}
+	}
+	return existing, nil
+}
+
+func runKey(trainCode string, serviceDate time.Time) string {
+	return trainCode + "/" + serviceDate.Format(time.DateOnly)
+}
+
+// serviceToday returns today's service date in Bangkok, in the same form as the dates
+// parsed from requests.
+func serviceToday() time.Time {
+	today, _ := time.Parse(time.DateOnly, time.Now().In(bangkokLocation).Format(time.DateOnly))
+	return today
+}
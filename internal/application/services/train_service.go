@@ -0,0 +1,216 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+// Train methods
+func (s *TrainService) CreateTrain(train entities.Train) (entities.Train, error) {
+	// Validate required fields
+	if train.Code == "" {
+		return entities.Train{}, fmt.Errorf("train code is required")
+	}
+	if train.Name == "" {
+		return entities.Train{}, fmt.Errorf("train name is required")
+	}
+	if train.Seats <= 0 {
+		return entities.Train{}, fmt.Errorf("train seats must be greater than zero")
+	}
+	if train.ModifyBy == 0 {
+		return entities.Train{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+	if train.FromStationCode == "" || train.ToStationCode == "" {
+		return entities.Train{}, fmt.Errorf("origin and destination stations are required")
+	}
+	if train.TrainTypeCode == "" || train.TrainLineCode == "" || train.TrainProfitTypeCode == "" {
+		return entities.Train{}, fmt.Errorf("train type, train line and profit type are required")
+	}
+
+	if err := s.validateTrainReferences(train); err != nil {
+		return entities.Train{}, err
+	}
+
+	createdTrain, err := s.trainRepo.CreateTrain(train)
+	if err != nil {
+		return entities.Train{}, fmt.Errorf("failed to create train: %w", err)
+	}
+
+	return s.trainRepo.GetTrainByCode(createdTrain.Code)
+}
+
+func (s *TrainService) UpdateTrain(train entities.Train) (entities.Train, error) {
+	if train.Code == "" {
+		return entities.Train{}, fmt.Errorf("train code is required")
+	}
+	if train.Seats < 0 {
+		return entities.Train{}, fmt.Errorf("train seats cannot be negative")
+	}
+
+	// Only the references that are being changed need to be checked
+	if err := s.validateTrainReferences(train); err != nil {
+		return entities.Train{}, err
+	}
+
+	if _, err := s.trainRepo.UpdateTrain(train); err != nil {
+		return entities.Train{}, err
+	}
+
+	return s.trainRepo.GetTrainByCode(train.Code)
+}
+
+func (s *TrainService) DeleteTrain(code string) error {
+	return s.trainRepo.DeleteTrain(code)
+}
+
+func (s *TrainService) GetTrains(filters map[string]interface{}) ([]entities.Train, error) {
+	return s.trainRepo.GetTrains(filters)
+}
+
+func (s *TrainService) GetTrainByCode(code string) (entities.Train, error) {
+	return s.trainRepo.GetTrainByCode(code)
+}
+
+// validateTrainReferences makes sure every non-empty foreign key of the train points to an
+// existing station, train type, train line and profit type.
+func (s *TrainService) validateTrainReferences(train entities.Train) error {
+	if train.FromStationCode != "" && train.FromStationCode == train.ToStationCode {
+		return fmt.Errorf("origin and destination stations must be different")
+	}
+
+	for _, stationCode := range []string{train.FromStationCode, train.ToStationCode} {
+		if stationCode == "" {
+			continue
+		}
+		if _, err := s.repo.GetTrainStationByCode(stationCode); err != nil {
+			return err
+		}
+	}
+
+	if train.TrainTypeCode != "" {
+		if _, err := s.trainRepo.GetTrainTypeByCode(train.TrainTypeCode); err != nil {
+			return err
+		}
+	}
+	if train.TrainLineCode != "" {
+		if _, err := s.trainRepo.GetTrainLineByCode(train.TrainLineCode); err != nil {
+			return err
+		}
+	}
+	if train.TrainProfitTypeCode != "" {
+		if _, err := s.trainRepo.GetTrainProfitTypeByCode(train.TrainProfitTypeCode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TrainType methods
+func (s *TrainService) CreateTrainType(trainType entities.TrainType) (entities.TrainType, error) {
+	if trainType.Code == "" {
+		return entities.TrainType{}, fmt.Errorf("train type code is required")
+	}
+	if trainType.Name == "" {
+		return entities.TrainType{}, fmt.Errorf("train type name is required")
+	}
+	if trainType.ModifyBy == 0 {
+		return entities.TrainType{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+	if trainType.Fee < 0 {
+		return entities.TrainType{}, fmt.Errorf("train type fee cannot be negative")
+	}
+
+	createdTrainType, err := s.trainRepo.CreateTrainType(trainType)
+	if err != nil {
+		return entities.TrainType{}, fmt.Errorf("failed to create train type: %w", err)
+	}
+
+	return createdTrainType, nil
+}
+
+func (s *TrainService) UpdateTrainType(trainType entities.TrainType) (entities.TrainType, error) {
+	if trainType.Fee < 0 {
+		return entities.TrainType{}, fmt.Errorf("train type fee cannot be negative")
+	}
+	return s.trainRepo.UpdateTrainType(trainType)
+}
+
+func (s *TrainService) DeleteTrainType(code string) error {
+	return s.trainRepo.DeleteTrainType(code)
+}
+
+func (s *TrainService) GetTrainTypes() ([]entities.TrainType, error) {
+	return s.trainRepo.GetTrainTypes()
+}
+
+// TrainLine methods
+func (s *TrainService) CreateTrainLine(trainLine entities.TrainLine) (entities.TrainLine, error) {
+	if trainLine.Code == "" {
+		return entities.TrainLine{}, fmt.Errorf("train line code is required")
+	}
+	if trainLine.Name == "" {
+		return entities.TrainLine{}, fmt.Errorf("train line name is required")
+	}
+	if trainLine.ModifyBy == 0 {
+		return entities.TrainLine{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+
+	createdTrainLine, err := s.trainRepo.CreateTrainLine(trainLine)
+	if err != nil {
+		return entities.TrainLine{}, fmt.Errorf("failed to create train line: %w", err)
+	}
+
+	return createdTrainLine, nil
+}
+
+func (s *TrainService) UpdateTrainLine(trainLine entities.TrainLine) (entities.TrainLine, error) {
+	return s.trainRepo.UpdateTrainLine(trainLine)
+}
+
+func (s *TrainService) DeleteTrainLine(code string) error {
+	return s.trainRepo.DeleteTrainLine(code)
+}
+
+func (s *TrainService) GetTrainLines() ([]entities.TrainLine, error) {
+	return s.trainRepo.GetTrainLines()
+}
+
+// TrainProfitType methods
+func (s *TrainService) CreateTrainProfitType(profitType entities.TrainProfitType) (entities.TrainProfitType, error) {
+	if profitType.Code == "" {
+		return entities.TrainProfitType{}, fmt.Errorf("profit type code is required")
+	}
+	if profitType.Name == "" {
+		return entities.TrainProfitType{}, fmt.Errorf("profit type name is required")
+	}
+	if profitType.ModifyBy == 0 {
+		return entities.TrainProfitType{}, fmt.Errorf("modifyBy (user ID) is required")
+	}
+	if profitType.FarePercent < 0 {
+		return entities.TrainProfitType{}, fmt.Errorf("fare percent cannot be negative")
+	}
+
+	createdProfitType, err := s.trainRepo.CreateTrainProfitType(profitType)
+	if err != nil {
+		return entities.TrainProfitType{}, fmt.Errorf("failed to create profit type: %w", err)
+	}
+
+	return createdProfitType, nil
+}
+
+func (s *TrainService) UpdateTrainProfitType(profitType entities.TrainProfitType) (entities.TrainProfitType, error) {
+	if profitType.FarePercent < 0 {
+		return entities.TrainProfitType{}, fmt.Errorf("fare percent cannot be negative")
+	}
+	return s.trainRepo.UpdateTrainProfitType(profitType)
+}
+
+func (s *TrainService) DeleteTrainProfitType(code string) error {
+	return s.trainRepo.DeleteTrainProfitType(code)
+}
+
+func (s *TrainService) GetTrainProfitTypes() ([]entities.TrainProfitType, error) {
+	return s.trainRepo.GetTrainProfitTypes()
+}
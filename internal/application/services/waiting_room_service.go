@@ -0,0 +1,368 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+const (
+	// DefaultAdmissionMinutes is how long an admitted user may book when the window does
+	// not say otherwise.
+	DefaultAdmissionMinutes = 10
+
+	// Queue states reported to polling clients
+	QueueStateOpen     = "open"     // No sale window, booking needs no admission
+	QueueStateWaiting  = "waiting"  // In the queue
+	QueueStateAdmitted = "admitted" // May book with the admission token
+	QueueStateExpired  = "expired"  // Dropped or admission ran out, join again
+
+	// A queued user who stops polling for this long loses their place
+	queueStaleAfter = 2 * time.Minute
+	// activeWindowCacheTTL limits how often booking requests look up the sale window
+	activeWindowCacheTTL = 5 * time.Second
+)
+
+var ErrQueueTokenNotFound = errors.New("queue token not found")
+
+// waitingRoomKeys are the Redis keys of one sale window's queue. All of them expire a day
+// after the window ends.
+type waitingRoomKeys struct {
+	queue    string // sorted set token -> place in line
+	seen     string // sorted set token -> last poll (unix ms)
+	admitted string // sorted set token -> admission time (unix ms)
+	state    string // hash with admitted_at, the time admissions were counted up to
+	users    string // hash user ID -> token
+	tokens   string // hash token -> user ID
+	seq      string // counter handing out places in line
+}
+
+func windowKeys(windowID uint) waitingRoomKeys {
+	prefix := fmt.Sprintf("waiting_room:%d:", windowID)
+	return waitingRoomKeys{
+		queue:    prefix + "queue",
+		seen:     prefix + "seen",
+		admitted: prefix + "admitted",
+		state:    prefix + "state",
+		users:    prefix + "users",
+		tokens:   prefix + "tokens",
+		seq:      prefix + "seq",
+	}
+}
+
+func (k waitingRoomKeys) all() []string {
+	return []string{k.users, k.tokens, k.queue, k.seen, k.admitted, k.seq, k.state}
+}
+
+// joinScript puts a user in line, or returns the token they already hold while it is
+// still queued or admitted.
+// ARGV: user ID, new token, now (ms), expire at (unix s), window start (ms), admitted after (ms)
+var joinScript = redis.NewScript(`
+local existing = redis.call('HGET', KEYS[1], ARGV[1])
+if existing then
+  local admittedAt = redis.call('ZSCORE', KEYS[5], existing)
+  if redis.call('ZSCORE', KEYS[3], existing) or (admittedAt and tonumber(admittedAt) >= tonumber(ARGV[6])) then
+    redis.call('ZADD', KEYS[4], ARGV[3], existing)
+    return existing
+  end
+  redis.call('HDEL', KEYS[2], existing)
+end
+
+local seq = redis.call('INCR', KEYS[6])
+redis.call('ZADD', KEYS[3], seq, ARGV[2])
+redis.call('ZADD', KEYS[4], ARGV[3], ARGV[2])
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[1])
+redis.call('HSETNX', KEYS[7], 'admitted_at', ARGV[5])
+for i = 1, #KEYS do
+  redis.call('EXPIREAT', KEYS[i], ARGV[4])
+end
+return ARGV[2]
+`)
+
+// admitScript lets in as many users from the head of the line as the admission rate allows
+// since it last ran, skipping users who stopped polling. Any instance may run it at any
+// time, it only admits what the clock allows. At most one minute of admissions builds up
+// while nobody is waiting.
+// ARGV: now (ms), admit per minute, stale before (ms)
+var admitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local last = tonumber(redis.call('HGET', KEYS[4], 'admitted_at') or ARGV[1])
+if last < now - 60000 then last = now - 60000 end
+
+local allowance = math.floor((now - last) * rate / 60000)
+if allowance < 1 then return 0 end
+redis.call('HSET', KEYS[4], 'admitted_at', last + math.floor(allowance * 60000 / rate))
+
+local admitted = 0
+while admitted < allowance do
+  local head = redis.call('ZRANGE', KEYS[1], 0, 0)
+  if #head == 0 then break end
+  local token = head[1]
+  redis.call('ZREM', KEYS[1], token)
+  local seen = tonumber(redis.call('ZSCORE', KEYS[2], token) or '0')
+  if seen >= tonumber(ARGV[3]) then
+    redis.call('ZADD', KEYS[3], now, token)
+    admitted = admitted + 1
+  end
+end
+return admitted
+`)
+
+// QueueStatus is what a client polling the waiting room is told.
+type QueueStatus struct {
+	State                string     `json:"state"`
+	SaleWindowID         uint       `json:"sale_window_id,omitempty"`
+	QueueToken           string     `json:"queue_token,omitempty"`
+	Position             int        `json:"position,omitempty"`
+	EstimatedWaitSeconds int        `json:"estimated_wait_seconds,omitempty"`
+	AdmissionToken       string     `json:"admission_token,omitempty"`
+	AdmissionExpiresAt   *time.Time `json:"admission_expires_at,omitempty"`
+}
+
+// WaitingRoomService queues users in front of booking while a sale window is active. The
+// queue lives in Redis, so every API instance serves the same line.
+type WaitingRoomService struct {
+	repo interfaces.SaleWindowRepository
+
+	// Cached answer of ActiveWindow
+	mu       sync.Mutex
+	active   *entities.SaleWindow
+	activeAt time.Time
+	cached   bool
+}
+
+func NewWaitingRoomService(repo interfaces.SaleWindowRepository) *WaitingRoomService {
+	return &WaitingRoomService{repo: repo}
+}
+
+// Sale window methods
+func (s *WaitingRoomService) GetWindows() ([]entities.SaleWindow, error) {
+	return s.repo.GetWindows()
+}
+
+func (s *WaitingRoomService) CreateWindow(window entities.SaleWindow) (entities.SaleWindow, error) {
+	if window.ModifyBy == 0 {
+		return entities.SaleWindow{}, errors.New("modifyBy (user ID) is required")
+	}
+	if window.Name == "" {
+		return entities.SaleWindow{}, errors.New("name is required")
+	}
+	if !window.EndsAt.After(window.StartsAt) {
+		return entities.SaleWindow{}, errors.New("sale window must end after it starts")
+	}
+	if window.AdmitPerMinute < 1 {
+		return entities.SaleWindow{}, errors.New("admit per minute must be at least 1")
+	}
+	if window.AdmissionMinutes == 0 {
+		window.AdmissionMinutes = DefaultAdmissionMinutes
+	}
+	if window.AdmissionMinutes < 1 {
+		return entities.SaleWindow{}, errors.New("admission minutes must be at least 1")
+	}
+
+	created, err := s.repo.CreateWindow(window)
+	if err != nil {
+		return entities.SaleWindow{}, err
+	}
+	s.forgetActiveWindow()
+	return created, nil
+}
+
+func (s *WaitingRoomService) DeleteWindow(id uint) error {
+	if err := s.repo.DeleteWindow(id); err != nil {
+		return err
+	}
+	s.forgetActiveWindow()
+	return nil
+}
+
+// ActiveWindow returns the sale window open now, or nil. The answer is cached for a few
+// seconds, as every booking request asks.
+func (s *WaitingRoomService) ActiveWindow() (*entities.SaleWindow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.cached && now.Sub(s.activeAt) < activeWindowCacheTTL && (s.active == nil || s.active.Active(now)) {
+		return s.active, nil
+	}
+
+	window, err := s.repo.GetActiveWindow(now)
+	switch {
+	case errors.Is(err, interfaces.ErrSaleWindowNotFound):
+		s.active = nil
+	case err != nil:
+		return nil, err
+	default:
+		s.active = &window
+	}
+	s.activeAt, s.cached = now, true
+	return s.active, nil
+}
+
+func (s *WaitingRoomService) forgetActiveWindow() {
+	s.mu.Lock()
+	s.cached = false
+	s.mu.Unlock()
+}
+
+// Join puts the user in line for the active sale window. Joining again keeps the place.
+func (s *WaitingRoomService) Join(userID uint) (QueueStatus, error) {
+	window, err := s.ActiveWindow()
+	if err != nil {
+		return QueueStatus{}, err
+	}
+	if window == nil {
+		return QueueStatus{State: QueueStateOpen}, nil
+	}
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return QueueStatus{}, fmt.Errorf("failed to generate queue token: %v", err)
+	}
+
+	now := time.Now()
+	keys := windowKeys(window.ID)
+	token, err := joinScript.Run(ctx, redisClient, keys.all(),
+		userID,
+		hex.EncodeToString(tokenBytes),
+		now.UnixMilli(),
+		window.EndsAt.Add(24*time.Hour).Unix(),
+		window.StartsAt.UnixMilli(),
+		now.Add(-admissionDuration(*window)).UnixMilli(),
+	).Text()
+	if err != nil {
+		return QueueStatus{}, fmt.Errorf("failed to join the waiting room: %v", err)
+	}
+
+	return s.status(*window, userID, token, now)
+}
+
+// Status tells the user where their token stands. Polling also keeps the place in line,
+// and once admitted it hands out the admission token booking requires.
+func (s *WaitingRoomService) Status(userID uint, token string) (QueueStatus, error) {
+	window, err := s.ActiveWindow()
+	if err != nil {
+		return QueueStatus{}, err
+	}
+	if window == nil {
+		return QueueStatus{State: QueueStateOpen}, nil
+	}
+
+	keys := windowKeys(window.ID)
+	owner, err := redisClient.HGet(ctx, keys.tokens, token).Result()
+	if err == redis.Nil || (err == nil && owner != strconv.FormatUint(uint64(userID), 10)) {
+		return QueueStatus{}, ErrQueueTokenNotFound
+	} else if err != nil {
+		return QueueStatus{}, fmt.Errorf("failed to read queue token: %v", err)
+	}
+
+	now := time.Now()
+	if err := redisClient.ZAddXX(ctx, keys.seen, &redis.Z{Score: float64(now.UnixMilli()), Member: token}).Err(); err != nil {
+		return QueueStatus{}, fmt.Errorf("failed to update queue token: %v", err)
+	}
+
+	return s.status(*window, userID, token, now)
+}
+
+func (s *WaitingRoomService) status(window entities.SaleWindow, userID uint, token string, now time.Time) (QueueStatus, error) {
+	keys := windowKeys(window.ID)
+	err := admitScript.Run(ctx, redisClient, []string{keys.queue, keys.seen, keys.admitted, keys.state},
+		now.UnixMilli(), window.AdmitPerMinute, now.Add(-queueStaleAfter).UnixMilli()).Err()
+	if err != nil {
+		return QueueStatus{}, fmt.Errorf("failed to admit users: %v", err)
+	}
+
+	status := QueueStatus{SaleWindowID: window.ID, QueueToken: token}
+
+	admittedAt, err := redisClient.ZScore(ctx, keys.admitted, token).Result()
+	switch {
+	case err == nil:
+		expiresAt := time.UnixMilli(int64(admittedAt)).Add(admissionDuration(window))
+		if !now.Before(expiresAt) {
+			status.State = QueueStateExpired
+			return status, nil
+		}
+		admission, err := generateAdmissionToken(window, userID, expiresAt)
+		if err != nil {
+			return QueueStatus{}, err
+		}
+		status.State = QueueStateAdmitted
+		status.AdmissionToken = admission
+		status.AdmissionExpiresAt = &expiresAt
+		return status, nil
+	case err != redis.Nil:
+		return QueueStatus{}, fmt.Errorf("failed to read admission: %v", err)
+	}
+
+	rank, err := redisClient.ZRank(ctx, keys.queue, token).Result()
+	if err == redis.Nil {
+		status.State = QueueStateExpired
+		return status, nil
+	} else if err != nil {
+		return QueueStatus{}, fmt.Errorf("failed to read place in line: %v", err)
+	}
+
+	status.State = QueueStateWaiting
+	status.Position = int(rank) + 1
+	status.EstimatedWaitSeconds = (status.Position*60 + window.AdmitPerMinute - 1) / window.AdmitPerMinute
+	return status, nil
+}
+
+// ValidateAdmission checks that the admission token was issued to the user for the active
+// sale window and has not expired.
+func (s *WaitingRoomService) ValidateAdmission(tokenString string, window entities.SaleWindow, userID uint) error {
+	if tokenString == "" {
+		return errors.New("admission token is required")
+	}
+
+	token, err := ValidateToken(tokenString)
+	if err != nil {
+		return err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return errors.New("invalid admission token claims")
+	}
+
+	windowID, ok := claims["admission"].(float64)
+	if !ok || uint(windowID) != window.ID {
+		return errors.New("admission token is not for this sale")
+	}
+
+	user, ok := claims["user"].(float64)
+	if !ok || uint(user) != userID {
+		return errors.New("admission token was issued to another user")
+	}
+	return nil
+}
+
+// generateAdmissionToken signs a token naming the user and the sale window. It has no role
+// claim, so it cannot pass as an access token.
+func generateAdmissionToken(window entities.SaleWindow, userID uint, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"user":      userID,
+		"admission": window.ID,
+		"exp":       expiresAt.Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign admission token: %v", err)
+	}
+	return token, nil
+}
+
+func admissionDuration(window entities.SaleWindow) time.Duration {
+	return time.Duration(window.AdmissionMinutes) * time.Minute
+}
@@ -0,0 +1,97 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	BookingStatusHeld           = "held"
+	BookingStatusPendingPayment = "pending_payment"
+	BookingStatusConfirmed      = "confirmed"
+	BookingStatusCheckedIn      = "checked_in"
+	BookingStatusCancelled      = "cancelled"
+	BookingStatusRefunded       = "refunded"
+	BookingStatusExpired        = "expired"
+	BookingStatusNoShow         = "no_show"
+)
+
+// Booking represents a group of seats reserved by a user on a train.
+type Booking struct {
+	gorm.Model
+	Reference  string `json:"reference" gorm:"uniqueIndex;not null"`
+	UserID     uint   `json:"user_id" gorm:"not null;index"`      // FK to User
+	TrainCode  string `json:"train_code" gorm:"not null;index"`   // FK to Train
+	TrainRunID uint   `json:"train_run_id" gorm:"not null;index"` // FK to TrainRun
+
+	// The journey covers the legs from FromOrder up to ToOrder of the train's route
+	ServiceDate     time.Time `json:"service_date" gorm:"type:date;not null;index"`
+	FromStationCode string    `json:"from_station_code" gorm:"not null"` // FK to TrainStation
+	ToStationCode   string    `json:"to_station_code" gorm:"not null"`   // FK to TrainStation
+	FromOrder       int       `json:"from_order" gorm:"not null"`
+	ToOrder         int       `json:"to_order" gorm:"not null"`
+
+	SeatCount   int        `json:"seat_count" gorm:"not null"`
+	TotalPrice  int        `json:"total_price" gorm:"not null"`  // After the discount
+	Status      string     `json:"status" gorm:"not null;index"` // held, pending_payment, confirmed, checked_in, cancelled, refunded, expired, no_show
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+
+	// Set when a promotion code was applied, it is redeemed when the booking is confirmed
+	PromotionCode string `json:"promotion_code,omitempty" gorm:"index"` // FK to Promotion
+	Discount      int    `json:"discount,omitempty" gorm:"not null;default:0"`
+
+	// A held booking keeps its seats until HoldExpiresAt unless it is paid or confirmed
+	HoldExpiresAt *time.Time `json:"hold_expires_at,omitempty" gorm:"index"`
+
+	Tickets []Ticket `json:"tickets" gorm:"foreignKey:BookingID"`
+
+	ModifyBy uint  `json:"-" gorm:"not null"`
+	User     *User `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+// Holding reports whether the booking keeps its seats only until its hold expires.
+func (b Booking) Holding() bool {
+	return b.Status == BookingStatusHeld || b.Status == BookingStatusPendingPayment
+}
+
+// BookingHistory records one status change of a booking. FromStatus is empty for the
+// status a booking was created in.
+type BookingHistory struct {
+	ID         uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	BookingID  uint   `json:"booking_id" gorm:"not null;index"` // FK to Booking
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status" gorm:"not null"`
+	Reason     string `json:"reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ModifyBy  *uint     `json:"-"` // Nil for changes made by the system, e.g. an expired hold
+	User      *User     `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+// Ticket represents a single seat issued as part of a booking.
+type Ticket struct {
+	gorm.Model
+	BookingID    uint   `json:"booking_id" gorm:"not null;index"` // FK to Booking
+	TicketNumber string `json:"ticket_number" gorm:"uniqueIndex;not null"`
+	TrainCode    string `json:"train_code" gorm:"not null"` // FK to Train
+	Price        int    `json:"price" gorm:"not null"`
+	Status       string `json:"status" gorm:"not null"`
+
+	// Set when the ticket holds a specific seat
+	SeatID      *uint  `json:"seat_id,omitempty"` // FK to Seat
+	CoachNumber int    `json:"coach_number,omitempty"`
+	SeatNumber  string `json:"seat_number,omitempty"`
+
+	// The passenger the ticket is checked against
+	PassengerID *uint      `json:"passenger_id,omitempty"` // FK to Passenger
+	Passenger   *Passenger `json:"passenger,omitempty" gorm:"foreignKey:PassengerID"`
+
+	// Set when the ticket was sold at a concession fare, staff check the proof on board
+	ConcessionCode  string `json:"concession_code,omitempty"`
+	ConcessionName  string `json:"concession_name,omitempty"`
+	ConcessionProof string `json:"concession_proof,omitempty"`
+
+	ModifyBy uint  `json:"-" gorm:"not null"`
+	User     *User `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
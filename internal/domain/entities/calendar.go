@@ -0,0 +1,85 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CalendarExceptionAdded   = "added"
+	CalendarExceptionRemoved = "removed"
+)
+
+// ServiceCalendar describes on which days a train runs, like a GTFS calendar entry:
+// a weekday pattern inside a validity range, refined by dated exceptions.
+type ServiceCalendar struct {
+	ID        uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	TrainCode string `json:"train_code" gorm:"uniqueIndex;not null"` // FK to Train
+	Train     *Train `json:"-" gorm:"foreignKey:TrainCode;references:Code"`
+
+	Monday    bool `json:"monday" gorm:"not null"`
+	Tuesday   bool `json:"tuesday" gorm:"not null"`
+	Wednesday bool `json:"wednesday" gorm:"not null"`
+	Thursday  bool `json:"thursday" gorm:"not null"`
+	Friday    bool `json:"friday" gorm:"not null"`
+	Saturday  bool `json:"saturday" gorm:"not null"`
+	Sunday    bool `json:"sunday" gorm:"not null"`
+
+	StartDate time.Time `json:"start_date" gorm:"type:date;not null"`
+	EndDate   time.Time `json:"end_date" gorm:"type:date;not null"`
+
+	Exceptions []ServiceCalendarException `json:"exceptions" gorm:"foreignKey:ServiceCalendarID"`
+
+	CreatedAt time.Time      `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"-" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ModifyBy  uint           `json:"-" gorm:"not null"`
+	User      *User          `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+// ServiceCalendarException adds or removes a single date, like GTFS calendar_dates.
+// Added dates run even outside the validity range, e.g. extra Songkran services.
+type ServiceCalendarException struct {
+	ID                uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ServiceCalendarID uint      `json:"service_calendar_id" gorm:"not null;uniqueIndex:idx_calendar_exception_date"` // FK to ServiceCalendar
+	Date              time.Time `json:"date" gorm:"type:date;not null;uniqueIndex:idx_calendar_exception_date"`
+	Type              string    `json:"type" gorm:"not null"` // added, removed
+	Note              string    `json:"note"`
+
+	CreatedAt time.Time `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"-" gorm:"autoUpdateTime"`
+	ModifyBy  uint      `json:"-" gorm:"not null"`
+	User      *User     `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+// RunsOn reports whether the calendar includes the service date.
+func (c ServiceCalendar) RunsOn(serviceDate time.Time) bool {
+	day := serviceDate.Format(time.DateOnly)
+	for _, exception := range c.Exceptions {
+		if exception.Date.Format(time.DateOnly) == day {
+			return exception.Type == CalendarExceptionAdded
+		}
+	}
+
+	if day < c.StartDate.Format(time.DateOnly) || day > c.EndDate.Format(time.DateOnly) {
+		return false
+	}
+
+	switch serviceDate.Weekday() {
+	case time.Monday:
+		return c.Monday
+	case time.Tuesday:
+		return c.Tuesday
+	case time.Wednesday:
+		return c.Wednesday
+	case time.Thursday:
+		return c.Thursday
+	case time.Friday:
+		return c.Friday
+	case time.Saturday:
+		return c.Saturday
+	default:
+		return c.Sunday
+	}
+}
@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Berth positions of sleeping car seats
+const (
+	BerthUpper = "upper"
+	BerthLower = "lower"
+)
+
+// Facing directions of seats, relative to the direction of travel
+const (
+	FacingForward  = "forward"
+	FacingBackward = "backward"
+)
+
+// Coach is one car of a train's consist. Coaches are numbered from 1 along the train and
+// each has a single class.
+type Coach struct {
+	ID             uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	TrainCode      string `json:"train_code" gorm:"not null;index"` // FK to Train
+	Number         int    `json:"number" gorm:"not null"`
+	Class          int    `json:"class" gorm:"not null"` // 1, 2 or 3
+	AirConditioned bool   `json:"air_conditioned" gorm:"not null"`
+
+	Seats []Seat `json:"seats" gorm:"foreignKey:CoachID"`
+
+	CreatedAt time.Time      `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"-" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ModifyBy  uint           `json:"-" gorm:"not null"`
+	User      *User          `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+// Seat is a seat or berth of a coach. Row and Column place it on the coach layout, seats
+// in the same row with neighbouring columns sit next to each other.
+type Seat struct {
+	ID      uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	CoachID uint   `json:"coach_id" gorm:"not null;index"` // FK to Coach
+	Number  string `json:"number" gorm:"not null"`         // As printed on the seat, e.g. "12" or "12A"
+	Row     int    `json:"row" gorm:"not null"`
+	Column  int    `json:"column" gorm:"not null"`
+	Berth   string `json:"berth,omitempty"`  // upper, lower or empty for a seat
+	Facing  string `json:"facing,omitempty"` // forward, backward
+
+	CreatedAt time.Time      `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"-" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// SeatReservation holds a seat of a train run from stop FromOrder to stop ToOrder for a
+// ticket. The same seat can be sold again on legs outside of that range.
+type SeatReservation struct {
+	ID         uint `json:"id" gorm:"primaryKey;autoIncrement"`
+	TrainRunID uint `json:"train_run_id" gorm:"not null;index:idx_seat_reservation_run_seat"` // FK to TrainRun
+	SeatID     uint `json:"seat_id" gorm:"not null;index:idx_seat_reservation_run_seat"`      // FK to Seat
+	TicketID   uint `json:"ticket_id" gorm:"not null;index"`                                  // FK to Ticket
+	FromOrder  int  `json:"from_order" gorm:"not null"`
+	ToOrder    int  `json:"to_order" gorm:"not null"`
+
+	CreatedAt time.Time `json:"-" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ConcessionCategoryChild    = "child"
+	ConcessionCategorySenior   = "senior"
+	ConcessionCategoryMonk     = "monk"
+	ConcessionCategoryDisabled = "disabled"
+)
+
+// ConcessionRule is a reduced fare for a category of passengers. The age and nationality
+// conditions are checked against the passenger when booking, Proof is what staff check on
+// board, e.g. the identity card of a monk.
+type ConcessionRule struct {
+	Code     string `json:"code" gorm:"primaryKey;not null;index"`
+	Name     string `json:"name" gorm:"not null"`
+	Category string `json:"category" gorm:"not null;index"` // child, senior, monk, disabled
+
+	DiscountPercent int `json:"discount_percent" gorm:"not null"` // Taken off the distance fare, fees are paid in full
+
+	// Conditions, a zero value leaves the condition out
+	MinAge      int  `json:"min_age" gorm:"not null;default:0"`
+	MaxAge      int  `json:"max_age" gorm:"not null;default:0"`
+	MaxHeightCm int  `json:"max_height_cm" gorm:"not null;default:0"` // Passengers older than MaxAge still qualify up to this height
+	ThaiOnly    bool `json:"thai_only" gorm:"not null;default:false"` // Needs a Thai national ID
+
+	Proof  string `json:"proof" gorm:"not null"` // What the passenger shows on board, e.g. "Thai national ID card"
+	Active bool   `json:"active" gorm:"not null;default:true"`
+
+	CreatedAt time.Time      `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"-" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	ModifyBy uint  `json:"-" gorm:"not null"`
+	User     *User `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
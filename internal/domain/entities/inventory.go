@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+// LegInventory holds the free seats of one leg of a train run. A leg is the segment
+// between two consecutive stops (FromOrder, ToOrder) of the train's StationOrder. A
+// booking from stop A to stop B consumes one seat on every leg between A and B and
+// nothing outside of it.
+type LegInventory struct {
+	ID         uint `json:"id" gorm:"primaryKey;autoIncrement"`
+	TrainRunID uint `json:"train_run_id" gorm:"not null;uniqueIndex:idx_leg_inventory_run_leg"` // FK to TrainRun
+	FromOrder  int  `json:"from_order" gorm:"not null;uniqueIndex:idx_leg_inventory_run_leg"`
+	ToOrder    int  `json:"to_order" gorm:"not null;uniqueIndex:idx_leg_inventory_run_leg"`
+
+	Seats          int `json:"seats" gorm:"not null"`
+	AvailableSeats int `json:"available_seats" gorm:"not null"`
+
+	CreatedAt time.Time `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"-" gorm:"autoUpdateTime"`
+}
+
+// InventoryFlush records a batch of leg seat changes written behind from the Redis seat
+// counters, so a batch retried after a crash is applied only once.
+type InventoryFlush struct {
+	ID         uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	FlushID    string `json:"flush_id" gorm:"not null;uniqueIndex"`
+	TrainRunID uint   `json:"train_run_id" gorm:"not null;index"` // FK to TrainRun
+
+	CreatedAt time.Time `json:"-" gorm:"autoCreateTime"`
+}
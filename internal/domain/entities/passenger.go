@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PassengerDocumentNationalID = "national_id" // Thai national ID card
+	PassengerDocumentPassport   = "passport"
+)
+
+// Passenger is a named traveller checked against their document on the train. Saved
+// passengers are kept on the user's profile to pick when booking, a ticket keeps its own
+// copy so later edits do not change tickets already issued.
+type Passenger struct {
+	gorm.Model
+	UserID uint `json:"user_id" gorm:"not null;index"` // FK to User who entered the passenger
+	Saved  bool `json:"saved" gorm:"not null;default:false;index"`
+
+	Title       string `json:"title" gorm:"not null"` // mr, mrs, ms, miss, master
+	FirstNameTh string `json:"first_name_th,omitempty"`
+	LastNameTh  string `json:"last_name_th,omitempty"`
+	FirstNameEn string `json:"first_name_en,omitempty"`
+	LastNameEn  string `json:"last_name_en,omitempty"`
+
+	DocumentType   string    `json:"document_type" gorm:"not null"` // national_id, passport
+	DocumentNumber string    `json:"document_number" gorm:"not null"`
+	Country        string    `json:"country" gorm:"not null"` // ISO 3166-1 alpha-2 code of the issuing country
+	DateOfBirth    time.Time `json:"date_of_birth" gorm:"type:date;not null"`
+
+	ModifyBy uint  `json:"-" gorm:"not null"`
+	User     *User `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
@@ -0,0 +1,65 @@
+package entities
+
+import "time"
+
+const (
+	PaymentStatusPending   = "pending"
+	PaymentStatusSucceeded = "succeeded"
+	PaymentStatusFailed    = "failed"
+	PaymentStatusExpired   = "expired"
+)
+
+// Payment is one attempt to collect the total price of a booking through a payment
+// provider. ProviderRef is how the provider refers to the payment in status queries,
+// webhooks and refunds.
+type Payment struct {
+	ID          uint     `json:"id" gorm:"primaryKey;autoIncrement"`
+	BookingID   uint     `json:"booking_id" gorm:"not null;index"` // FK to Booking
+	Booking     *Booking `json:"booking,omitempty" gorm:"foreignKey:BookingID"`
+	Provider    string   `json:"provider" gorm:"not null"` // promptpay, fake
+	ProviderRef string   `json:"provider_ref" gorm:"uniqueIndex;not null"`
+	Amount      int      `json:"amount" gorm:"not null"`
+	Status      string   `json:"status" gorm:"not null;index"` // pending, succeeded, failed, expired
+
+	// The EMVCo payload customers scan with their banking app, for QR payments
+	QRPayload string `json:"qr_payload,omitempty"`
+
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	PaidAt    *time.Time `json:"paid_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"-" gorm:"autoUpdateTime"`
+	ModifyBy  uint       `json:"-" gorm:"not null"`
+	User      *User      `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+const (
+	PaymentEventStateReceived = "received"
+	PaymentEventStateApplied  = "applied"
+	PaymentEventStateIgnored  = "ignored"
+	PaymentEventStateFailed   = "failed"
+)
+
+// PaymentWebhookEvent is a payment notification as a provider sent it. A provider may send
+// the same event more than once, it is stored once per provider and EventID. State tells
+// whether the event changed its payment, had nothing left to change, or could not be
+// applied and waits to be replayed.
+type PaymentWebhookEvent struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Provider    string    `json:"provider" gorm:"not null;uniqueIndex:idx_payment_webhook_event"`
+	EventID     string    `json:"event_id" gorm:"not null;uniqueIndex:idx_payment_webhook_event"`
+	ProviderRef string    `json:"provider_ref" gorm:"not null;index"` // FK to Payment
+	Status      string    `json:"status" gorm:"not null"`             // The payment status reported
+	Amount      int       `json:"amount"`
+	OccurredAt  time.Time `json:"occurred_at"`
+	Payload     string    `json:"payload" gorm:"type:text;not null"` // The raw body
+
+	State       string     `json:"state" gorm:"not null;index"` // received, applied, ignored, failed
+	Error       string     `json:"error,omitempty"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"-" gorm:"autoUpdateTime"`
+	ModifyBy  *uint     `json:"-"` // Nil unless an admin replayed the event
+	User      *User     `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
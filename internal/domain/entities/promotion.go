@@ -0,0 +1,57 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PromotionDiscountPercent = "percent"
+	PromotionDiscountFixed   = "fixed"
+)
+
+// Promotion is a discount campaign redeemed with its code when booking. Zero or nil limits
+// leave the limit out.
+type Promotion struct {
+	Code        string `json:"code" gorm:"primaryKey;not null;index"`
+	Description string `json:"description" gorm:"not null"`
+
+	DiscountType  string `json:"discount_type" gorm:"not null"`  // percent, fixed
+	DiscountValue int    `json:"discount_value" gorm:"not null"` // Percent off, or baht off the booking
+	MaxDiscount   int    `json:"max_discount" gorm:"not null;default:0"`
+
+	// Which trips qualify
+	TrainLineCode string     `json:"train_line_code,omitempty"` // FK to TrainLine
+	TrainTypeCode string     `json:"train_type_code,omitempty"` // FK to TrainType
+	TravelFrom    *time.Time `json:"travel_from,omitempty" gorm:"type:date"`
+	TravelTo      *time.Time `json:"travel_to,omitempty" gorm:"type:date"`
+	BookingFrom   *time.Time `json:"booking_from,omitempty"`
+	BookingTo     *time.Time `json:"booking_to,omitempty"`
+	MinSpend      int        `json:"min_spend" gorm:"not null;default:0"`
+
+	// Usage caps, counted when a booking is confirmed
+	TotalLimit    int `json:"total_limit" gorm:"not null;default:0"`
+	PerUserLimit  int `json:"per_user_limit" gorm:"not null;default:0"`
+	RedeemedCount int `json:"redeemed_count" gorm:"not null;default:0"`
+
+	Active bool `json:"active" gorm:"not null;default:true"`
+
+	CreatedAt time.Time      `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"-" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	ModifyBy uint  `json:"-" gorm:"not null"`
+	User     *User `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+// PromotionRedemption counts one use of a promotion by a confirmed booking.
+type PromotionRedemption struct {
+	ID            uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	PromotionCode string `json:"promotion_code" gorm:"not null;index"`   // FK to Promotion
+	BookingID     uint   `json:"booking_id" gorm:"not null;uniqueIndex"` // FK to Booking
+	UserID        uint   `json:"user_id" gorm:"not null;index"`          // FK to User
+	Discount      int    `json:"discount" gorm:"not null"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,55 @@
+package entities
+
+import "time"
+
+const (
+	ReconciliationMatched           = "matched"
+	ReconciliationMissingPayment    = "missing_payment"
+	ReconciliationMissingSettlement = "missing_settlement"
+	ReconciliationProviderMismatch  = "provider_mismatch"
+	ReconciliationDuplicate         = "duplicate"
+	ReconciliationAmountMismatch    = "amount_mismatch"
+	ReconciliationStatusMismatch    = "status_mismatch"
+	ReconciliationInvalid           = "invalid"
+)
+
+// ReconciliationReport is the outcome of matching one settlement file of a provider
+// against our payments. Payments that succeeded on SettlementDate are expected in the file.
+type ReconciliationReport struct {
+	ID             uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Provider       string    `json:"provider" gorm:"not null;index"`
+	SettlementDate time.Time `json:"settlement_date" gorm:"type:date;not null;index"`
+	FileName       string    `json:"file_name"`
+
+	RowCount     int `json:"row_count" gorm:"not null"`
+	MatchedCount int `json:"matched_count" gorm:"not null"`
+	IssueCount   int `json:"issue_count" gorm:"not null"`
+	SettledTotal int `json:"settled_total" gorm:"not null"` // Baht in the valid rows of the file
+
+	Items []ReconciliationItem `json:"items,omitempty" gorm:"foreignKey:ReportID"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ModifyBy  uint      `json:"-" gorm:"not null"`
+	User      *User     `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+// ReconciliationItem is one row of a settlement file, or a payment missing from it, and
+// what it was matched to. Line is 0 for payments missing from the file.
+type ReconciliationItem struct {
+	ID            uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	ReportID      uint   `json:"report_id" gorm:"not null;index"` // FK to ReconciliationReport
+	Line          int    `json:"line"`
+	ProviderRef   string `json:"provider_ref" gorm:"index"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	SettledAmount int    `json:"settled_amount"`
+
+	PaymentID        *uint  `json:"payment_id,omitempty"` // FK to Payment
+	PaymentStatus    string `json:"payment_status,omitempty"`
+	ExpectedAmount   int    `json:"expected_amount"`
+	BookingID        *uint  `json:"booking_id,omitempty"` // FK to Booking
+	BookingReference string `json:"booking_reference,omitempty"`
+	BookingStatus    string `json:"booking_status,omitempty"`
+
+	Result string `json:"result" gorm:"not null;index"` // matched, missing_payment, missing_settlement, provider_mismatch, duplicate, amount_mismatch, status_mismatch, invalid
+	Note   string `json:"note,omitempty"`
+}
@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	FareTypeStandard   = "standard"
+	FareTypeConcession = "concession"
+	FareTypePromotion  = "promotion"
+)
+
+const (
+	RefundStatusPending            = "pending"
+	RefundStatusProcessing         = "processing"
+	RefundStatusSucceeded          = "succeeded"
+	RefundStatusFailedManualReview = "failed_manual_review"
+)
+
+// RefundRule gives back RefundPercent of the fare, less FeePerTicket, when a booking is
+// cancelled at least MinHoursBefore hours before the train leaves the passenger's station.
+// An empty TrainTypeCode or FareType matches any.
+type RefundRule struct {
+	ID            uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name          string `json:"name" gorm:"not null"`
+	TrainTypeCode string `json:"train_type_code,omitempty" gorm:"index"` // FK to TrainType
+	FareType      string `json:"fare_type,omitempty"`                    // standard, concession, promotion
+
+	MinHoursBefore int  `json:"min_hours_before" gorm:"not null;default:0"`
+	RefundPercent  int  `json:"refund_percent" gorm:"not null"`
+	FeePerTicket   int  `json:"fee_per_ticket" gorm:"not null;default:0"`
+	Active         bool `json:"active" gorm:"not null;default:true"`
+
+	CreatedAt time.Time      `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"-" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ModifyBy  uint           `json:"-" gorm:"not null"`
+	User      *User          `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+// Refund is money owed back to the customer for a booking. It is created pending and paid
+// out by the refund worker through the provider of PaymentID, retrying with a growing
+// delay. A refund the worker gives up on waits for staff in failed_manual_review.
+type Refund struct {
+	ID        uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	BookingID uint   `json:"booking_id" gorm:"not null;index"` // FK to Booking
+	PaymentID *uint  `json:"payment_id,omitempty"`             // FK to Payment, found by the worker when not set
+	Amount    int    `json:"amount" gorm:"not null"`
+	Status    string `json:"status" gorm:"not null;index"` // pending, processing, succeeded, failed_manual_review
+	Reason    string `json:"reason,omitempty"`
+
+	Attempts      int             `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty" gorm:"index"`
+	LastError     string          `json:"last_error,omitempty"`
+	History       []RefundAttempt `json:"history,omitempty" gorm:"foreignKey:RefundID"`
+
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	ModifyBy    uint       `json:"-" gorm:"not null"`
+	User        *User      `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+// RefundAttempt records one call to the payment provider to pay out a refund.
+type RefundAttempt struct {
+	ID          uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	RefundID    uint   `json:"refund_id" gorm:"not null;index"` // FK to Refund
+	Attempt     int    `json:"attempt" gorm:"not null"`
+	Provider    string `json:"provider"`
+	ProviderRef string `json:"provider_ref"`
+	Amount      int    `json:"amount" gorm:"not null"`
+	Succeeded   bool   `json:"succeeded" gorm:"not null"`
+	Error       string `json:"error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
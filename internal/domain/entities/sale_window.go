@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SaleWindow is a ticket sale opening, e.g. for Songkran. While it is active, bookings go
+// through the waiting room, which admits AdmitPerMinute users a minute and lets each of
+// them book for AdmissionMinutes.
+type SaleWindow struct {
+	ID               uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name             string    `json:"name" gorm:"not null"`
+	StartsAt         time.Time `json:"starts_at" gorm:"not null;index"`
+	EndsAt           time.Time `json:"ends_at" gorm:"not null;index"`
+	AdmitPerMinute   int       `json:"admit_per_minute" gorm:"not null"`
+	AdmissionMinutes int       `json:"admission_minutes" gorm:"not null"`
+
+	CreatedAt time.Time      `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"-" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ModifyBy  uint           `json:"-" gorm:"not null"`
+	User      *User          `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+// Active reports whether the window is open at the given time.
+func (w SaleWindow) Active(at time.Time) bool {
+	return !at.Before(w.StartsAt) && at.Before(w.EndsAt)
+}
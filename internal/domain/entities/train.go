@@ -12,10 +12,7 @@ type Train struct {
 	Name            string `json:"name" gorm:"not null"`
 	FromStationCode string `json:"from" gorm:"not null"` // FK to TrainStation
 	ToStationCode   string `json:"to" gorm:"not null"`   // FK to TrainStation
-	Time            string `json:"time" gorm:"not null"`
-	Price           int    `json:"price" gorm:"not null"`
 	Seats           int    `json:"seats" gorm:"not null"`
-	AvailableSeats  int    `json:"available_seats" gorm:"not null"`
 
 	TrainTypeCode string    `json:"train_type_code" gorm:"not null"` // FK to TrainType
 	TrainType     TrainType `json:"train_type" gorm:"foreignKey:TrainTypeCode;references:Code"`
@@ -39,7 +36,8 @@ type Train struct {
 // TrainType represents different types of trains.
 type TrainType struct {
 	Code string `json:"code" gorm:"primaryKey;not null;index"`
-	Name string `json:"name" gorm:"not null"`
+	Name string `json:"name" gorm:"not null"`          // ordinary, rapid, express, special express
+	Fee  int    `json:"fee" gorm:"not null;default:0"` // Supplementary fee per passenger in baht, e.g. the express fee
 
 	CreatedAt time.Time      `json:"-" gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `json:"-" gorm:"autoUpdateTime"`
@@ -65,6 +63,9 @@ type TrainProfitType struct {
 	Code string `json:"code" gorm:"primaryKey;not null;index"`
 	Name string `json:"name" gorm:"not null"` // SOUTh, NORTH, EAST, WEST, EAST-NORTH, EAST-SOUTH, WEST-NORTH, WEST-SOUTH
 
+	// Share of the distance fare charged, e.g. below 100 for subsidised social services
+	FarePercent int `json:"fare_percent" gorm:"not null;default:100"`
+
 	CreatedAt time.Time      `json:"-" gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `json:"-" gorm:"autoUpdateTime"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -76,6 +77,7 @@ type TrainProfitType struct {
 // TrainStation represents a train station.
 type TrainStation struct {
 	ID   uint   `json:"id" gorm:"primaryKey;not null;index;autoIncrement"`
+	Code string `json:"code" gorm:"uniqueIndex;not null"` // Immutable natural key, e.g. SRT station number
 	Name string `json:"name" gorm:"not null"`
 
 	Province    string `json:"province" gorm:"not null"`
@@ -128,6 +130,14 @@ type StationOrderDetail struct {
 
 	Order int `json:"order" gorm:"not null"` // Order in the train route
 
+	// Minutes after midnight of the service date (Asia/Bangkok). Values of 1440 and more
+	// fall on the following days, for services that run past midnight and overnight sleepers.
+	ArrivalOffset   int `json:"arrival_offset" gorm:"not null;default:0"`
+	DepartureOffset int `json:"departure_offset" gorm:"not null;default:0"`
+
+	// Kilometres from the first stop of the route along the track, the basis of the fare
+	DistanceKm int `json:"distance_km" gorm:"not null;default:0"`
+
 	ModifyBy uint  `json:"-" gorm:"not null"`
 	User     *User `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
 }
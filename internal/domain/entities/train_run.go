@@ -0,0 +1,40 @@
+package entities
+
+import "time"
+
+const (
+	TrainRunStatusScheduled = "scheduled"
+	TrainRunStatusDelayed   = "delayed"
+	TrainRunStatusCancelled = "cancelled"
+	TrainRunStatusDeparted  = "departed"
+	TrainRunStatusArrived   = "arrived"
+)
+
+// TrainRun is a train on one service date. Train and its StationOrder are the timetable
+// template, a run holds what is live for that date: its own seat inventory, its status and
+// the actual departure and arrival times.
+type TrainRun struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	TrainCode   string    `json:"train_code" gorm:"not null;uniqueIndex:idx_train_run_date"` // FK to Train
+	Train       *Train    `json:"-" gorm:"foreignKey:TrainCode;references:Code"`
+	ServiceDate time.Time `json:"service_date" gorm:"type:date;not null;uniqueIndex:idx_train_run_date;index"`
+
+	Status string `json:"status" gorm:"not null;index"` // scheduled, delayed, cancelled, departed, arrived
+	Seats  int    `json:"seats" gorm:"not null"`        // Capacity of the train when the run was generated
+
+	DelayMinutes    int        `json:"delay_minutes" gorm:"not null;default:0"`
+	ActualDeparture *time.Time `json:"actual_departure,omitempty"`
+	ActualArrival   *time.Time `json:"actual_arrival,omitempty"`
+
+	Legs []LegInventory `json:"legs,omitempty" gorm:"foreignKey:TrainRunID"`
+
+	CreatedAt time.Time `json:"-" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"-" gorm:"autoUpdateTime"`
+	ModifyBy  *uint     `json:"-"` // Nil while the run is only touched by the generator
+	User      *User     `json:"user,omitempty" gorm:"foreignKey:ModifyBy;references:ID"`
+}
+
+// Bookable reports whether seats can still be sold on the run.
+func (r TrainRun) Bookable() bool {
+	return r.Status == TrainRunStatusScheduled || r.Status == TrainRunStatusDelayed
+}
@@ -0,0 +1,53 @@
+package interfaces
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+var (
+	ErrBookingNotFound  = errors.New("booking not found")
+	ErrSeatsUnavailable = errors.New("not enough seats available")
+	ErrSeatsTaken       = errors.New("selected seats are no longer available")
+	ErrHoldExpired      = errors.New("seat hold has expired")
+	ErrPaymentRequired  = errors.New("booking must be paid before it is confirmed")
+
+	ErrInvalidBookingTransition = errors.New("invalid booking status change")
+)
+
+type BookingRepository interface {
+	// CreateBooking inserts the booking and a seat reservation for every ticket with a
+	// SeatID. The seats must already be taken from the seat inventory, the legs are
+	// updated later through ApplyLegDeltas. The promotion of a confirmed booking is
+	// redeemed in the same transaction.
+	CreateBooking(booking entities.Booking) (entities.Booking, error)
+	GetBookingByID(id uint) (entities.Booking, error)
+	GetBookingsByUser(userID uint, offset, limit int) ([]entities.Booking, int64, error)
+
+	// Status changes
+	// TransitionBooking moves the booking from change.FromStatus to change.ToStatus and
+	// records the change. It fails with ErrInvalidBookingTransition when the booking is no
+	// longer in FromStatus, and with ErrHoldExpired when a hold that ran out would be kept.
+	// The promotion of a booking being confirmed is redeemed in the same transaction, which
+	// fails with ErrPromotionExhausted once the promotion is used up. With releaseSeats, the
+	// seats go back to the legs it covered and its tickets' seats are freed. A refund, if
+	// given, is created for the booking in the same transaction.
+	TransitionBooking(change entities.BookingHistory, releaseSeats bool, refund *entities.Refund) (entities.Booking, error)
+	GetBookingHistory(bookingID uint) ([]entities.BookingHistory, error)
+
+	// Seat holds
+	// GetExpiredHolds returns up to limit held or pending payment bookings whose hold ran
+	// out before now.
+	GetExpiredHolds(now time.Time, limit int) ([]entities.Booking, error)
+
+	// Leg inventory
+	GetStationOrder(trainCode string) (entities.StationOrder, error)
+	GetAvailableSeats(trainCode string, serviceDate time.Time, fromOrder, toOrder int) (int, error)
+	GetLegInventory(trainRunID uint) ([]entities.LegInventory, error)
+	GetSeatReservations(trainRunID uint) ([]entities.SeatReservation, error)
+	// ApplyLegDeltas adds deltas, keyed by the FromOrder of each leg, to the available seats
+	// of the run's legs. A flushID that was applied before is skipped.
+	ApplyLegDeltas(trainRunID uint, flushID string, deltas map[int]int) error
+}
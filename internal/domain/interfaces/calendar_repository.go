@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+var (
+	ErrCalendarNotFound = errors.New("service calendar not found")
+	ErrTrainNotRunning  = errors.New("train does not run on this date")
+)
+
+type ServiceCalendarRepository interface {
+	GetCalendar(trainCode string) (entities.ServiceCalendar, error)
+	GetCalendars() ([]entities.ServiceCalendar, error)
+
+	// SaveCalendar creates the train's calendar or replaces its weekday pattern and
+	// validity range. Exceptions are kept.
+	SaveCalendar(calendar entities.ServiceCalendar) (entities.ServiceCalendar, error)
+
+	// SaveException adds the exception or replaces the one already on that date.
+	SaveException(trainCode string, exception entities.ServiceCalendarException) (entities.ServiceCalendar, error)
+	DeleteException(trainCode string, date time.Time) error
+}
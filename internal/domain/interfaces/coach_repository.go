@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+type CoachRepository interface {
+	// GetCoaches returns the consist of the train in coach order, each coach with its seats.
+	GetCoaches(trainCode string) ([]entities.Coach, error)
+
+	// ReplaceCoaches swaps the train's consist for the given coaches in one transaction and
+	// sets the capacity of the train and of its upcoming runs to the number of seats. It
+	// fails while seats are sold on upcoming runs of the train.
+	ReplaceCoaches(trainCode string, coaches []entities.Coach, modifyBy uint) ([]entities.Coach, error)
+
+	// GetReservedSeatIDs returns the seats of the run held on any leg between fromOrder and toOrder.
+	GetReservedSeatIDs(trainRunID uint, fromOrder, toOrder int) ([]uint, error)
+}
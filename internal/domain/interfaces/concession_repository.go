@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"errors"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+var (
+	ErrConcessionNotFound = errors.New("concession not found")
+)
+
+type ConcessionRepository interface {
+	CreateRule(rule entities.ConcessionRule) (entities.ConcessionRule, error)
+	UpdateRule(rule entities.ConcessionRule) (entities.ConcessionRule, error)
+	DeleteRule(code string) error
+	GetRules() ([]entities.ConcessionRule, error)
+	GetRuleByCode(code string) (entities.ConcessionRule, error)
+}
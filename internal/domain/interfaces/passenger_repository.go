@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"errors"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+var (
+	ErrPassengerNotFound = errors.New("passenger not found")
+)
+
+type PassengerRepository interface {
+	GetPassenger(id uint) (entities.Passenger, error)
+	// GetSavedPassengers returns the passengers saved on the user's profile.
+	GetSavedPassengers(userID uint) ([]entities.Passenger, error)
+	CreatePassenger(passenger entities.Passenger) (entities.Passenger, error)
+	UpdatePassenger(passenger entities.Passenger) (entities.Passenger, error)
+	DeletePassenger(id uint) error
+}
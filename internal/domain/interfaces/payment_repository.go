@@ -0,0 +1,46 @@
+package interfaces
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+var (
+	ErrPaymentNotFound        = errors.New("payment not found")
+	ErrPaymentStatusChanged   = errors.New("payment status has changed")
+	ErrPaymentProviderUnknown = errors.New("unknown payment provider")
+	ErrPaymentEventNotFound   = errors.New("payment event not found")
+
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	ErrRefundNotSupported      = errors.New("payment provider cannot refund automatically")
+)
+
+type PaymentRepository interface {
+	CreatePayment(payment entities.Payment) (entities.Payment, error)
+	GetPaymentByID(id uint) (entities.Payment, error)
+	GetPaymentByProviderRef(providerRef string) (entities.Payment, error)
+	GetPaymentsByBooking(bookingID uint) ([]entities.Payment, error)
+
+	// For reconciliation, the payments come with their booking
+	GetPaymentsByProviderRefs(providerRefs []string) ([]entities.Payment, error)
+	// GetSucceededPayments returns the payments through the provider paid from from up to
+	// but excluding to.
+	GetSucceededPayments(provider string, from, to time.Time) ([]entities.Payment, error)
+
+	// UpdatePaymentStatus moves the payment from fromStatus to toStatus. It fails with
+	// ErrPaymentStatusChanged when the payment is no longer in fromStatus, so a payment is
+	// settled only once however often the provider reports it.
+	UpdatePaymentStatus(id uint, fromStatus, toStatus string, paidAt *time.Time) (entities.Payment, error)
+
+	// Webhook events
+	// CreateWebhookEvent stores the event unless the provider sent it before. It returns the
+	// stored event and whether it was new.
+	CreateWebhookEvent(event entities.PaymentWebhookEvent) (entities.PaymentWebhookEvent, bool, error)
+	UpdateWebhookEvent(event entities.PaymentWebhookEvent) (entities.PaymentWebhookEvent, error)
+	GetWebhookEvent(id uint) (entities.PaymentWebhookEvent, error)
+	// GetWebhookEvents returns the events in the state, newest first. An empty state
+	// returns events in any state.
+	GetWebhookEvents(state string, offset, limit int) ([]entities.PaymentWebhookEvent, int64, error)
+}
@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"errors"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+var (
+	ErrPromotionNotFound  = errors.New("promotion not found")
+	ErrPromotionExhausted = errors.New("promotion has been used up")
+)
+
+type PromotionRepository interface {
+	CreatePromotion(promotion entities.Promotion) (entities.Promotion, error)
+	UpdatePromotion(promotion entities.Promotion) (entities.Promotion, error)
+	DeletePromotion(code string) error
+	GetPromotions() ([]entities.Promotion, error)
+	GetPromotionByCode(code string) (entities.Promotion, error)
+
+	// CountRedemptions returns how often the user redeemed the promotion.
+	CountRedemptions(code string, userID uint) (int64, error)
+}
@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"errors"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+var (
+	ErrReconciliationReportNotFound = errors.New("reconciliation report not found")
+)
+
+type ReconciliationRepository interface {
+	// CreateReport stores the report together with its items.
+	CreateReport(report entities.ReconciliationReport) (entities.ReconciliationReport, error)
+	// GetReport returns the report with its items.
+	GetReport(id uint) (entities.ReconciliationReport, error)
+	// GetReports returns reports without their items, newest first.
+	GetReports(offset, limit int) ([]entities.ReconciliationReport, int64, error)
+}
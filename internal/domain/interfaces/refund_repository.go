@@ -0,0 +1,43 @@
+package interfaces
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+var (
+	ErrRefundRuleNotFound  = errors.New("refund rule not found")
+	ErrRefundNotFound      = errors.New("refund not found")
+	ErrRefundStatusChanged = errors.New("refund status has changed")
+)
+
+type RefundRepository interface {
+	// Refund rules
+	CreateRule(rule entities.RefundRule) (entities.RefundRule, error)
+	UpdateRule(rule entities.RefundRule) (entities.RefundRule, error)
+	DeleteRule(id uint) error
+	GetRules() ([]entities.RefundRule, error)
+	GetActiveRules() ([]entities.RefundRule, error)
+
+	// Refunds of cancellations are created together with the cancellation, see
+	// BookingRepository. CreateRefund is for money owed back without a status change, e.g.
+	// a payment that arrived after the hold ran out.
+	CreateRefund(refund entities.Refund) (entities.Refund, error)
+	GetRefundsByBooking(bookingID uint) ([]entities.Refund, error)
+	// GetRefund returns the refund with its attempts.
+	GetRefund(id uint) (entities.Refund, error)
+
+	// Refund worker
+	// GetDueRefunds returns up to limit pending refunds whose next attempt is due at now.
+	GetDueRefunds(now time.Time, limit int) ([]entities.Refund, error)
+	// GetStuckRefunds returns the refunds waiting for staff and those processing since
+	// before processingBefore, oldest first.
+	GetStuckRefunds(processingBefore time.Time) ([]entities.Refund, error)
+	// UpdateRefund saves the status, attempts and outcome of the refund if it is still in
+	// fromStatus, and fails with ErrRefundStatusChanged otherwise, so only one worker
+	// claims a refund.
+	UpdateRefund(refund entities.Refund, fromStatus string) (entities.Refund, error)
+	CreateRefundAttempt(attempt entities.RefundAttempt) (entities.RefundAttempt, error)
+}
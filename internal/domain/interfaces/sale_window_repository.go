@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+var (
+	ErrSaleWindowNotFound = errors.New("sale window not found")
+	ErrSaleWindowOverlap  = errors.New("sale window overlaps another one")
+)
+
+type SaleWindowRepository interface {
+	GetWindow(id uint) (entities.SaleWindow, error)
+	GetWindows() ([]entities.SaleWindow, error)
+
+	// GetActiveWindow returns the window open at the given time, or ErrSaleWindowNotFound.
+	GetActiveWindow(at time.Time) (entities.SaleWindow, error)
+
+	// CreateWindow returns ErrSaleWindowOverlap when the window overlaps another one.
+	CreateWindow(window entities.SaleWindow) (entities.SaleWindow, error)
+	DeleteWindow(id uint) error
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+type StationOrderRepository interface {
+	GetStationOrder(trainCode string) (entities.StationOrder, error)
+	// GetStationOrders returns every route with its stops in order and its train preloaded
+	// together with the train type and profit type.
+	GetStationOrders() ([]entities.StationOrder, error)
+	CreateStationOrder(stationOrder entities.StationOrder) (entities.StationOrder, error)
+
+	// ReplaceStationOrder swaps every stop of the train's route for the given ones in a
+	// single transaction, so readers never see a half written route. Replacing and deleting
+	// fail while seats are sold on upcoming runs of the train.
+	ReplaceStationOrder(trainCode string, stops []entities.StationOrderDetail, modifyBy uint) (entities.StationOrder, error)
+	DeleteStationOrder(trainCode string) error
+}
@@ -12,6 +12,7 @@ type StationTypeRepository interface {
 	DeleteTrainStation(id uint) error
 	GetTrainStations(filters map[string]interface{}) ([]entities.TrainStation, error)
 	GetTrainStationById(id uint) (entities.TrainStation, error)
+	GetTrainStationByCode(code string) (entities.TrainStation, error)
 
 	// TrainStationType
 	CreateTrainStationType(stationType entities.StationType) (entities.StationType, error)
@@ -0,0 +1,35 @@
+package interfaces
+
+import (
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+type TrainRepository interface {
+	// Train
+	CreateTrain(train entities.Train) (entities.Train, error)
+	UpdateTrain(train entities.Train) (entities.Train, error)
+	DeleteTrain(code string) error
+	GetTrains(filters map[string]interface{}) ([]entities.Train, error)
+	GetTrainByCode(code string) (entities.Train, error)
+
+	// TrainType
+	CreateTrainType(trainType entities.TrainType) (entities.TrainType, error)
+	UpdateTrainType(trainType entities.TrainType) (entities.TrainType, error)
+	DeleteTrainType(code string) error
+	GetTrainTypes() ([]entities.TrainType, error)
+	GetTrainTypeByCode(code string) (entities.TrainType, error)
+
+	// TrainLine
+	CreateTrainLine(trainLine entities.TrainLine) (entities.TrainLine, error)
+	UpdateTrainLine(trainLine entities.TrainLine) (entities.TrainLine, error)
+	DeleteTrainLine(code string) error
+	GetTrainLines() ([]entities.TrainLine, error)
+	GetTrainLineByCode(code string) (entities.TrainLine, error)
+
+	// TrainProfitType
+	CreateTrainProfitType(profitType entities.TrainProfitType) (entities.TrainProfitType, error)
+	UpdateTrainProfitType(profitType entities.TrainProfitType) (entities.TrainProfitType, error)
+	DeleteTrainProfitType(code string) error
+	GetTrainProfitTypes() ([]entities.TrainProfitType, error)
+	GetTrainProfitTypeByCode(code string) (entities.TrainProfitType, error)
+}
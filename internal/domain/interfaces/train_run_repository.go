@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+var (
+	ErrTrainRunNotFound    = errors.New("train run not found")
+	ErrTrainRunNotBookable = errors.New("train run is not open for booking")
+)
+
+type TrainRunRepository interface {
+	// GetRun returns the run with its legs in route order.
+	GetRun(trainCode string, serviceDate time.Time) (entities.TrainRun, error)
+	GetRuns(trainCode string, from, to time.Time) ([]entities.TrainRun, error)
+	GetRunsByStatus(status string, from, to time.Time) ([]entities.TrainRun, error)
+
+	// CreateRuns creates the runs that do not exist yet, each with one leg per pair of
+	// consecutive stops of the train's route, and returns how many were created.
+	CreateRuns(runs []entities.TrainRun) (int, error)
+	UpdateRun(run entities.TrainRun) (entities.TrainRun, error)
+}
@@ -32,14 +32,43 @@ func (db *Database) Connect() (*gorm.DB, error) {
 
 // Migrate applies database migrations for the specified entities.
 func (db *Database) Migrate() error {
+	if err := db.migrateStationCodes(); err != nil {
+		return err
+	}
+
 	err := db.DB.AutoMigrate(
 		&entities.User{},
-		// &entities.Train{},
-		// &entities.TrainType{},
+		&entities.TrainType{},
+		&entities.TrainLine{},
+		&entities.TrainProfitType{},
 		&entities.TrainStation{},
 		&entities.StationType{},
-		// &entities.StationOrder{},
-		// &entities.StationOrderDetail{},
+		&entities.Train{},
+		&entities.StationOrder{},
+		&entities.StationOrderDetail{},
+		&entities.ServiceCalendar{},
+		&entities.ServiceCalendarException{},
+		&entities.TrainRun{},
+		&entities.Coach{},
+		&entities.Seat{},
+		&entities.Booking{},
+		&entities.Ticket{},
+		&entities.LegInventory{},
+		&entities.InventoryFlush{},
+		&entities.SeatReservation{},
+		&entities.SaleWindow{},
+		&entities.BookingHistory{},
+		&entities.Passenger{},
+		&entities.ConcessionRule{},
+		&entities.Promotion{},
+		&entities.PromotionRedemption{},
+		&entities.RefundRule{},
+		&entities.Refund{},
+		&entities.RefundAttempt{},
+		&entities.Payment{},
+		&entities.PaymentWebhookEvent{},
+		&entities.ReconciliationReport{},
+		&entities.ReconciliationItem{},
 	)
 
 	if err != nil {
@@ -50,6 +79,30 @@ func (db *Database) Migrate() error {
 	return nil
 }
 
+// migrateStationCodes adds the code column to train stations created before stations had
+// one. Existing rows get a generated code such as "ST0012" so the column can be made
+// NOT NULL and unique by AutoMigrate.
+func (db *Database) migrateStationCodes() error {
+	migrator := db.DB.Migrator()
+	if !migrator.HasTable(&entities.TrainStation{}) || migrator.HasColumn(&entities.TrainStation{}, "code") {
+		return nil
+	}
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE train_stations ADD COLUMN code text").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("UPDATE train_stations SET code = 'ST' || LPAD(id::text, 4, '0') WHERE code IS NULL").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("ALTER TABLE train_stations ALTER COLUMN code SET NOT NULL").Error; err != nil {
+			return err
+		}
+		log.Println("Generated codes for existing train stations.")
+		return nil
+	})
+}
+
 // Close closes the database connection.
 func (db *Database) Close() error {
 	sqlDB, err := db.DB.DB()
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+)
+
+// maxIdempotencyKeyLength limits the Idempotency-Key header.
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyMiddleware honours the Idempotency-Key header. The first request with a key
+// runs as usual and its successful response is stored for the user, a retry with the same
+// key and body gets that response again with the Idempotent-Replayed header set. A key
+// reused with a different request is rejected. Requests without the header are not
+// affected. It must run after JWTMiddleware, as keys are scoped to the user.
+func IdempotencyMiddleware(store *services.IdempotencyService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get("Idempotency-Key")
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Idempotency-Key is too long",
+			})
+		}
+
+		userID, ok := c.Locals("user").(uint)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid or missing user ID",
+			})
+		}
+
+		fingerprint := requestFingerprint(c)
+		record, err := store.Begin(userID, key, fingerprint)
+		if err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "Failed to check the Idempotency-Key",
+			})
+		}
+
+		if record != nil {
+			if record.Fingerprint != fingerprint {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"error": "Idempotency-Key was already used for a different request",
+				})
+			}
+			if !record.Done {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "A request with this Idempotency-Key is still in progress",
+				})
+			}
+
+			c.Set("Idempotent-Replayed", "true")
+			if record.ContentType != "" {
+				c.Set(fiber.HeaderContentType, record.ContentType)
+			}
+			return c.Status(record.Status).Send(record.Body)
+		}
+
+		// Only successful responses are stored. After an error, e.g. seats taken or the
+		// waiting room turning the request away, the key is released for the client to try
+		// the request again.
+		err = c.Next()
+		status := c.Response().StatusCode()
+		if err != nil || status >= fiber.StatusBadRequest {
+			if releaseErr := store.Release(userID, key); releaseErr != nil {
+				log.Printf("Failed to release Idempotency-Key of user %d: %v", userID, releaseErr)
+			}
+			return err
+		}
+
+		if err := store.Complete(userID, key, services.IdempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        append([]byte(nil), c.Response().Body()...),
+		}); err != nil {
+			log.Printf("Failed to store the response for Idempotency-Key of user %d: %v", userID, err)
+		}
+		return nil
+	}
+}
+
+// requestFingerprint identifies a request by its method, URL and body.
+func requestFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method() + " " + c.OriginalURL() + "\n"))
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
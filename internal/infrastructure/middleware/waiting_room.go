@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+)
+
+// WaitingRoomMiddleware lets requests through while a sale window is active only with an
+// admission token from the waiting room in the X-Admission-Token header. It must run after
+// JWTMiddleware, as the token is checked against the user.
+func WaitingRoomMiddleware(room *services.WaitingRoomService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		window, err := room.ActiveWindow()
+		if err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "Failed to check the ticket sale",
+			})
+		}
+		if window == nil {
+			return c.Next()
+		}
+
+		userID, _ := c.Locals("user").(uint)
+		if err := room.ValidateAdmission(c.Get("X-Admission-Token"), *window, userID); err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":          "A ticket sale is in progress, join the waiting room to book",
+				"reason":         err.Error(),
+				"sale_window_id": window.ID,
+			})
+		}
+
+		return c.Next()
+	}
+}
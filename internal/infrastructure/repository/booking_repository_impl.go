@@ -0,0 +1,402 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func NewBookingRepository(db *gorm.DB) interfaces.BookingRepository {
+	return &bookingRepositoryImpl{db: db}
+}
+
+type bookingRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// CreateBooking implements interfaces.BookingRepository.
+// Only inserts happen here, the seats were taken from the Redis seat counters already and
+// the legs catch up through ApplyLegDeltas, so concurrent bookings do not queue on leg rows.
+func (b *bookingRepositoryImpl) CreateBooking(booking entities.Booking) (entities.Booking, error) {
+	tx := b.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.Booking{}, err
+	}
+
+	// A share lock keeps the run's status from changing until the booking is committed
+	var run entities.TrainRun
+	if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("train_code = ? AND service_date = ?", booking.TrainCode, booking.ServiceDate).
+		First(&run).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return entities.Booking{}, interfaces.ErrTrainRunNotFound
+		}
+		return entities.Booking{}, fmt.Errorf("failed to lock run of train %s: %w", booking.TrainCode, err)
+	}
+	if !run.Bookable() {
+		tx.Rollback()
+		return entities.Booking{}, fmt.Errorf("%w: train %s on %s is %s", interfaces.ErrTrainRunNotBookable,
+			run.TrainCode, run.ServiceDate.Format(time.DateOnly), run.Status)
+	}
+
+	booking.TrainRunID = run.ID
+
+	if err := tx.Create(&booking).Error; err != nil {
+		tx.Rollback()
+		return entities.Booking{}, err
+	}
+
+	var reservations []entities.SeatReservation
+	for _, ticket := range booking.Tickets {
+		if ticket.SeatID == nil {
+			continue
+		}
+		reservations = append(reservations, entities.SeatReservation{
+			TrainRunID: run.ID,
+			SeatID:     *ticket.SeatID,
+			TicketID:   ticket.ID,
+			FromOrder:  booking.FromOrder,
+			ToOrder:    booking.ToOrder,
+		})
+	}
+	if len(reservations) > 0 {
+		if err := tx.Create(&reservations).Error; err != nil {
+			tx.Rollback()
+			return entities.Booking{}, fmt.Errorf("failed to reserve seats on train %s: %w", booking.TrainCode, err)
+		}
+	}
+
+	if booking.Status == entities.BookingStatusConfirmed && booking.PromotionCode != "" {
+		if err := redeemPromotion(tx, booking); err != nil {
+			tx.Rollback()
+			return entities.Booking{}, err
+		}
+	}
+
+	modifyBy := booking.ModifyBy
+	if err := tx.Create(&entities.BookingHistory{
+		BookingID: booking.ID,
+		ToStatus:  booking.Status,
+		ModifyBy:  &modifyBy,
+	}).Error; err != nil {
+		tx.Rollback()
+		return entities.Booking{}, fmt.Errorf("failed to record history of booking %s: %w", booking.Reference, err)
+	}
+
+	return booking, tx.Commit().Error
+}
+
+// GetBookingByID implements interfaces.BookingRepository.
+func (b *bookingRepositoryImpl) GetBookingByID(id uint) (entities.Booking, error) {
+	var booking entities.Booking
+	if err := b.db.Preload("Tickets.Passenger").Where("id = ?", id).First(&booking).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.Booking{}, interfaces.ErrBookingNotFound
+		}
+		return entities.Booking{}, fmt.Errorf("failed to fetch booking with id %d: %w", id, err)
+	}
+	return booking, nil
+}
+
+// GetBookingsByUser implements interfaces.BookingRepository.
+func (b *bookingRepositoryImpl) GetBookingsByUser(userID uint, offset int, limit int) ([]entities.Booking, int64, error) {
+	var bookings []entities.Booking
+	var total int64
+
+	// Count total bookings of the user
+	if err := b.db.Model(&entities.Booking{}).
+		Where("user_id = ?", userID).
+		Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count bookings of user %d: %w", userID, err)
+	}
+
+	// Fetch paginated bookings, newest first
+	err := b.db.Preload("Tickets.Passenger").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&bookings).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch bookings of user %d: %w", userID, err)
+	}
+
+	return bookings, total, nil
+}
+
+// TransitionBooking implements interfaces.BookingRepository.
+// The booking row lock orders concurrent changes, e.g. a confirmation against the hold
+// sweeper, so only one of them moves the booking out of its status.
+func (b *bookingRepositoryImpl) TransitionBooking(change entities.BookingHistory, releaseSeats bool, refund *entities.Refund) (entities.Booking, error) {
+	tx := b.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.Booking{}, err
+	}
+
+	booking, err := lockBooking(tx, change.BookingID)
+	if err != nil {
+		tx.Rollback()
+		return entities.Booking{}, err
+	}
+
+	if booking.Status != change.FromStatus {
+		tx.Rollback()
+		return entities.Booking{}, fmt.Errorf("%w: booking %s is %s, not %s", interfaces.ErrInvalidBookingTransition,
+			booking.Reference, booking.Status, change.FromStatus)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status": change.ToStatus,
+	}
+	if change.ModifyBy != nil {
+		updates["modify_by"] = *change.ModifyBy
+	}
+	switch change.ToStatus {
+	case entities.BookingStatusPendingPayment, entities.BookingStatusConfirmed:
+		if booking.Holding() && booking.HoldExpiresAt != nil && !now.Before(*booking.HoldExpiresAt) {
+			tx.Rollback()
+			return entities.Booking{}, interfaces.ErrHoldExpired
+		}
+		if change.ToStatus == entities.BookingStatusConfirmed {
+			updates["hold_expires_at"] = nil
+			if booking.PromotionCode != "" {
+				if err := redeemPromotion(tx, booking); err != nil {
+					tx.Rollback()
+					return entities.Booking{}, err
+				}
+			}
+		}
+	case entities.BookingStatusCancelled:
+		updates["cancelled_at"] = now
+	}
+
+	if releaseSeats {
+		err = releaseBooking(tx, booking, updates)
+	} else if err = tx.Model(&booking).Updates(updates).Error; err != nil {
+		err = fmt.Errorf("failed to update booking %s: %w", booking.Reference, err)
+	}
+	if err != nil {
+		tx.Rollback()
+		return entities.Booking{}, err
+	}
+
+	ticketUpdates := map[string]interface{}{
+		"status": change.ToStatus,
+	}
+	if change.ModifyBy != nil {
+		ticketUpdates["modify_by"] = *change.ModifyBy
+	}
+	if err := tx.Model(&entities.Ticket{}).
+		Where("booking_id = ?", booking.ID).
+		Updates(ticketUpdates).Error; err != nil {
+		tx.Rollback()
+		return entities.Booking{}, fmt.Errorf("failed to update tickets of booking %s: %w", booking.Reference, err)
+	}
+
+	if err := tx.Create(&change).Error; err != nil {
+		tx.Rollback()
+		return entities.Booking{}, fmt.Errorf("failed to record history of booking %s: %w", booking.Reference, err)
+	}
+
+	if refund != nil {
+		refund.BookingID = booking.ID
+		if err := tx.Create(refund).Error; err != nil {
+			tx.Rollback()
+			return entities.Booking{}, fmt.Errorf("failed to create refund of booking %s: %w", booking.Reference, err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return entities.Booking{}, err
+	}
+
+	return b.GetBookingByID(booking.ID)
+}
+
+// GetBookingHistory implements interfaces.BookingRepository.
+func (b *bookingRepositoryImpl) GetBookingHistory(bookingID uint) ([]entities.BookingHistory, error) {
+	var history []entities.BookingHistory
+	if err := b.db.Preload("User").
+		Where("booking_id = ?", bookingID).
+		Order("created_at ASC, id ASC").
+		Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch history of booking %d: %w", bookingID, err)
+	}
+	return history, nil
+}
+
+// GetExpiredHolds implements interfaces.BookingRepository.
+func (b *bookingRepositoryImpl) GetExpiredHolds(now time.Time, limit int) ([]entities.Booking, error) {
+	var bookings []entities.Booking
+	if err := b.db.Where("status IN ? AND hold_expires_at <= ?",
+		[]string{entities.BookingStatusHeld, entities.BookingStatusPendingPayment}, now).
+		Order("hold_expires_at ASC").
+		Limit(limit).
+		Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch expired holds: %w", err)
+	}
+	return bookings, nil
+}
+
+// GetLegInventory implements interfaces.BookingRepository.
+func (b *bookingRepositoryImpl) GetLegInventory(trainRunID uint) ([]entities.LegInventory, error) {
+	var legs []entities.LegInventory
+	if err := b.db.Where("train_run_id = ?", trainRunID).Order("from_order ASC").Find(&legs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch legs of run %d: %w", trainRunID, err)
+	}
+	return legs, nil
+}
+
+// GetSeatReservations implements interfaces.BookingRepository.
+func (b *bookingRepositoryImpl) GetSeatReservations(trainRunID uint) ([]entities.SeatReservation, error) {
+	var reservations []entities.SeatReservation
+	if err := b.db.Where("train_run_id = ?", trainRunID).Find(&reservations).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch seat reservations of run %d: %w", trainRunID, err)
+	}
+	return reservations, nil
+}
+
+// ApplyLegDeltas implements interfaces.BookingRepository.
+// The flush is recorded in the same transaction, so a retried flush is applied only once.
+func (b *bookingRepositoryImpl) ApplyLegDeltas(trainRunID uint, flushID string, deltas map[int]int) error {
+	tx := b.db.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entities.InventoryFlush{FlushID: flushID, TrainRunID: trainRunID})
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record flush %s: %w", flushID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return nil
+	}
+
+	for fromOrder, delta := range deltas {
+		if delta == 0 {
+			continue
+		}
+		if err := tx.Model(&entities.LegInventory{}).
+			Where("train_run_id = ? AND from_order = ?", trainRunID, fromOrder).
+			Update("available_seats", gorm.Expr("available_seats + ?", delta)).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to update leg %d of run %d: %w", fromOrder, trainRunID, err)
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// GetStationOrder implements interfaces.BookingRepository.
+func (b *bookingRepositoryImpl) GetStationOrder(trainCode string) (entities.StationOrder, error) {
+	var stationOrder entities.StationOrder
+	err := b.db.Preload("Stations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("\"order\" ASC")
+	}).Where("train_code = ?", trainCode).First(&stationOrder).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.StationOrder{}, fmt.Errorf("route of train %s not found", trainCode)
+		}
+		return entities.StationOrder{}, fmt.Errorf("failed to fetch route of train %s: %w", trainCode, err)
+	}
+	return stationOrder, nil
+}
+
+// GetAvailableSeats implements interfaces.BookingRepository.
+// It returns the smallest number of free seats over the legs between the two stops, which
+// is how many passengers can still travel from fromOrder to toOrder.
+func (b *bookingRepositoryImpl) GetAvailableSeats(trainCode string, serviceDate time.Time, fromOrder int, toOrder int) (int, error) {
+	var run entities.TrainRun
+	if err := b.db.Where("train_code = ? AND service_date = ?", trainCode, serviceDate).First(&run).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, interfaces.ErrTrainRunNotFound
+		}
+		return 0, fmt.Errorf("failed to fetch run of train %s: %w", trainCode, err)
+	}
+	if !run.Bookable() {
+		return 0, nil
+	}
+
+	var available int
+	if err := b.db.Model(&entities.LegInventory{}).
+		Select("COALESCE(MIN(available_seats), 0)").
+		Where("train_run_id = ? AND from_order >= ? AND to_order <= ?", run.ID, fromOrder, toOrder).
+		Scan(&available).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch available seats of train %s: %w", trainCode, err)
+	}
+
+	return available, nil
+}
+
+// lockLegs locks the legs between fromOrder and toOrder in route order, so two
+// transactions always acquire their locks in the same order and cannot deadlock.
+func lockLegs(tx *gorm.DB, trainRunID uint, fromOrder, toOrder int) ([]entities.LegInventory, error) {
+	var legs []entities.LegInventory
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("train_run_id = ? AND from_order >= ? AND to_order <= ?", trainRunID, fromOrder, toOrder).
+		Order("from_order ASC").
+		Find(&legs).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock legs of run %d: %w", trainRunID, err)
+	}
+
+	if len(legs) == 0 || legs[0].FromOrder != fromOrder || legs[len(legs)-1].ToOrder != toOrder {
+		return nil, fmt.Errorf("run %d has no legs between stop %d and stop %d", trainRunID, fromOrder, toOrder)
+	}
+	return legs, nil
+}
+
+// updateLegSeats adds delta to the available seats of every given leg.
+func updateLegSeats(tx *gorm.DB, legs []entities.LegInventory, delta int) error {
+	ids := make([]uint, len(legs))
+	for i, leg := range legs {
+		ids[i] = leg.ID
+	}
+	return tx.Model(&entities.LegInventory{}).
+		Where("id IN ?", ids).
+		Update("available_seats", gorm.Expr("available_seats + ?", delta)).Error
+}
+
+// lockBooking loads the booking with SELECT ... FOR UPDATE.
+func lockBooking(tx *gorm.DB, id uint) (entities.Booking, error) {
+	var booking entities.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&booking).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.Booking{}, interfaces.ErrBookingNotFound
+		}
+		return entities.Booking{}, fmt.Errorf("failed to lock booking with id %d: %w", id, err)
+	}
+	return booking, nil
+}
+
+// releaseBooking returns the seats of a locked booking to the legs it covered, frees the
+// seats its tickets held and applies updates to the booking.
+func releaseBooking(tx *gorm.DB, booking entities.Booking, updates map[string]interface{}) error {
+	legs, err := lockLegs(tx, booking.TrainRunID, booking.FromOrder, booking.ToOrder)
+	if err != nil {
+		return err
+	}
+
+	if err := updateLegSeats(tx, legs, booking.SeatCount); err != nil {
+		return fmt.Errorf("failed to release seats on train %s: %w", booking.TrainCode, err)
+	}
+
+	if err := tx.Where("ticket_id IN (?)", tx.Model(&entities.Ticket{}).Select("id").Where("booking_id = ?", booking.ID)).
+		Delete(&entities.SeatReservation{}).Error; err != nil {
+		return fmt.Errorf("failed to free seats of booking %s: %w", booking.Reference, err)
+	}
+
+	if err := tx.Model(&booking).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update booking %s: %w", booking.Reference, err)
+	}
+	return nil
+}
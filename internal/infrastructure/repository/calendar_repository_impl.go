@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func NewServiceCalendarRepository(db *gorm.DB) interfaces.ServiceCalendarRepository {
+	return &serviceCalendarRepositoryImpl{db: db}
+}
+
+type serviceCalendarRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// GetCalendar implements interfaces.ServiceCalendarRepository.
+func (s *serviceCalendarRepositoryImpl) GetCalendar(trainCode string) (entities.ServiceCalendar, error) {
+	var calendar entities.ServiceCalendar
+	err := s.db.Preload("Exceptions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("date ASC")
+	}).Where("train_code = ?", trainCode).First(&calendar).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.ServiceCalendar{}, interfaces.ErrCalendarNotFound
+		}
+		return entities.ServiceCalendar{}, fmt.Errorf("failed to fetch calendar of train %s: %w", trainCode, err)
+	}
+	return calendar, nil
+}
+
+// GetCalendars implements interfaces.ServiceCalendarRepository.
+func (s *serviceCalendarRepositoryImpl) GetCalendars() ([]entities.ServiceCalendar, error) {
+	var calendars []entities.ServiceCalendar
+	if err := s.db.Preload("Exceptions").Find(&calendars).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch calendars: %w", err)
+	}
+	return calendars, nil
+}
+
+// SaveCalendar implements interfaces.ServiceCalendarRepository.
+func (s *serviceCalendarRepositoryImpl) SaveCalendar(calendar entities.ServiceCalendar) (entities.ServiceCalendar, error) {
+	tx := s.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.ServiceCalendar{}, err
+	}
+
+	var existing entities.ServiceCalendar
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("train_code = ?", calendar.TrainCode).
+		First(&existing).Error
+
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		if err := tx.Create(&calendar).Error; err != nil {
+			tx.Rollback()
+			return entities.ServiceCalendar{}, fmt.Errorf("failed to create calendar of train %s: %w", calendar.TrainCode, err)
+		}
+	case err != nil:
+		tx.Rollback()
+		return entities.ServiceCalendar{}, fmt.Errorf("failed to fetch calendar of train %s: %w", calendar.TrainCode, err)
+	default:
+		// Select every field so days switched off (false) are written as well
+		if err := tx.Model(&existing).
+			Select("monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday",
+				"start_date", "end_date", "modify_by").
+			Updates(calendar).Error; err != nil {
+			tx.Rollback()
+			return entities.ServiceCalendar{}, fmt.Errorf("failed to update calendar of train %s: %w", calendar.TrainCode, err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return entities.ServiceCalendar{}, err
+	}
+
+	return s.GetCalendar(calendar.TrainCode)
+}
+
+// SaveException implements interfaces.ServiceCalendarRepository.
+func (s *serviceCalendarRepositoryImpl) SaveException(trainCode string, exception entities.ServiceCalendarException) (entities.ServiceCalendar, error) {
+	calendar, err := s.GetCalendar(trainCode)
+	if err != nil {
+		return entities.ServiceCalendar{}, err
+	}
+
+	exception.ID = 0
+	exception.ServiceCalendarID = calendar.ID
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "service_calendar_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"type", "note", "modify_by", "updated_at"}),
+	}).Create(&exception).Error; err != nil {
+		return entities.ServiceCalendar{}, fmt.Errorf("failed to save exception of train %s: %w", trainCode, err)
+	}
+
+	return s.GetCalendar(trainCode)
+}
+
+// DeleteException implements interfaces.ServiceCalendarRepository.
+func (s *serviceCalendarRepositoryImpl) DeleteException(trainCode string, date time.Time) error {
+	calendar, err := s.GetCalendar(trainCode)
+	if err != nil {
+		return err
+	}
+
+	result := s.db.Where("service_calendar_id = ? AND date = ?", calendar.ID, date).
+		Delete(&entities.ServiceCalendarException{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete exception of train %s: %w", trainCode, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("train %s has no exception on %s", trainCode, date.Format(time.DateOnly))
+	}
+	return nil
+}
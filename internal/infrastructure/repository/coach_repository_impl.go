@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func NewCoachRepository(db *gorm.DB) interfaces.CoachRepository {
+	return &coachRepositoryImpl{db: db}
+}
+
+type coachRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// GetCoaches implements interfaces.CoachRepository.
+func (c *coachRepositoryImpl) GetCoaches(trainCode string) ([]entities.Coach, error) {
+	var coaches []entities.Coach
+	err := c.db.Preload("Seats", func(db *gorm.DB) *gorm.DB {
+		return db.Order("\"row\" ASC, \"column\" ASC")
+	}).Where("train_code = ?", trainCode).Order("number ASC").Find(&coaches).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch coaches of train %s: %w", trainCode, err)
+	}
+	return coaches, nil
+}
+
+// ReplaceCoaches implements interfaces.CoachRepository.
+// Old coaches and seats are soft-deleted, so tickets sold on past runs still point at them.
+func (c *coachRepositoryImpl) ReplaceCoaches(trainCode string, coaches []entities.Coach, modifyBy uint) ([]entities.Coach, error) {
+	tx := c.db.Begin()
+	if err := tx.Error; err != nil {
+		return nil, err
+	}
+
+	if err := lockTrain(tx, trainCode); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	today, _ := time.Parse(time.DateOnly, time.Now().Format(time.DateOnly))
+	var runs []entities.TrainRun
+	if err := tx.Where("train_code = ? AND service_date >= ?", trainCode, today).Find(&runs).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to fetch runs of train %s: %w", trainCode, err)
+	}
+
+	runIDs := make([]uint, len(runs))
+	for i, run := range runs {
+		runIDs[i] = run.ID
+	}
+
+	seats := 0
+	for _, coach := range coaches {
+		seats += len(coach.Seats)
+	}
+
+	if len(runIDs) > 0 {
+		var legs []entities.LegInventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("train_run_id IN ?", runIDs).
+			Find(&legs).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to lock legs of train %s: %w", trainCode, err)
+		}
+		for _, leg := range legs {
+			if leg.AvailableSeats < leg.Seats {
+				tx.Rollback()
+				return nil, fmt.Errorf("coaches of train %s cannot be changed while seats are sold on upcoming runs", trainCode)
+			}
+		}
+
+		if err := tx.Model(&entities.TrainRun{}).Where("id IN ?", runIDs).Update("seats", seats).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to resize runs of train %s: %w", trainCode, err)
+		}
+		if err := tx.Model(&entities.LegInventory{}).Where("train_run_id IN ?", runIDs).
+			Updates(map[string]interface{}{"seats": seats, "available_seats": seats}).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to resize legs of train %s: %w", trainCode, err)
+		}
+	}
+
+	if err := tx.Where("coach_id IN (?)", tx.Model(&entities.Coach{}).Select("id").Where("train_code = ?", trainCode)).
+		Delete(&entities.Seat{}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to remove seats of train %s: %w", trainCode, err)
+	}
+	if err := tx.Where("train_code = ?", trainCode).Delete(&entities.Coach{}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to remove coaches of train %s: %w", trainCode, err)
+	}
+
+	for i := range coaches {
+		coaches[i].ID = 0
+		coaches[i].TrainCode = trainCode
+		coaches[i].ModifyBy = modifyBy
+		for j := range coaches[i].Seats {
+			coaches[i].Seats[j].ID = 0
+		}
+	}
+	if len(coaches) > 0 {
+		if err := tx.Create(&coaches).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to create coaches of train %s: %w", trainCode, err)
+		}
+	}
+
+	if err := tx.Model(&entities.Train{}).Where("code = ?", trainCode).Updates(map[string]interface{}{
+		"seats":     seats,
+		"modify_by": modifyBy,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to update seats of train %s: %w", trainCode, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return c.GetCoaches(trainCode)
+}
+
+// GetReservedSeatIDs implements interfaces.CoachRepository.
+func (c *coachRepositoryImpl) GetReservedSeatIDs(trainRunID uint, fromOrder, toOrder int) ([]uint, error) {
+	var seatIDs []uint
+	if err := c.db.Model(&entities.SeatReservation{}).
+		Where("train_run_id = ? AND from_order < ? AND to_order > ?", trainRunID, toOrder, fromOrder).
+		Distinct().
+		Pluck("seat_id", &seatIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch reserved seats of run %d: %w", trainRunID, err)
+	}
+	return seatIDs, nil
+}
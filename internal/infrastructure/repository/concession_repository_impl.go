@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+)
+
+func NewConcessionRepository(db *gorm.DB) interfaces.ConcessionRepository {
+	return &concessionRepositoryImpl{db: db}
+}
+
+type concessionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// CreateRule implements interfaces.ConcessionRepository.
+func (r *concessionRepositoryImpl) CreateRule(rule entities.ConcessionRule) (entities.ConcessionRule, error) {
+	tx := r.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.ConcessionRule{}, err
+	}
+
+	if err := tx.Where("code = ?", rule.Code).First(&entities.ConcessionRule{}).Error; err == nil {
+		tx.Rollback()
+		return entities.ConcessionRule{}, fmt.Errorf("concession code %s already exists", rule.Code)
+	}
+
+	if err := tx.Create(&rule).Error; err != nil {
+		tx.Rollback()
+		return entities.ConcessionRule{}, err
+	}
+
+	return rule, tx.Commit().Error
+}
+
+// UpdateRule implements interfaces.ConcessionRepository.
+// Every condition is written, so a condition can be turned off by setting it to zero.
+func (r *concessionRepositoryImpl) UpdateRule(rule entities.ConcessionRule) (entities.ConcessionRule, error) {
+	existing, err := r.GetRuleByCode(rule.Code)
+	if err != nil {
+		return entities.ConcessionRule{}, err
+	}
+
+	if err := r.db.Model(&existing).
+		Select("name", "category", "discount_percent", "min_age", "max_age", "max_height_cm", "thai_only", "proof", "active", "modify_by").
+		Updates(rule).Error; err != nil {
+		return entities.ConcessionRule{}, fmt.Errorf("failed to update concession with code %s: %w", rule.Code, err)
+	}
+
+	return r.GetRuleByCode(rule.Code)
+}
+
+// DeleteRule implements interfaces.ConcessionRepository.
+// Tickets keep the code and proof of the concession they were sold with.
+func (r *concessionRepositoryImpl) DeleteRule(code string) error {
+	result := r.db.Where("code = ?", code).Delete(&entities.ConcessionRule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete concession with code %s: %w", code, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return interfaces.ErrConcessionNotFound
+	}
+	return nil
+}
+
+// GetRules implements interfaces.ConcessionRepository.
+func (r *concessionRepositoryImpl) GetRules() ([]entities.ConcessionRule, error) {
+	var rules []entities.ConcessionRule
+	if err := r.db.Order("category ASC, code ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch concessions: %w", err)
+	}
+	return rules, nil
+}
+
+// GetRuleByCode implements interfaces.ConcessionRepository.
+func (r *concessionRepositoryImpl) GetRuleByCode(code string) (entities.ConcessionRule, error) {
+	var rule entities.ConcessionRule
+	if err := r.db.Where("code = ?", code).First(&rule).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.ConcessionRule{}, interfaces.ErrConcessionNotFound
+		}
+		return entities.ConcessionRule{}, fmt.Errorf("failed to fetch concession with code %s: %w", code, err)
+	}
+	return rule, nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+)
+
+func NewPassengerRepository(db *gorm.DB) interfaces.PassengerRepository {
+	return &passengerRepositoryImpl{db: db}
+}
+
+type passengerRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// GetPassenger implements interfaces.PassengerRepository.
+func (p *passengerRepositoryImpl) GetPassenger(id uint) (entities.Passenger, error) {
+	var passenger entities.Passenger
+	if err := p.db.Where("id = ?", id).First(&passenger).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.Passenger{}, interfaces.ErrPassengerNotFound
+		}
+		return entities.Passenger{}, fmt.Errorf("failed to fetch passenger with id %d: %w", id, err)
+	}
+	return passenger, nil
+}
+
+// GetSavedPassengers implements interfaces.PassengerRepository.
+func (p *passengerRepositoryImpl) GetSavedPassengers(userID uint) ([]entities.Passenger, error) {
+	var passengers []entities.Passenger
+	if err := p.db.Where("user_id = ? AND saved = ?", userID, true).
+		Order("created_at ASC").
+		Find(&passengers).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch passengers of user %d: %w", userID, err)
+	}
+	return passengers, nil
+}
+
+// CreatePassenger implements interfaces.PassengerRepository.
+func (p *passengerRepositoryImpl) CreatePassenger(passenger entities.Passenger) (entities.Passenger, error) {
+	if err := p.db.Create(&passenger).Error; err != nil {
+		return entities.Passenger{}, fmt.Errorf("failed to create passenger: %w", err)
+	}
+	return passenger, nil
+}
+
+// UpdatePassenger implements interfaces.PassengerRepository.
+func (p *passengerRepositoryImpl) UpdatePassenger(passenger entities.Passenger) (entities.Passenger, error) {
+	if err := p.db.Save(&passenger).Error; err != nil {
+		return entities.Passenger{}, fmt.Errorf("failed to update passenger with id %d: %w", passenger.ID, err)
+	}
+	return passenger, nil
+}
+
+// DeletePassenger implements interfaces.PassengerRepository.
+func (p *passengerRepositoryImpl) DeletePassenger(id uint) error {
+	result := p.db.Delete(&entities.Passenger{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete passenger with id %d: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return interfaces.ErrPassengerNotFound
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func NewPaymentRepository(db *gorm.DB) interfaces.PaymentRepository {
+	return &paymentRepositoryImpl{db: db}
+}
+
+type paymentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// CreatePayment implements interfaces.PaymentRepository.
+func (r *paymentRepositoryImpl) CreatePayment(payment entities.Payment) (entities.Payment, error) {
+	if err := r.db.Create(&payment).Error; err != nil {
+		return entities.Payment{}, fmt.Errorf("failed to create payment: %w", err)
+	}
+	return payment, nil
+}
+
+// GetPaymentByID implements interfaces.PaymentRepository.
+func (r *paymentRepositoryImpl) GetPaymentByID(id uint) (entities.Payment, error) {
+	var payment entities.Payment
+	if err := r.db.Where("id = ?", id).First(&payment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.Payment{}, interfaces.ErrPaymentNotFound
+		}
+		return entities.Payment{}, fmt.Errorf("failed to fetch payment with id %d: %w", id, err)
+	}
+	return payment, nil
+}
+
+// GetPaymentByProviderRef implements interfaces.PaymentRepository.
+func (r *paymentRepositoryImpl) GetPaymentByProviderRef(providerRef string) (entities.Payment, error) {
+	var payment entities.Payment
+	if err := r.db.Where("provider_ref = ?", providerRef).First(&payment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.Payment{}, interfaces.ErrPaymentNotFound
+		}
+		return entities.Payment{}, fmt.Errorf("failed to fetch payment %s: %w", providerRef, err)
+	}
+	return payment, nil
+}
+
+// GetPaymentsByBooking implements interfaces.PaymentRepository.
+func (r *paymentRepositoryImpl) GetPaymentsByBooking(bookingID uint) ([]entities.Payment, error) {
+	var payments []entities.Payment
+	if err := r.db.Where("booking_id = ?", bookingID).Order("created_at ASC").Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch payments of booking %d: %w", bookingID, err)
+	}
+	return payments, nil
+}
+
+// GetPaymentsByProviderRefs implements interfaces.PaymentRepository.
+func (r *paymentRepositoryImpl) GetPaymentsByProviderRefs(providerRefs []string) ([]entities.Payment, error) {
+	var payments []entities.Payment
+	if len(providerRefs) == 0 {
+		return payments, nil
+	}
+	if err := r.db.Preload("Booking").Where("provider_ref IN ?", providerRefs).Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch payments: %w", err)
+	}
+	return payments, nil
+}
+
+// GetSucceededPayments implements interfaces.PaymentRepository.
+func (r *paymentRepositoryImpl) GetSucceededPayments(provider string, from, to time.Time) ([]entities.Payment, error) {
+	var payments []entities.Payment
+	if err := r.db.Preload("Booking").
+		Where("provider = ? AND status = ? AND paid_at >= ? AND paid_at < ?", provider, entities.PaymentStatusSucceeded, from, to).
+		Order("paid_at ASC").
+		Find(&payments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch %s payments: %w", provider, err)
+	}
+	return payments, nil
+}
+
+// UpdatePaymentStatus implements interfaces.PaymentRepository.
+func (r *paymentRepositoryImpl) UpdatePaymentStatus(id uint, fromStatus, toStatus string, paidAt *time.Time) (entities.Payment, error) {
+	result := r.db.Model(&entities.Payment{}).
+		Where("id = ? AND status = ?", id, fromStatus).
+		Updates(map[string]interface{}{"status": toStatus, "paid_at": paidAt})
+	if result.Error != nil {
+		return entities.Payment{}, fmt.Errorf("failed to update payment with id %d: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetPaymentByID(id); err != nil {
+			return entities.Payment{}, err
+		}
+		return entities.Payment{}, interfaces.ErrPaymentStatusChanged
+	}
+	return r.GetPaymentByID(id)
+}
+
+// CreateWebhookEvent implements interfaces.PaymentRepository.
+func (r *paymentRepositoryImpl) CreateWebhookEvent(event entities.PaymentWebhookEvent) (entities.PaymentWebhookEvent, bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+	if result.Error != nil {
+		return entities.PaymentWebhookEvent{}, false, fmt.Errorf("failed to store %s event %s: %w", event.Provider, event.EventID, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return event, true, nil
+	}
+
+	var existing entities.PaymentWebhookEvent
+	if err := r.db.Where("provider = ? AND event_id = ?", event.Provider, event.EventID).First(&existing).Error; err != nil {
+		return entities.PaymentWebhookEvent{}, false, fmt.Errorf("failed to fetch %s event %s: %w", event.Provider, event.EventID, err)
+	}
+	return existing, false, nil
+}
+
+// UpdateWebhookEvent implements interfaces.PaymentRepository.
+func (r *paymentRepositoryImpl) UpdateWebhookEvent(event entities.PaymentWebhookEvent) (entities.PaymentWebhookEvent, error) {
+	result := r.db.Model(&entities.PaymentWebhookEvent{ID: event.ID}).
+		Select("state", "error", "attempts", "processed_at", "modify_by").
+		Updates(event)
+	if result.Error != nil {
+		return entities.PaymentWebhookEvent{}, fmt.Errorf("failed to update payment event with id %d: %w", event.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return entities.PaymentWebhookEvent{}, interfaces.ErrPaymentEventNotFound
+	}
+	return r.GetWebhookEvent(event.ID)
+}
+
+// GetWebhookEvent implements interfaces.PaymentRepository.
+func (r *paymentRepositoryImpl) GetWebhookEvent(id uint) (entities.PaymentWebhookEvent, error) {
+	var event entities.PaymentWebhookEvent
+	if err := r.db.Where("id = ?", id).First(&event).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.PaymentWebhookEvent{}, interfaces.ErrPaymentEventNotFound
+		}
+		return entities.PaymentWebhookEvent{}, fmt.Errorf("failed to fetch payment event with id %d: %w", id, err)
+	}
+	return event, nil
+}
+
+// GetWebhookEvents implements interfaces.PaymentRepository.
+func (r *paymentRepositoryImpl) GetWebhookEvents(state string, offset, limit int) ([]entities.PaymentWebhookEvent, int64, error) {
+	inState := func(db *gorm.DB) *gorm.DB {
+		if state != "" {
+			return db.Where("state = ?", state)
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.Model(&entities.PaymentWebhookEvent{}).Scopes(inState).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count payment events: %w", err)
+	}
+
+	var events []entities.PaymentWebhookEvent
+	if err := r.db.Scopes(inState).Order("created_at DESC").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch payment events: %w", err)
+	}
+	return events, total, nil
+}
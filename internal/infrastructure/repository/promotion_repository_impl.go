@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func NewPromotionRepository(db *gorm.DB) interfaces.PromotionRepository {
+	return &promotionRepositoryImpl{db: db}
+}
+
+type promotionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// CreatePromotion implements interfaces.PromotionRepository.
+func (p *promotionRepositoryImpl) CreatePromotion(promotion entities.Promotion) (entities.Promotion, error) {
+	tx := p.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.Promotion{}, err
+	}
+
+	if err := tx.Where("code = ?", promotion.Code).First(&entities.Promotion{}).Error; err == nil {
+		tx.Rollback()
+		return entities.Promotion{}, fmt.Errorf("promotion code %s already exists", promotion.Code)
+	}
+
+	promotion.RedeemedCount = 0
+	if err := tx.Create(&promotion).Error; err != nil {
+		tx.Rollback()
+		return entities.Promotion{}, err
+	}
+
+	return promotion, tx.Commit().Error
+}
+
+// UpdatePromotion implements interfaces.PromotionRepository.
+// Every condition is written, so a limit can be lifted by setting it to zero. The count of
+// redemptions is left alone.
+func (p *promotionRepositoryImpl) UpdatePromotion(promotion entities.Promotion) (entities.Promotion, error) {
+	existing, err := p.GetPromotionByCode(promotion.Code)
+	if err != nil {
+		return entities.Promotion{}, err
+	}
+
+	if err := p.db.Model(&existing).
+		Select("description", "discount_type", "discount_value", "max_discount", "train_line_code", "train_type_code",
+			"travel_from", "travel_to", "booking_from", "booking_to", "min_spend", "total_limit", "per_user_limit",
+			"active", "modify_by").
+		Updates(promotion).Error; err != nil {
+		return entities.Promotion{}, fmt.Errorf("failed to update promotion with code %s: %w", promotion.Code, err)
+	}
+
+	return p.GetPromotionByCode(promotion.Code)
+}
+
+// DeletePromotion implements interfaces.PromotionRepository.
+func (p *promotionRepositoryImpl) DeletePromotion(code string) error {
+	result := p.db.Where("code = ?", code).Delete(&entities.Promotion{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete promotion with code %s: %w", code, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return interfaces.ErrPromotionNotFound
+	}
+	return nil
+}
+
+// GetPromotions implements interfaces.PromotionRepository.
+func (p *promotionRepositoryImpl) GetPromotions() ([]entities.Promotion, error) {
+	var promotions []entities.Promotion
+	if err := p.db.Order("code ASC").Find(&promotions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch promotions: %w", err)
+	}
+	return promotions, nil
+}
+
+// GetPromotionByCode implements interfaces.PromotionRepository.
+func (p *promotionRepositoryImpl) GetPromotionByCode(code string) (entities.Promotion, error) {
+	var promotion entities.Promotion
+	if err := p.db.Where("code = ?", code).First(&promotion).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.Promotion{}, interfaces.ErrPromotionNotFound
+		}
+		return entities.Promotion{}, fmt.Errorf("failed to fetch promotion with code %s: %w", code, err)
+	}
+	return promotion, nil
+}
+
+// CountRedemptions implements interfaces.PromotionRepository.
+func (p *promotionRepositoryImpl) CountRedemptions(code string, userID uint) (int64, error) {
+	var count int64
+	if err := p.db.Model(&entities.PromotionRedemption{}).
+		Where("promotion_code = ? AND user_id = ?", code, userID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count redemptions of promotion %s: %w", code, err)
+	}
+	return count, nil
+}
+
+// redeemPromotion counts the promotion of a booking being confirmed in tx. The promotion
+// row lock makes concurrent confirmations take turns, so the caps checked here cannot be
+// overspent.
+func redeemPromotion(tx *gorm.DB, booking entities.Booking) error {
+	var promotion entities.Promotion
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", booking.PromotionCode).
+		First(&promotion).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return interfaces.ErrPromotionNotFound
+		}
+		return fmt.Errorf("failed to lock promotion %s: %w", booking.PromotionCode, err)
+	}
+
+	if promotion.TotalLimit > 0 && promotion.RedeemedCount >= promotion.TotalLimit {
+		return fmt.Errorf("%w: %s", interfaces.ErrPromotionExhausted, promotion.Code)
+	}
+	if promotion.PerUserLimit > 0 {
+		var used int64
+		if err := tx.Model(&entities.PromotionRedemption{}).
+			Where("promotion_code = ? AND user_id = ?", promotion.Code, booking.UserID).
+			Count(&used).Error; err != nil {
+			return fmt.Errorf("failed to count redemptions of promotion %s: %w", promotion.Code, err)
+		}
+		if used >= int64(promotion.PerUserLimit) {
+			return fmt.Errorf("%w: %s may be used %d times per user", interfaces.ErrPromotionExhausted,
+				promotion.Code, promotion.PerUserLimit)
+		}
+	}
+
+	if err := tx.Create(&entities.PromotionRedemption{
+		PromotionCode: promotion.Code,
+		BookingID:     booking.ID,
+		UserID:        booking.UserID,
+		Discount:      booking.Discount,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to redeem promotion %s: %w", promotion.Code, err)
+	}
+
+	if err := tx.Model(&promotion).
+		Update("redeemed_count", gorm.Expr("redeemed_count + 1")).Error; err != nil {
+		return fmt.Errorf("failed to count promotion %s: %w", promotion.Code, err)
+	}
+	return nil
+}
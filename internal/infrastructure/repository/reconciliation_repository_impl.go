@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+)
+
+func NewReconciliationRepository(db *gorm.DB) interfaces.ReconciliationRepository {
+	return &reconciliationRepositoryImpl{db: db}
+}
+
+type reconciliationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// CreateReport implements interfaces.ReconciliationRepository.
+func (r *reconciliationRepositoryImpl) CreateReport(report entities.ReconciliationReport) (entities.ReconciliationReport, error) {
+	// The items are inserted with the report in one transaction
+	if err := r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&report).Error
+	}); err != nil {
+		return entities.ReconciliationReport{}, fmt.Errorf("failed to create reconciliation report: %w", err)
+	}
+	return report, nil
+}
+
+// GetReport implements interfaces.ReconciliationRepository.
+func (r *reconciliationRepositoryImpl) GetReport(id uint) (entities.ReconciliationReport, error) {
+	var report entities.ReconciliationReport
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("id = ?", id).First(&report).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.ReconciliationReport{}, interfaces.ErrReconciliationReportNotFound
+		}
+		return entities.ReconciliationReport{}, fmt.Errorf("failed to fetch reconciliation report with id %d: %w", id, err)
+	}
+	return report, nil
+}
+
+// GetReports implements interfaces.ReconciliationRepository.
+func (r *reconciliationRepositoryImpl) GetReports(offset, limit int) ([]entities.ReconciliationReport, int64, error) {
+	var total int64
+	if err := r.db.Model(&entities.ReconciliationReport{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count reconciliation reports: %w", err)
+	}
+
+	var reports []entities.ReconciliationReport
+	if err := r.db.Order("created_at DESC").Offset(offset).Limit(limit).Find(&reports).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch reconciliation reports: %w", err)
+	}
+	return reports, total, nil
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+)
+
+func NewRefundRepository(db *gorm.DB) interfaces.RefundRepository {
+	return &refundRepositoryImpl{db: db}
+}
+
+type refundRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// CreateRule implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) CreateRule(rule entities.RefundRule) (entities.RefundRule, error) {
+	if err := r.db.Create(&rule).Error; err != nil {
+		return entities.RefundRule{}, fmt.Errorf("failed to create refund rule: %w", err)
+	}
+	return rule, nil
+}
+
+// UpdateRule implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) UpdateRule(rule entities.RefundRule) (entities.RefundRule, error) {
+	var existing entities.RefundRule
+	if err := r.db.Where("id = ?", rule.ID).First(&existing).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.RefundRule{}, interfaces.ErrRefundRuleNotFound
+		}
+		return entities.RefundRule{}, fmt.Errorf("failed to fetch refund rule with id %d: %w", rule.ID, err)
+	}
+
+	if err := r.db.Model(&existing).
+		Select("name", "train_type_code", "fare_type", "min_hours_before", "refund_percent", "fee_per_ticket", "active", "modify_by").
+		Updates(rule).Error; err != nil {
+		return entities.RefundRule{}, fmt.Errorf("failed to update refund rule with id %d: %w", rule.ID, err)
+	}
+
+	if err := r.db.Where("id = ?", rule.ID).First(&existing).Error; err != nil {
+		return entities.RefundRule{}, fmt.Errorf("failed to fetch refund rule with id %d: %w", rule.ID, err)
+	}
+	return existing, nil
+}
+
+// DeleteRule implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) DeleteRule(id uint) error {
+	result := r.db.Delete(&entities.RefundRule{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete refund rule with id %d: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return interfaces.ErrRefundRuleNotFound
+	}
+	return nil
+}
+
+// GetRules implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) GetRules() ([]entities.RefundRule, error) {
+	var rules []entities.RefundRule
+	if err := r.db.Order("train_type_code ASC, fare_type ASC, min_hours_before DESC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch refund rules: %w", err)
+	}
+	return rules, nil
+}
+
+// GetActiveRules implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) GetActiveRules() ([]entities.RefundRule, error) {
+	var rules []entities.RefundRule
+	if err := r.db.Where("active = ?", true).Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch refund rules: %w", err)
+	}
+	return rules, nil
+}
+
+// CreateRefund implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) CreateRefund(refund entities.Refund) (entities.Refund, error) {
+	if err := r.db.Create(&refund).Error; err != nil {
+		return entities.Refund{}, fmt.Errorf("failed to create refund for booking %d: %w", refund.BookingID, err)
+	}
+	return refund, nil
+}
+
+// GetRefundsByBooking implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) GetRefundsByBooking(bookingID uint) ([]entities.Refund, error) {
+	var refunds []entities.Refund
+	if err := r.db.Where("booking_id = ?", bookingID).Order("created_at ASC").Find(&refunds).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch refunds of booking %d: %w", bookingID, err)
+	}
+	return refunds, nil
+}
+
+// GetRefund implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) GetRefund(id uint) (entities.Refund, error) {
+	var refund entities.Refund
+	err := r.db.Preload("History", func(db *gorm.DB) *gorm.DB {
+		return db.Order("attempt ASC")
+	}).Where("id = ?", id).First(&refund).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.Refund{}, interfaces.ErrRefundNotFound
+		}
+		return entities.Refund{}, fmt.Errorf("failed to fetch refund with id %d: %w", id, err)
+	}
+	return refund, nil
+}
+
+// GetDueRefunds implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) GetDueRefunds(now time.Time, limit int) ([]entities.Refund, error) {
+	var refunds []entities.Refund
+	if err := r.db.Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", entities.RefundStatusPending, now).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&refunds).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch due refunds: %w", err)
+	}
+	return refunds, nil
+}
+
+// GetStuckRefunds implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) GetStuckRefunds(processingBefore time.Time) ([]entities.Refund, error) {
+	var refunds []entities.Refund
+	if err := r.db.Where("status = ? OR (status = ? AND updated_at < ?)",
+		entities.RefundStatusFailedManualReview, entities.RefundStatusProcessing, processingBefore).
+		Order("created_at ASC").
+		Find(&refunds).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch stuck refunds: %w", err)
+	}
+	return refunds, nil
+}
+
+// UpdateRefund implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) UpdateRefund(refund entities.Refund, fromStatus string) (entities.Refund, error) {
+	result := r.db.Model(&entities.Refund{}).
+		Where("id = ? AND status = ?", refund.ID, fromStatus).
+		Select("payment_id", "status", "attempts", "next_attempt_at", "last_error", "processed_at", "modify_by").
+		Updates(refund)
+	if result.Error != nil {
+		return entities.Refund{}, fmt.Errorf("failed to update refund with id %d: %w", refund.ID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetRefund(refund.ID); err != nil {
+			return entities.Refund{}, err
+		}
+		return entities.Refund{}, interfaces.ErrRefundStatusChanged
+	}
+	return r.GetRefund(refund.ID)
+}
+
+// CreateRefundAttempt implements interfaces.RefundRepository.
+func (r *refundRepositoryImpl) CreateRefundAttempt(attempt entities.RefundAttempt) (entities.RefundAttempt, error) {
+	if err := r.db.Create(&attempt).Error; err != nil {
+		return entities.RefundAttempt{}, fmt.Errorf("failed to record attempt %d of refund %d: %w", attempt.Attempt, attempt.RefundID, err)
+	}
+	return attempt, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+)
+
+func NewSaleWindowRepository(db *gorm.DB) interfaces.SaleWindowRepository {
+	return &saleWindowRepositoryImpl{db: db}
+}
+
+type saleWindowRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// GetWindow implements interfaces.SaleWindowRepository.
+func (s *saleWindowRepositoryImpl) GetWindow(id uint) (entities.SaleWindow, error) {
+	var window entities.SaleWindow
+	if err := s.db.Where("id = ?", id).First(&window).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.SaleWindow{}, interfaces.ErrSaleWindowNotFound
+		}
+		return entities.SaleWindow{}, fmt.Errorf("failed to fetch sale window with id %d: %w", id, err)
+	}
+	return window, nil
+}
+
+// GetWindows implements interfaces.SaleWindowRepository.
+func (s *saleWindowRepositoryImpl) GetWindows() ([]entities.SaleWindow, error) {
+	var windows []entities.SaleWindow
+	if err := s.db.Order("starts_at ASC").Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch sale windows: %w", err)
+	}
+	return windows, nil
+}
+
+// GetActiveWindow implements interfaces.SaleWindowRepository.
+func (s *saleWindowRepositoryImpl) GetActiveWindow(at time.Time) (entities.SaleWindow, error) {
+	var window entities.SaleWindow
+	if err := s.db.Where("starts_at <= ? AND ends_at > ?", at, at).First(&window).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.SaleWindow{}, interfaces.ErrSaleWindowNotFound
+		}
+		return entities.SaleWindow{}, fmt.Errorf("failed to fetch active sale window: %w", err)
+	}
+	return window, nil
+}
+
+// CreateWindow implements interfaces.SaleWindowRepository.
+func (s *saleWindowRepositoryImpl) CreateWindow(window entities.SaleWindow) (entities.SaleWindow, error) {
+	var overlapping int64
+	if err := s.db.Model(&entities.SaleWindow{}).
+		Where("starts_at < ? AND ends_at > ?", window.EndsAt, window.StartsAt).
+		Count(&overlapping).Error; err != nil {
+		return entities.SaleWindow{}, fmt.Errorf("failed to check sale windows: %w", err)
+	}
+	if overlapping > 0 {
+		return entities.SaleWindow{}, interfaces.ErrSaleWindowOverlap
+	}
+
+	if err := s.db.Create(&window).Error; err != nil {
+		return entities.SaleWindow{}, fmt.Errorf("failed to create sale window: %w", err)
+	}
+	return window, nil
+}
+
+// DeleteWindow implements interfaces.SaleWindowRepository.
+func (s *saleWindowRepositoryImpl) DeleteWindow(id uint) error {
+	result := s.db.Delete(&entities.SaleWindow{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete sale window with id %d: %w", id, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return interfaces.ErrSaleWindowNotFound
+	}
+	return nil
+}
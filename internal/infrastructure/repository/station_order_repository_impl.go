@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func NewStationOrderRepository(db *gorm.DB) interfaces.StationOrderRepository {
+	return &stationOrderRepositoryImpl{db: db}
+}
+
+type stationOrderRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// GetStationOrder implements interfaces.StationOrderRepository.
+func (s *stationOrderRepositoryImpl) GetStationOrder(trainCode string) (entities.StationOrder, error) {
+	var stationOrder entities.StationOrder
+	err := s.db.Preload("Stations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("\"order\" ASC")
+	}).Where("train_code = ?", trainCode).First(&stationOrder).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.StationOrder{}, fmt.Errorf("route of train %s not found", trainCode)
+		}
+		return entities.StationOrder{}, fmt.Errorf("failed to fetch route of train %s: %w", trainCode, err)
+	}
+	return stationOrder, nil
+}
+
+// GetStationOrders implements interfaces.StationOrderRepository.
+func (s *stationOrderRepositoryImpl) GetStationOrders() ([]entities.StationOrder, error) {
+	var stationOrders []entities.StationOrder
+	err := s.db.Preload("Train.TrainType").
+		Preload("Train.TrainProfitType").
+		Preload("Stations", func(db *gorm.DB) *gorm.DB {
+			return db.Order("\"order\" ASC")
+		}).
+		Joins("JOIN trains ON trains.code = station_orders.train_code AND trains.deleted_at IS NULL").
+		Find(&stationOrders).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch routes: %w", err)
+	}
+	return stationOrders, nil
+}
+
+// CreateStationOrder implements interfaces.StationOrderRepository.
+func (s *stationOrderRepositoryImpl) CreateStationOrder(stationOrder entities.StationOrder) (entities.StationOrder, error) {
+	tx := s.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.StationOrder{}, err
+	}
+
+	// Lock the train so route changes of the same train are applied one at a time
+	if err := lockTrain(tx, stationOrder.TrainCode); err != nil {
+		tx.Rollback()
+		return entities.StationOrder{}, err
+	}
+
+	if err := tx.Where("train_code = ?", stationOrder.TrainCode).First(&entities.StationOrder{}).Error; err == nil {
+		tx.Rollback()
+		return entities.StationOrder{}, fmt.Errorf("route of train %s already exists", stationOrder.TrainCode)
+	}
+
+	if err := tx.Create(&stationOrder).Error; err != nil {
+		tx.Rollback()
+		return entities.StationOrder{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return entities.StationOrder{}, err
+	}
+
+	return s.GetStationOrder(stationOrder.TrainCode)
+}
+
+// ReplaceStationOrder implements interfaces.StationOrderRepository.
+func (s *stationOrderRepositoryImpl) ReplaceStationOrder(trainCode string, stops []entities.StationOrderDetail, modifyBy uint) (entities.StationOrder, error) {
+	tx := s.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.StationOrder{}, err
+	}
+
+	if err := lockTrain(tx, trainCode); err != nil {
+		tx.Rollback()
+		return entities.StationOrder{}, err
+	}
+
+	var stationOrder entities.StationOrder
+	if err := tx.Where("train_code = ?", trainCode).First(&stationOrder).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return entities.StationOrder{}, fmt.Errorf("route of train %s not found", trainCode)
+		}
+		return entities.StationOrder{}, fmt.Errorf("failed to fetch route of train %s: %w", trainCode, err)
+	}
+
+	runs, err := releaseUnsoldLegs(tx, trainCode)
+	if err != nil {
+		tx.Rollback()
+		return entities.StationOrder{}, err
+	}
+
+	// The old stops are removed for good, the route history is not kept
+	if err := tx.Unscoped().
+		Where("station_order_id = ?", stationOrder.ID).
+		Delete(&entities.StationOrderDetail{}).Error; err != nil {
+		tx.Rollback()
+		return entities.StationOrder{}, fmt.Errorf("failed to remove stops of train %s: %w", trainCode, err)
+	}
+
+	for i := range stops {
+		stops[i].ID = 0
+		stops[i].StationOrderID = stationOrder.ID
+		stops[i].ModifyBy = modifyBy
+	}
+	if err := tx.Create(&stops).Error; err != nil {
+		tx.Rollback()
+		return entities.StationOrder{}, fmt.Errorf("failed to create stops of train %s: %w", trainCode, err)
+	}
+
+	// Upcoming runs keep their status and capacity and get legs for the new stops
+	for _, run := range runs {
+		legs := buildLegs(run, stops)
+		if err := tx.Create(&legs).Error; err != nil {
+			tx.Rollback()
+			return entities.StationOrder{}, fmt.Errorf("failed to rebuild legs of train %s: %w", trainCode, err)
+		}
+	}
+
+	if err := tx.Model(&stationOrder).Update("modify_by", modifyBy).Error; err != nil {
+		tx.Rollback()
+		return entities.StationOrder{}, fmt.Errorf("failed to update route of train %s: %w", trainCode, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return entities.StationOrder{}, err
+	}
+
+	return s.GetStationOrder(trainCode)
+}
+
+// DeleteStationOrder implements interfaces.StationOrderRepository.
+func (s *stationOrderRepositoryImpl) DeleteStationOrder(trainCode string) error {
+	tx := s.db.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	if err := lockTrain(tx, trainCode); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var stationOrder entities.StationOrder
+	if err := tx.Where("train_code = ?", trainCode).First(&stationOrder).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("route of train %s not found", trainCode)
+		}
+		return err
+	}
+
+	runs, err := releaseUnsoldLegs(tx, trainCode)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Without a route the upcoming runs cannot be sold, they are generated again with the next route
+	if len(runs) > 0 {
+		if err := tx.Delete(&runs).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Where("station_order_id = ?", stationOrder.ID).Delete(&entities.StationOrderDetail{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Delete(&stationOrder).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// releaseUnsoldLegs removes the leg inventory of upcoming runs, which is keyed by the stop
+// orders of the route being changed, and returns those runs. It fails if any seat is sold.
+func releaseUnsoldLegs(tx *gorm.DB, trainCode string) ([]entities.TrainRun, error) {
+	today, _ := time.Parse(time.DateOnly, time.Now().Format(time.DateOnly))
+
+	var runs []entities.TrainRun
+	if err := tx.Where("train_code = ? AND service_date >= ?", trainCode, today).
+		Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch runs of train %s: %w", trainCode, err)
+	}
+	if len(runs) == 0 {
+		return nil, nil
+	}
+
+	runIDs := make([]uint, len(runs))
+	for i, run := range runs {
+		runIDs[i] = run.ID
+	}
+
+	var legs []entities.LegInventory
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("train_run_id IN ?", runIDs).
+		Find(&legs).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock legs of train %s: %w", trainCode, err)
+	}
+
+	for _, leg := range legs {
+		if leg.AvailableSeats < leg.Seats {
+			return nil, fmt.Errorf("route of train %s cannot be changed while seats are sold on upcoming runs", trainCode)
+		}
+	}
+
+	if len(legs) > 0 {
+		if err := tx.Delete(&legs).Error; err != nil {
+			return nil, fmt.Errorf("failed to release legs of train %s: %w", trainCode, err)
+		}
+	}
+	return runs, nil
+}
+
+// lockTrain locks the train row with SELECT ... FOR UPDATE for the rest of the transaction.
+func lockTrain(tx *gorm.DB, trainCode string) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", trainCode).
+		First(&entities.Train{}).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("train with code %s not found", trainCode)
+		}
+		return fmt.Errorf("failed to lock train %s: %w", trainCode, err)
+	}
+	return nil
+}
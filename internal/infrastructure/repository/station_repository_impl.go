@@ -23,9 +23,9 @@ func (t *trainRepositoryImpl) CreateTrainStation(station entities.TrainStation)
 		return entities.TrainStation{}, err
 	}
 
-	if err := tx.Where("id = ?", station.ID).First(&entities.User{}).Error; err == nil {
+	if err := tx.Unscoped().Where("code = ?", station.Code).First(&entities.TrainStation{}).Error; err == nil {
 		tx.Rollback()
-		return entities.TrainStation{}, fmt.Errorf("train station code %d already exists", station.ID)
+		return entities.TrainStation{}, fmt.Errorf("train station code %s already exists", station.Code)
 	}
 
 	if err := tx.Create(&station).Error; err != nil {
@@ -88,8 +88,8 @@ func (t *trainRepositoryImpl) UpdateTrainStation(station entities.TrainStation)
 		return entities.TrainStation{}, fmt.Errorf("failed to fetch train station with code %d: %w", station.ID, err)
 	}
 
-	// Update the user
-	if err := t.db.Model(&existingTrainStation).Updates(station).Error; err != nil {
+	// Update the train station, the code is immutable once created
+	if err := t.db.Model(&existingTrainStation).Omit("code").Updates(station).Error; err != nil {
 		return entities.TrainStation{}, fmt.Errorf("failed to update train station with code %d: %w", station.ID, err)
 	}
 
@@ -107,6 +107,20 @@ func (t *trainRepositoryImpl) GetTrainStationById(id uint) (entities.TrainStatio
 	return trainStation, nil
 }
 
+// GetTrainStationByCode implements interfaces.StationTypeRepository.
+func (t *trainRepositoryImpl) GetTrainStationByCode(code string) (entities.TrainStation, error) {
+	var trainStation entities.TrainStation
+
+	if err := t.db.Where("code = ?", code).First(&trainStation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.TrainStation{}, fmt.Errorf("train station with code %s not found", code)
+		}
+		return entities.TrainStation{}, fmt.Errorf("failed to fetch train station with code %s: %w", code, err)
+	}
+
+	return trainStation, nil
+}
+
 // BulkCreateTrainStation implements interfaces.TrainRepository.
 // This method creates multiple train stations in bulk. It first checks if any of the provided train station codes already exist in the database.
 // If any of the codes already exist, it rolls back the transaction and returns an error.
@@ -116,6 +130,24 @@ func (t *trainRepositoryImpl) BulkCreateTrainStation(stations []entities.TrainSt
 		return nil, err
 	}
 
+	codes := make([]string, len(stations))
+	for i, station := range stations {
+		codes[i] = station.Code
+	}
+
+	// Soft-deleted stations keep their code, so they are checked as well
+	var existingCodes []string
+	if err := tx.Unscoped().Model(&entities.TrainStation{}).
+		Where("code IN ?", codes).
+		Pluck("code", &existingCodes).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(existingCodes) > 0 {
+		tx.Rollback()
+		return nil, fmt.Errorf("train station codes already exist: %v", existingCodes)
+	}
+
 	if err := tx.Create(&stations).Error; err != nil {
 		tx.Rollback()
 		return nil, err
@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+)
+
+func NewTrainCatalogRepository(db *gorm.DB) interfaces.TrainRepository {
+	return &trainCatalogRepositoryImpl{db: db}
+}
+
+type trainCatalogRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// CreateTrain implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) CreateTrain(train entities.Train) (entities.Train, error) {
+	tx := t.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.Train{}, err
+	}
+
+	if err := tx.Where("code = ?", train.Code).First(&entities.Train{}).Error; err == nil {
+		tx.Rollback()
+		return entities.Train{}, fmt.Errorf("train code %s already exists", train.Code)
+	}
+
+	if err := tx.Create(&train).Error; err != nil {
+		tx.Rollback()
+		return entities.Train{}, err
+	}
+
+	return train, tx.Commit().Error
+}
+
+// UpdateTrain implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) UpdateTrain(train entities.Train) (entities.Train, error) {
+	// Check if the train exists
+	var existingTrain entities.Train
+	if err := t.db.Where("code = ?", train.Code).First(&existingTrain).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.Train{}, fmt.Errorf("train with code %s not found", train.Code)
+		}
+		return entities.Train{}, fmt.Errorf("failed to fetch train with code %s: %w", train.Code, err)
+	}
+
+	// Update the train
+	if err := t.db.Model(&existingTrain).Updates(train).Error; err != nil {
+		return entities.Train{}, fmt.Errorf("failed to update train with code %s: %w", train.Code, err)
+	}
+
+	return existingTrain, nil
+}
+
+// DeleteTrain implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) DeleteTrain(code string) error {
+	result := t.db.Where("code = ?", code).Delete(&entities.Train{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete train with code %s: %w", code, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("train with code %s not found", code)
+	}
+	return nil
+}
+
+// GetTrains implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) GetTrains(filters map[string]interface{}) ([]entities.Train, error) {
+	var trains []entities.Train
+
+	// Apply filters to the query
+	query := t.db.Model(&entities.Train{}).
+		Preload("TrainType").
+		Preload("TrainLine").
+		Preload("TrainProfitType")
+	for key, value := range filters {
+		if value != "" && value != nil {
+			query = query.Where(fmt.Sprintf("%s = ?", key), value)
+		}
+	}
+
+	if err := query.Order("code ASC").Find(&trains).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch trains: %w", err)
+	}
+
+	return trains, nil
+}
+
+// GetTrainByCode implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) GetTrainByCode(code string) (entities.Train, error) {
+	var train entities.Train
+	err := t.db.Preload("TrainType").
+		Preload("TrainLine").
+		Preload("TrainProfitType").
+		Where("code = ?", code).
+		First(&train).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.Train{}, fmt.Errorf("train with code %s not found", code)
+		}
+		return entities.Train{}, fmt.Errorf("failed to fetch train with code %s: %w", code, err)
+	}
+	return train, nil
+}
+
+// CreateTrainType implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) CreateTrainType(trainType entities.TrainType) (entities.TrainType, error) {
+	tx := t.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.TrainType{}, err
+	}
+
+	if err := tx.Where("code = ?", trainType.Code).First(&entities.TrainType{}).Error; err == nil {
+		tx.Rollback()
+		return entities.TrainType{}, fmt.Errorf("train type code %s already exists", trainType.Code)
+	}
+
+	if err := tx.Create(&trainType).Error; err != nil {
+		tx.Rollback()
+		return entities.TrainType{}, err
+	}
+
+	return trainType, tx.Commit().Error
+}
+
+// UpdateTrainType implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) UpdateTrainType(trainType entities.TrainType) (entities.TrainType, error) {
+	var existingTrainType entities.TrainType
+	if err := t.db.Where("code = ?", trainType.Code).First(&existingTrainType).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.TrainType{}, fmt.Errorf("train type with code %s not found", trainType.Code)
+		}
+		return entities.TrainType{}, fmt.Errorf("failed to fetch train type with code %s: %w", trainType.Code, err)
+	}
+
+	if err := t.db.Model(&existingTrainType).Updates(trainType).Error; err != nil {
+		return entities.TrainType{}, fmt.Errorf("failed to update train type with code %s: %w", trainType.Code, err)
+	}
+
+	return existingTrainType, nil
+}
+
+// DeleteTrainType implements interfaces.TrainRepository.
+// A train type that is still used by a train cannot be deleted.
+func (t *trainCatalogRepositoryImpl) DeleteTrainType(code string) error {
+	return deleteTrainLookup(t.db, &entities.TrainType{}, "train_type_code", "train type", code)
+}
+
+// GetTrainTypes implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) GetTrainTypes() ([]entities.TrainType, error) {
+	var trainTypes []entities.TrainType
+	if err := t.db.Order("code ASC").Find(&trainTypes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch train types: %w", err)
+	}
+	return trainTypes, nil
+}
+
+// GetTrainTypeByCode implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) GetTrainTypeByCode(code string) (entities.TrainType, error) {
+	var trainType entities.TrainType
+	if err := t.db.Where("code = ?", code).First(&trainType).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.TrainType{}, fmt.Errorf("train type with code %s not found", code)
+		}
+		return entities.TrainType{}, fmt.Errorf("failed to fetch train type with code %s: %w", code, err)
+	}
+	return trainType, nil
+}
+
+// CreateTrainLine implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) CreateTrainLine(trainLine entities.TrainLine) (entities.TrainLine, error) {
+	tx := t.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.TrainLine{}, err
+	}
+
+	if err := tx.Where("code = ?", trainLine.Code).First(&entities.TrainLine{}).Error; err == nil {
+		tx.Rollback()
+		return entities.TrainLine{}, fmt.Errorf("train line code %s already exists", trainLine.Code)
+	}
+
+	if err := tx.Create(&trainLine).Error; err != nil {
+		tx.Rollback()
+		return entities.TrainLine{}, err
+	}
+
+	return trainLine, tx.Commit().Error
+}
+
+// UpdateTrainLine implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) UpdateTrainLine(trainLine entities.TrainLine) (entities.TrainLine, error) {
+	var existingTrainLine entities.TrainLine
+	if err := t.db.Where("code = ?", trainLine.Code).First(&existingTrainLine).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.TrainLine{}, fmt.Errorf("train line with code %s not found", trainLine.Code)
+		}
+		return entities.TrainLine{}, fmt.Errorf("failed to fetch train line with code %s: %w", trainLine.Code, err)
+	}
+
+	if err := t.db.Model(&existingTrainLine).Updates(trainLine).Error; err != nil {
+		return entities.TrainLine{}, fmt.Errorf("failed to update train line with code %s: %w", trainLine.Code, err)
+	}
+
+	return existingTrainLine, nil
+}
+
+// DeleteTrainLine implements interfaces.TrainRepository.
+// A train line that is still used by a train cannot be deleted.
+func (t *trainCatalogRepositoryImpl) DeleteTrainLine(code string) error {
+	return deleteTrainLookup(t.db, &entities.TrainLine{}, "train_line_code", "train line", code)
+}
+
+// GetTrainLines implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) GetTrainLines() ([]entities.TrainLine, error) {
+	var trainLines []entities.TrainLine
+	if err := t.db.Order("code ASC").Find(&trainLines).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch train lines: %w", err)
+	}
+	return trainLines, nil
+}
+
+// GetTrainLineByCode implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) GetTrainLineByCode(code string) (entities.TrainLine, error) {
+	var trainLine entities.TrainLine
+	if err := t.db.Where("code = ?", code).First(&trainLine).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.TrainLine{}, fmt.Errorf("train line with code %s not found", code)
+		}
+		return entities.TrainLine{}, fmt.Errorf("failed to fetch train line with code %s: %w", code, err)
+	}
+	return trainLine, nil
+}
+
+// CreateTrainProfitType implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) CreateTrainProfitType(profitType entities.TrainProfitType) (entities.TrainProfitType, error) {
+	tx := t.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.TrainProfitType{}, err
+	}
+
+	if err := tx.Where("code = ?", profitType.Code).First(&entities.TrainProfitType{}).Error; err == nil {
+		tx.Rollback()
+		return entities.TrainProfitType{}, fmt.Errorf("profit type code %s already exists", profitType.Code)
+	}
+
+	if err := tx.Create(&profitType).Error; err != nil {
+		tx.Rollback()
+		return entities.TrainProfitType{}, err
+	}
+
+	return profitType, tx.Commit().Error
+}
+
+// UpdateTrainProfitType implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) UpdateTrainProfitType(profitType entities.TrainProfitType) (entities.TrainProfitType, error) {
+	var existingProfitType entities.TrainProfitType
+	if err := t.db.Where("code = ?", profitType.Code).First(&existingProfitType).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.TrainProfitType{}, fmt.Errorf("profit type with code %s not found", profitType.Code)
+		}
+		return entities.TrainProfitType{}, fmt.Errorf("failed to fetch profit type with code %s: %w", profitType.Code, err)
+	}
+
+	if err := t.db.Model(&existingProfitType).Updates(profitType).Error; err != nil {
+		return entities.TrainProfitType{}, fmt.Errorf("failed to update profit type with code %s: %w", profitType.Code, err)
+	}
+
+	return existingProfitType, nil
+}
+
+// DeleteTrainProfitType implements interfaces.TrainRepository.
+// A profit type that is still used by a train cannot be deleted.
+func (t *trainCatalogRepositoryImpl) DeleteTrainProfitType(code string) error {
+	return deleteTrainLookup(t.db, &entities.TrainProfitType{}, "train_profit_type_code", "profit type", code)
+}
+
+// GetTrainProfitTypes implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) GetTrainProfitTypes() ([]entities.TrainProfitType, error) {
+	var profitTypes []entities.TrainProfitType
+	if err := t.db.Order("code ASC").Find(&profitTypes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch profit types: %w", err)
+	}
+	return profitTypes, nil
+}
+
+// GetTrainProfitTypeByCode implements interfaces.TrainRepository.
+func (t *trainCatalogRepositoryImpl) GetTrainProfitTypeByCode(code string) (entities.TrainProfitType, error) {
+	var profitType entities.TrainProfitType
+	if err := t.db.Where("code = ?", code).First(&profitType).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.TrainProfitType{}, fmt.Errorf("profit type with code %s not found", code)
+		}
+		return entities.TrainProfitType{}, fmt.Errorf("failed to fetch profit type with code %s: %w", code, err)
+	}
+	return profitType, nil
+}
+
+// deleteTrainLookup soft deletes a train type, line or profit type after making sure no
+// train references it through column.
+func deleteTrainLookup(db *gorm.DB, model interface{}, column, label, code string) error {
+	tx := db.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	var inUse int64
+	if err := tx.Model(&entities.Train{}).Where(fmt.Sprintf("%s = ?", column), code).Count(&inUse).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to check trains using %s %s: %w", label, code, err)
+	}
+	if inUse > 0 {
+		tx.Rollback()
+		return fmt.Errorf("%s %s is used by %d train(s)", label, code, inUse)
+	}
+
+	result := tx.Where("code = ?", code).Delete(model)
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("%s code %s not found", label, code)
+	}
+
+	return tx.Commit().Error
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func NewTrainRunRepository(db *gorm.DB) interfaces.TrainRunRepository {
+	return &trainRunRepositoryImpl{db: db}
+}
+
+type trainRunRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// GetRun implements interfaces.TrainRunRepository.
+func (t *trainRunRepositoryImpl) GetRun(trainCode string, serviceDate time.Time) (entities.TrainRun, error) {
+	var run entities.TrainRun
+	err := t.db.Preload("Legs", func(db *gorm.DB) *gorm.DB {
+		return db.Order("from_order ASC")
+	}).Where("train_code = ? AND service_date = ?", trainCode, serviceDate).First(&run).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return entities.TrainRun{}, interfaces.ErrTrainRunNotFound
+		}
+		return entities.TrainRun{}, fmt.Errorf("failed to fetch run of train %s: %w", trainCode, err)
+	}
+	return run, nil
+}
+
+// GetRuns implements interfaces.TrainRunRepository.
+func (t *trainRunRepositoryImpl) GetRuns(trainCode string, from, to time.Time) ([]entities.TrainRun, error) {
+	var runs []entities.TrainRun
+	if err := t.db.Where("train_code = ? AND service_date BETWEEN ? AND ?", trainCode, from, to).
+		Order("service_date ASC").
+		Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch runs of train %s: %w", trainCode, err)
+	}
+	return runs, nil
+}
+
+// GetRunsByStatus implements interfaces.TrainRunRepository.
+func (t *trainRunRepositoryImpl) GetRunsByStatus(status string, from, to time.Time) ([]entities.TrainRun, error) {
+	var runs []entities.TrainRun
+	if err := t.db.Where("status = ? AND service_date BETWEEN ? AND ?", status, from, to).
+		Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch %s runs: %w", status, err)
+	}
+	return runs, nil
+}
+
+// CreateRuns implements interfaces.TrainRunRepository.
+// Each run is created in its own transaction together with its legs, so a run is never
+// visible without inventory. Runs created concurrently by another instance are skipped.
+func (t *trainRunRepositoryImpl) CreateRuns(runs []entities.TrainRun) (int, error) {
+	created := 0
+	stopsByTrain := make(map[string][]entities.StationOrderDetail)
+
+	for _, run := range runs {
+		stops, ok := stopsByTrain[run.TrainCode]
+		if !ok {
+			var err error
+			if stops, err = routeStops(t.db, run.TrainCode); err != nil {
+				return created, err
+			}
+			stopsByTrain[run.TrainCode] = stops
+		}
+		if len(stops) < 2 {
+			continue
+		}
+
+		err := t.db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Omit("Legs").
+				Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&run)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return nil
+			}
+
+			legs := buildLegs(run, stops)
+			if err := tx.Create(&legs).Error; err != nil {
+				return err
+			}
+			created++
+			return nil
+		})
+		if err != nil {
+			return created, fmt.Errorf("failed to create run of train %s on %s: %w",
+				run.TrainCode, run.ServiceDate.Format(time.DateOnly), err)
+		}
+	}
+
+	return created, nil
+}
+
+// UpdateRun implements interfaces.TrainRunRepository.
+// The run is locked with SELECT ... FOR UPDATE, which waits for bookings in flight on the
+// run to finish since they hold a share lock on it.
+func (t *trainRunRepositoryImpl) UpdateRun(run entities.TrainRun) (entities.TrainRun, error) {
+	tx := t.db.Begin()
+	if err := tx.Error; err != nil {
+		return entities.TrainRun{}, err
+	}
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", run.ID).
+		First(&entities.TrainRun{}).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			return entities.TrainRun{}, interfaces.ErrTrainRunNotFound
+		}
+		return entities.TrainRun{}, fmt.Errorf("failed to lock run %d: %w", run.ID, err)
+	}
+
+	if err := tx.Model(&entities.TrainRun{ID: run.ID}).
+		Select("status", "delay_minutes", "actual_departure", "actual_arrival", "modify_by").
+		Updates(run).Error; err != nil {
+		tx.Rollback()
+		return entities.TrainRun{}, fmt.Errorf("failed to update run of train %s: %w", run.TrainCode, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return entities.TrainRun{}, err
+	}
+
+	return t.GetRun(run.TrainCode, run.ServiceDate)
+}
+
+// routeStops returns the stops of the train's current route in order.
+func routeStops(tx *gorm.DB, trainCode string) ([]entities.StationOrderDetail, error) {
+	var stops []entities.StationOrderDetail
+	if err := tx.Joins("JOIN station_orders ON station_orders.id = station_order_details.station_order_id").
+		Where("station_orders.train_code = ? AND station_orders.deleted_at IS NULL", trainCode).
+		Order("station_order_details.\"order\" ASC").
+		Find(&stops).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch route of train %s: %w", trainCode, err)
+	}
+	return stops, nil
+}
+
+// buildLegs returns one leg per pair of consecutive stops, each with every seat of the run free.
+func buildLegs(run entities.TrainRun, stops []entities.StationOrderDetail) []entities.LegInventory {
+	legs := make([]entities.LegInventory, 0, len(stops)-1)
+	for i := 0; i < len(stops)-1; i++ {
+		legs = append(legs, entities.LegInventory{
+			TrainRunID:     run.ID,
+			FromOrder:      stops[i].Order,
+			ToOrder:        stops[i+1].Order,
+			Seats:          run.Seats,
+			AvailableSeats: run.Seats,
+		})
+	}
+	return legs
+}
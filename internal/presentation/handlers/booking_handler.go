@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+type BookingHandler struct {
+	service *services.BookingService
+}
+
+func NewBookingHandler(service *services.BookingService) *BookingHandler {
+	return &BookingHandler{service: service}
+}
+
+func (h *BookingHandler) CreateBooking(c *fiber.Ctx) error {
+	return h.createBooking(c, h.service.CreateBooking)
+}
+
+// HoldSeats reserves seats for a few minutes, until the customer pays for them or, when
+// there is nothing to pay, confirms the hold.
+func (h *BookingHandler) HoldSeats(c *fiber.Ctx) error {
+	return h.createBooking(c, h.service.HoldSeats)
+}
+
+// bookingPassengerRequest names the passenger of a ticket, either one saved on the profile
+// or one entered with the booking.
+type bookingPassengerRequest struct {
+	SavedPassengerID uint   `json:"saved_passenger_id"`
+	Concession       string `json:"concession"` // Code of the concession rule the passenger travels on
+	HeightCm         int    `json:"height_cm"`  // Declared height, for concessions with a height limit
+	passengerRequest
+}
+
+func (h *BookingHandler) createBooking(c *fiber.Ctx, create func(uint, services.BookingRequest) (entities.Booking, error)) error {
+	type createBookingRequest struct {
+		TrainCode       string `json:"train_code" validate:"required"`
+		ServiceDate     string `json:"service_date" validate:"required"`
+		FromStationCode string `json:"from_station_code" validate:"required"`
+		ToStationCode   string `json:"to_station_code" validate:"required"`
+		SeatCount       int    `json:"seat_count" validate:"required"`
+		Class           int    `json:"class"`
+		AirConditioned  bool   `json:"air_conditioned"`
+		Berth           string `json:"berth"`
+		PromotionCode   string `json:"promotion_code"`
+
+		Seats      []services.SeatSelection  `json:"seats"`
+		Passengers []bookingPassengerRequest `json:"passengers"`
+	}
+
+	var req createBookingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	serviceDate, err := parseServiceDate(req.ServiceDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid service date, expected YYYY-MM-DD",
+		})
+	}
+
+	passengers := make([]services.PassengerSelection, len(req.Passengers))
+	for i, p := range req.Passengers {
+		passengers[i].SavedPassengerID = p.SavedPassengerID
+		passengers[i].Concession = p.Concession
+		passengers[i].HeightCm = p.HeightCm
+		if p.SavedPassengerID != 0 {
+			continue
+		}
+		if passengers[i].Passenger, err = p.toPassenger(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	booking, err := create(userID, services.BookingRequest{
+		TrainCode:       req.TrainCode,
+		ServiceDate:     serviceDate,
+		FromStationCode: req.FromStationCode,
+		ToStationCode:   req.ToStationCode,
+		SeatCount:       req.SeatCount,
+		FareOptions: services.FareOptions{
+			Class:          req.Class,
+			AirConditioned: req.AirConditioned,
+			Berth:          req.Berth,
+		},
+		Seats:         req.Seats,
+		Passengers:    passengers,
+		PromotionCode: req.PromotionCode,
+	})
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPassengerNotFound) || errors.Is(err, interfaces.ErrConcessionNotFound) ||
+			errors.Is(err, interfaces.ErrPromotionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, interfaces.ErrSeatsUnavailable) || errors.Is(err, interfaces.ErrSeatsTaken) ||
+			errors.Is(err, interfaces.ErrTrainRunNotBookable) || errors.Is(err, interfaces.ErrPromotionExhausted) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(booking)
+}
+
+func (h *BookingHandler) GetBookings(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	bookings, total, err := h.service.GetBookings(userID, (page-1)*limit, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch bookings",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  bookings,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func (h *BookingHandler) GetBooking(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid booking ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+	role, _ := c.Locals("role").(string)
+
+	booking, err := h.service.GetBooking(uint(id), userID, role)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrBookingNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch booking",
+		})
+	}
+
+	return c.JSON(booking)
+}
+
+// CancelBooking returns the refund quote of cancelling the booking now. With ?confirm=true
+// it cancels the booking and returns the refund created for it.
+func (h *BookingHandler) CancelBooking(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid booking ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+	role, _ := c.Locals("role").(string)
+
+	var result interface{}
+	if c.QueryBool("confirm", false) {
+		result, err = h.service.CancelBooking(uint(id), userID, role)
+	} else {
+		result, err = h.service.QuoteCancellation(uint(id), userID, role)
+	}
+	if err != nil {
+		if errors.Is(err, interfaces.ErrBookingNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, interfaces.ErrInvalidBookingTransition) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// GetRefunds returns the refunds of a booking.
+func (h *BookingHandler) GetRefunds(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid booking ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+	role, _ := c.Locals("role").(string)
+
+	refunds, err := h.service.GetRefunds(uint(id), userID, role)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrBookingNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch refunds",
+		})
+	}
+
+	return c.JSON(refunds)
+}
+
+// ConfirmHold turns a held booking with nothing to pay into a confirmed one.
+func (h *BookingHandler) ConfirmHold(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid booking ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+	role, _ := c.Locals("role").(string)
+
+	booking, err := h.service.ConfirmHold(uint(id), userID, role)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrBookingNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, interfaces.ErrPaymentRequired) {
+			return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, interfaces.ErrHoldExpired) || errors.Is(err, interfaces.ErrInvalidBookingTransition) ||
+			errors.Is(err, interfaces.ErrPromotionExhausted) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(booking)
+}
+
+// UpdateBookingStatus lets staff move a booking through its life cycle, e.g. check it in or
+// mark it as a no show.
+func (h *BookingHandler) UpdateBookingStatus(c *fiber.Ctx) error {
+	type updateBookingStatusRequest struct {
+		Status string `json:"status" validate:"required"`
+		Reason string `json:"reason"`
+	}
+
+	var req updateBookingStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid booking ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	booking, err := h.service.UpdateBookingStatus(uint(id), req.Status, req.Reason, userID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrBookingNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, interfaces.ErrPaymentRequired) {
+			return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, interfaces.ErrInvalidBookingTransition) || errors.Is(err, interfaces.ErrHoldExpired) ||
+			errors.Is(err, interfaces.ErrPromotionExhausted) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(booking)
+}
+
+// GetBookingHistory returns the status changes of a booking, oldest first.
+func (h *BookingHandler) GetBookingHistory(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid booking ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+	role, _ := c.Locals("role").(string)
+
+	history, err := h.service.GetBookingHistory(uint(id), userID, role)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrBookingNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch booking history",
+		})
+	}
+
+	return c.JSON(history)
+}
+
+// GetAvailability returns the free seats between two stops of a train on a service date.
+func (h *BookingHandler) GetAvailability(c *fiber.Ctx) error {
+	serviceDate, err := parseServiceDate(c.Query("date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+
+	trainCode := c.Query("train_code")
+	from := c.Query("from")
+	to := c.Query("to")
+
+	available, err := h.service.GetAvailableSeats(trainCode, serviceDate, from, to)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrTrainRunNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"train_code":      trainCode,
+		"service_date":    serviceDate.Format(time.DateOnly),
+		"from":            from,
+		"to":              to,
+		"available_seats": available,
+	})
+}
+
+// parseServiceDate parses a YYYY-MM-DD service date.
+func parseServiceDate(value string) (time.Time, error) {
+	return time.Parse(time.DateOnly, value)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// Calendar Handler
+func (h *TrainHandler) GetCalendar(c *fiber.Ctx) error {
+	calendar, err := h.calendar.GetCalendar(c.Params("code"))
+	if err != nil {
+		if errors.Is(err, interfaces.ErrCalendarNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(calendar)
+}
+
+func (h *TrainHandler) SaveCalendar(c *fiber.Ctx) error {
+	type calendarRequest struct {
+		Monday    bool   `json:"monday"`
+		Tuesday   bool   `json:"tuesday"`
+		Wednesday bool   `json:"wednesday"`
+		Thursday  bool   `json:"thursday"`
+		Friday    bool   `json:"friday"`
+		Saturday  bool   `json:"saturday"`
+		Sunday    bool   `json:"sunday"`
+		StartDate string `json:"start_date" validate:"required"`
+		EndDate   string `json:"end_date" validate:"required"`
+	}
+
+	var req calendarRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	startDate, err := parseServiceDate(req.StartDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid start_date, expected YYYY-MM-DD",
+		})
+	}
+	endDate, err := parseServiceDate(req.EndDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid end_date, expected YYYY-MM-DD",
+		})
+	}
+
+	calendar, err := h.calendar.SaveCalendar(entities.ServiceCalendar{
+		TrainCode: c.Params("code"),
+		Monday:    req.Monday,
+		Tuesday:   req.Tuesday,
+		Wednesday: req.Wednesday,
+		Thursday:  req.Thursday,
+		Friday:    req.Friday,
+		Saturday:  req.Saturday,
+		Sunday:    req.Sunday,
+		StartDate: startDate,
+		EndDate:   endDate,
+		ModifyBy:  userID,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(calendar)
+}
+
+func (h *TrainHandler) SaveCalendarException(c *fiber.Ctx) error {
+	type calendarExceptionRequest struct {
+		Date string `json:"date" validate:"required"`
+		Type string `json:"type" validate:"required"`
+		Note string `json:"note"`
+	}
+
+	var req calendarExceptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	date, err := parseServiceDate(req.Date)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+
+	calendar, err := h.calendar.SaveException(c.Params("code"), entities.ServiceCalendarException{
+		Date:     date,
+		Type:     req.Type,
+		Note:     req.Note,
+		ModifyBy: userID,
+	})
+	if err != nil {
+		if errors.Is(err, interfaces.ErrCalendarNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(calendar)
+}
+
+func (h *TrainHandler) DeleteCalendarException(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	date, err := parseServiceDate(c.Params("date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+
+	if err := h.calendar.DeleteException(c.Params("code"), date); err != nil {
+		if errors.Is(err, interfaces.ErrCalendarNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
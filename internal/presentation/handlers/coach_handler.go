@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+// Coach Handler
+func (h *TrainHandler) GetCoaches(c *fiber.Ctx) error {
+	coaches, err := h.services.GetCoaches(c.Params("code"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(coaches)
+}
+
+func (h *TrainHandler) ReplaceCoaches(c *fiber.Ctx) error {
+	type seatRequest struct {
+		Number string `json:"number" validate:"required"`
+		Row    int    `json:"row" validate:"required"`
+		Column int    `json:"column" validate:"required"`
+		Berth  string `json:"berth"`
+		Facing string `json:"facing"`
+	}
+	type coachRequest struct {
+		Number         int           `json:"number" validate:"required"`
+		Class          int           `json:"class" validate:"required"`
+		AirConditioned bool          `json:"air_conditioned"`
+		Seats          []seatRequest `json:"seats" validate:"required"`
+	}
+	type replaceCoachesRequest struct {
+		Coaches []coachRequest `json:"coaches" validate:"required"`
+	}
+
+	var req replaceCoachesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	coaches := make([]entities.Coach, len(req.Coaches))
+	for i, coach := range req.Coaches {
+		seats := make([]entities.Seat, len(coach.Seats))
+		for j, seat := range coach.Seats {
+			seats[j] = entities.Seat{
+				Number: seat.Number,
+				Row:    seat.Row,
+				Column: seat.Column,
+				Berth:  seat.Berth,
+				Facing: seat.Facing,
+			}
+		}
+		coaches[i] = entities.Coach{
+			Number:         coach.Number,
+			Class:          coach.Class,
+			AirConditioned: coach.AirConditioned,
+			Seats:          seats,
+		}
+	}
+
+	result, err := h.services.ReplaceCoaches(c.Params("code"), coaches, userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(result)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+type FareHandler struct {
+	service *services.FareService
+}
+
+func NewFareHandler(service *services.FareService) *FareHandler {
+	return &FareHandler{service: service}
+}
+
+// GetFare returns the fare breakdown of one passenger between two stops of a train.
+func (h *FareHandler) GetFare(c *fiber.Ctx) error {
+	options := services.FareOptions{
+		Class:          c.QueryInt("class", services.DefaultFareClass),
+		AirConditioned: c.QueryBool("air_conditioned", false),
+		Berth:          c.Query("berth"),
+		Concession:     c.Query("concession"),
+	}
+
+	fare, err := h.service.GetFare(c.Query("train_code"), c.Query("from"), c.Query("to"), options)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fare)
+}
+
+// concessionRequest is a concession rule as sent by an admin.
+type concessionRequest struct {
+	Code            string `json:"code"`
+	Name            string `json:"name" validate:"required"`
+	Category        string `json:"category" validate:"required"`
+	DiscountPercent int    `json:"discount_percent" validate:"required"`
+	MinAge          int    `json:"min_age"`
+	MaxAge          int    `json:"max_age"`
+	MaxHeightCm     int    `json:"max_height_cm"`
+	ThaiOnly        bool   `json:"thai_only"`
+	Proof           string `json:"proof" validate:"required"`
+	Active          *bool  `json:"active"` // Defaults to true
+}
+
+func (r concessionRequest) toRule(code string, modifyBy uint) entities.ConcessionRule {
+	active := r.Active == nil || *r.Active
+	return entities.ConcessionRule{
+		Code:            code,
+		Name:            r.Name,
+		Category:        r.Category,
+		DiscountPercent: r.DiscountPercent,
+		MinAge:          r.MinAge,
+		MaxAge:          r.MaxAge,
+		MaxHeightCm:     r.MaxHeightCm,
+		ThaiOnly:        r.ThaiOnly,
+		Proof:           r.Proof,
+		Active:          active,
+		ModifyBy:        modifyBy,
+	}
+}
+
+// GetConcessions lists the concession fares and the proof each of them needs.
+func (h *FareHandler) GetConcessions(c *fiber.Ctx) error {
+	rules, err := h.service.GetConcessions()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(rules)
+}
+
+func (h *FareHandler) CreateConcession(c *fiber.Ctx) error {
+	var req concessionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	rule, err := h.service.CreateConcession(req.toRule(req.Code, userID))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+func (h *FareHandler) UpdateConcession(c *fiber.Ctx) error {
+	var req concessionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	rule, err := h.service.UpdateConcession(req.toRule(c.Params("code"), userID))
+	if err != nil {
+		if errors.Is(err, interfaces.ErrConcessionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(rule)
+}
+
+func (h *FareHandler) DeleteConcession(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	if err := h.service.DeleteConcession(c.Params("code")); err != nil {
+		if errors.Is(err, interfaces.ErrConcessionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+)
+
+type JourneyHandler struct {
+	service *services.JourneyService
+}
+
+func NewJourneyHandler(service *services.JourneyService) *JourneyHandler {
+	return &JourneyHandler{service: service}
+}
+
+// PlanJourney returns itineraries between two stations, changing trains if needed.
+func (h *JourneyHandler) PlanJourney(c *fiber.Ctx) error {
+	serviceDate, err := parseServiceDate(c.Query("date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+
+	opts := services.JourneyOptions{
+		MaxTransfers:  c.QueryInt("max_transfers", services.DefaultMaxTransfers),
+		MinConnection: time.Duration(c.QueryInt("min_connection", int(services.DefaultMinConnection.Minutes()))) * time.Minute,
+		SortBy:        c.Query("sort"),
+	}
+
+	itineraries, err := h.service.PlanJourney(c.Query("from"), c.Query("to"), serviceDate, opts)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(itineraries)
+}
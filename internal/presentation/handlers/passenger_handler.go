@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// passengerRequest is a passenger as entered by the user, when saving it to the profile or
+// naming it on a booking.
+type passengerRequest struct {
+	Title          string `json:"title"`
+	FirstNameTh    string `json:"first_name_th"`
+	LastNameTh     string `json:"last_name_th"`
+	FirstNameEn    string `json:"first_name_en"`
+	LastNameEn     string `json:"last_name_en"`
+	DocumentType   string `json:"document_type"`
+	DocumentNumber string `json:"document_number"`
+	Country        string `json:"country"`
+	DateOfBirth    string `json:"date_of_birth"` // YYYY-MM-DD
+}
+
+func (r passengerRequest) toPassenger() (entities.Passenger, error) {
+	dateOfBirth, err := parseServiceDate(r.DateOfBirth)
+	if err != nil {
+		return entities.Passenger{}, errors.New("invalid date of birth, expected YYYY-MM-DD")
+	}
+	return entities.Passenger{
+		Title:          r.Title,
+		FirstNameTh:    r.FirstNameTh,
+		LastNameTh:     r.LastNameTh,
+		FirstNameEn:    r.FirstNameEn,
+		LastNameEn:     r.LastNameEn,
+		DocumentType:   r.DocumentType,
+		DocumentNumber: r.DocumentNumber,
+		Country:        r.Country,
+		DateOfBirth:    dateOfBirth,
+	}, nil
+}
+
+type PassengerHandler struct {
+	service *services.PassengerService
+}
+
+func NewPassengerHandler(service *services.PassengerService) *PassengerHandler {
+	return &PassengerHandler{service: service}
+}
+
+// GetPassengers returns the passengers saved on the user's profile.
+func (h *PassengerHandler) GetPassengers(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	passengers, err := h.service.GetSavedPassengers(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch passengers",
+		})
+	}
+	return c.JSON(passengers)
+}
+
+func (h *PassengerHandler) CreatePassenger(c *fiber.Ctx) error {
+	var req passengerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	passenger, err := req.toPassenger()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	saved, err := h.service.SavePassenger(userID, passenger)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(saved)
+}
+
+func (h *PassengerHandler) UpdatePassenger(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid passenger ID",
+		})
+	}
+
+	var req passengerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	passenger, err := req.toPassenger()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	updated, err := h.service.UpdatePassenger(uint(id), userID, passenger)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPassengerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(updated)
+}
+
+func (h *PassengerHandler) DeletePassenger(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid passenger ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	if err := h.service.DeletePassenger(uint(id), userID); err != nil {
+		if errors.Is(err, interfaces.ErrPassengerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+type PaymentHandler struct {
+	service *services.PaymentService
+}
+
+func NewPaymentHandler(service *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+// CreatePayment starts paying for a held booking. For PromptPay the payment carries the QR
+// payload the customer scans.
+func (h *PaymentHandler) CreatePayment(c *fiber.Ctx) error {
+	type createPaymentRequest struct {
+		BookingID uint   `json:"booking_id" validate:"required"`
+		Provider  string `json:"provider"` // Defaults to the first configured provider
+	}
+
+	var req createPaymentRequest
+	if err := c.BodyParser(&req); err != nil || req.BookingID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+	role, _ := c.Locals("role").(string)
+
+	payment, err := h.service.CreatePayment(req.BookingID, userID, role, req.Provider)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrBookingNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, interfaces.ErrHoldExpired) || errors.Is(err, interfaces.ErrInvalidBookingTransition) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(payment)
+}
+
+// GetPayments lists the payments of the booking given by ?booking_id=.
+func (h *PaymentHandler) GetPayments(c *fiber.Ctx) error {
+	bookingID := c.QueryInt("booking_id")
+	if bookingID < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid booking ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+	role, _ := c.Locals("role").(string)
+
+	payments, err := h.service.GetPayments(uint(bookingID), userID, role)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrBookingNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch payments",
+		})
+	}
+
+	return c.JSON(payments)
+}
+
+func (h *PaymentHandler) GetPayment(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid payment ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+	role, _ := c.Locals("role").(string)
+
+	payment, err := h.service.GetPayment(uint(id), userID, role)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPaymentNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch payment",
+		})
+	}
+
+	return c.JSON(payment)
+}
+
+// RefreshPayment asks the provider whether a pending payment was paid. Clients poll it
+// while the customer pays.
+func (h *PaymentHandler) RefreshPayment(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid payment ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+	role, _ := c.Locals("role").(string)
+
+	payment, err := h.service.RefreshPayment(uint(id), userID, role)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPaymentNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(payment)
+}
+
+// ConfirmPayment lets staff mark a payment as received, which confirms its booking.
+func (h *PaymentHandler) ConfirmPayment(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid payment ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	payment, err := h.service.ConfirmPayment(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPaymentNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(payment)
+}
+
+// ReceiveWebhook takes a payment notification from the provider named in the path. The
+// provider retries a notification until it gets a 2xx response, so an event that could not
+// be applied answers with an error once it is stored.
+func (h *PaymentHandler) ReceiveWebhook(c *fiber.Ctx) error {
+	body := append([]byte(nil), c.Body()...)
+	event, err := h.service.HandleWebhook(c.Params("provider"), body, http.Header(c.GetReqHeaders()))
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPaymentProviderUnknown) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, interfaces.ErrInvalidWebhookSignature) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if event.State == entities.PaymentEventStateFailed {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": event.Error,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"id":    event.ID,
+		"state": event.State,
+	})
+}
+
+// GetWebhookEvents lists the stored payment notifications, optionally in one ?state=.
+func (h *PaymentHandler) GetWebhookEvents(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	events, total, err := h.service.GetWebhookEvents(c.Query("state"), (page-1)*limit, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch payment events",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  events,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// ReplayWebhookEvent applies a stored payment notification again.
+func (h *PaymentHandler) ReplayWebhookEvent(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid payment event ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	event, err := h.service.ReplayEvent(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPaymentEventNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(event)
+}
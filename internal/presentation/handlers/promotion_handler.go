@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// promotionRequest is a promotion as sent by an admin. Travel dates are YYYY-MM-DD, the
+// booking period is a pair of timestamps.
+type promotionRequest struct {
+	Code          string     `json:"code"`
+	Description   string     `json:"description" validate:"required"`
+	DiscountType  string     `json:"discount_type" validate:"required"`
+	DiscountValue int        `json:"discount_value" validate:"required"`
+	MaxDiscount   int        `json:"max_discount"`
+	TrainLineCode string     `json:"train_line_code"`
+	TrainTypeCode string     `json:"train_type_code"`
+	TravelFrom    string     `json:"travel_from"`
+	TravelTo      string     `json:"travel_to"`
+	BookingFrom   *time.Time `json:"booking_from"`
+	BookingTo     *time.Time `json:"booking_to"`
+	MinSpend      int        `json:"min_spend"`
+	TotalLimit    int        `json:"total_limit"`
+	PerUserLimit  int        `json:"per_user_limit"`
+	Active        *bool      `json:"active"` // Defaults to true
+}
+
+func (r promotionRequest) toPromotion(code string, modifyBy uint) (entities.Promotion, error) {
+	travelFrom, err := parseOptionalDate(r.TravelFrom)
+	if err != nil {
+		return entities.Promotion{}, errors.New("invalid travel_from, expected YYYY-MM-DD")
+	}
+	travelTo, err := parseOptionalDate(r.TravelTo)
+	if err != nil {
+		return entities.Promotion{}, errors.New("invalid travel_to, expected YYYY-MM-DD")
+	}
+
+	return entities.Promotion{
+		Code:          code,
+		Description:   r.Description,
+		DiscountType:  r.DiscountType,
+		DiscountValue: r.DiscountValue,
+		MaxDiscount:   r.MaxDiscount,
+		TrainLineCode: r.TrainLineCode,
+		TrainTypeCode: r.TrainTypeCode,
+		TravelFrom:    travelFrom,
+		TravelTo:      travelTo,
+		BookingFrom:   r.BookingFrom,
+		BookingTo:     r.BookingTo,
+		MinSpend:      r.MinSpend,
+		TotalLimit:    r.TotalLimit,
+		PerUserLimit:  r.PerUserLimit,
+		Active:        r.Active == nil || *r.Active,
+		ModifyBy:      modifyBy,
+	}, nil
+}
+
+// parseOptionalDate parses a YYYY-MM-DD date, an empty value is no date.
+func parseOptionalDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+type PromotionHandler struct {
+	service *services.PromotionService
+}
+
+func NewPromotionHandler(service *services.PromotionService) *PromotionHandler {
+	return &PromotionHandler{service: service}
+}
+
+func (h *PromotionHandler) GetPromotions(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	promotions, err := h.service.GetPromotions()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(promotions)
+}
+
+func (h *PromotionHandler) GetPromotion(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	promotion, err := h.service.GetPromotion(c.Params("code"))
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPromotionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(promotion)
+}
+
+func (h *PromotionHandler) CreatePromotion(c *fiber.Ctx) error {
+	var req promotionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	promotion, err := req.toPromotion(req.Code, userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	created, err := h.service.CreatePromotion(promotion)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+func (h *PromotionHandler) UpdatePromotion(c *fiber.Ctx) error {
+	var req promotionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	promotion, err := req.toPromotion(c.Params("code"), userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	updated, err := h.service.UpdatePromotion(promotion)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPromotionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(updated)
+}
+
+func (h *PromotionHandler) DeletePromotion(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	if err := h.service.DeletePromotion(c.Params("code")); err != nil {
+		if errors.Is(err, interfaces.ErrPromotionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+type ReconciliationHandler struct {
+	service *services.ReconciliationService
+}
+
+func NewReconciliationHandler(service *services.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{service: service}
+}
+
+// ImportSettlement reconciles an uploaded settlement file. The multipart form carries the
+// CSV as file, the provider and the settlement date as YYYY-MM-DD.
+func (h *ReconciliationHandler) ImportSettlement(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	settlementDate, err := time.Parse(time.DateOnly, c.FormValue("date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid settlement date, expected YYYY-MM-DD",
+		})
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Settlement file is required",
+		})
+	}
+	file, err := header.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read settlement file",
+		})
+	}
+	defer file.Close()
+
+	report, err := h.service.Import(c.FormValue("provider"), settlementDate, header.Filename, file, userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
+
+func (h *ReconciliationHandler) GetReports(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	reports, total, err := h.service.GetReports((page-1)*limit, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch reconciliation reports",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  reports,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func (h *ReconciliationHandler) GetReport(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid report ID",
+		})
+	}
+
+	report, err := h.service.GetReport(uint(id))
+	if err != nil {
+		if errors.Is(err, interfaces.ErrReconciliationReportNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch reconciliation report",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// DownloadReport returns the items of a report as a CSV file.
+func (h *ReconciliationHandler) DownloadReport(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid report ID",
+		})
+	}
+
+	var csv bytes.Buffer
+	if err := h.service.ExportReport(uint(id), &csv); err != nil {
+		if errors.Is(err, interfaces.ErrReconciliationReportNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export reconciliation report",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Attachment(fmt.Sprintf("reconciliation-%d.csv", id))
+	return c.Send(csv.Bytes())
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+// refundRuleRequest is a refund rule as sent by an admin.
+type refundRuleRequest struct {
+	Name           string `json:"name" validate:"required"`
+	TrainTypeCode  string `json:"train_type_code"`
+	FareType       string `json:"fare_type"`
+	MinHoursBefore int    `json:"min_hours_before"`
+	RefundPercent  int    `json:"refund_percent"`
+	FeePerTicket   int    `json:"fee_per_ticket"`
+	Active         *bool  `json:"active"` // Defaults to true
+}
+
+func (r refundRuleRequest) toRule(id, modifyBy uint) entities.RefundRule {
+	return entities.RefundRule{
+		ID:             id,
+		Name:           r.Name,
+		TrainTypeCode:  r.TrainTypeCode,
+		FareType:       r.FareType,
+		MinHoursBefore: r.MinHoursBefore,
+		RefundPercent:  r.RefundPercent,
+		FeePerTicket:   r.FeePerTicket,
+		Active:         r.Active == nil || *r.Active,
+		ModifyBy:       modifyBy,
+	}
+}
+
+type RefundHandler struct {
+	service *services.RefundService
+	worker  *services.RefundWorker
+}
+
+func NewRefundHandler(service *services.RefundService, worker *services.RefundWorker) *RefundHandler {
+	return &RefundHandler{service: service, worker: worker}
+}
+
+// GetRules lists the refund rules, so customers can see the policy before booking.
+func (h *RefundHandler) GetRules(c *fiber.Ctx) error {
+	rules, err := h.service.GetRules()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(rules)
+}
+
+func (h *RefundHandler) CreateRule(c *fiber.Ctx) error {
+	var req refundRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	rule, err := h.service.CreateRule(req.toRule(0, userID))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+func (h *RefundHandler) UpdateRule(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid refund rule ID",
+		})
+	}
+
+	var req refundRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	rule, err := h.service.UpdateRule(req.toRule(uint(id), userID))
+	if err != nil {
+		if errors.Is(err, interfaces.ErrRefundRuleNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(rule)
+}
+
+func (h *RefundHandler) DeleteRule(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid refund rule ID",
+		})
+	}
+
+	if err := h.service.DeleteRule(uint(id)); err != nil {
+		if errors.Is(err, interfaces.ErrRefundRuleNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetStuckRefunds lists the refunds the worker gave up on or that are processing for too
+// long.
+func (h *RefundHandler) GetStuckRefunds(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	refunds, err := h.worker.GetStuckRefunds()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch refunds",
+		})
+	}
+	return c.JSON(refunds)
+}
+
+// GetRefund returns a refund with every attempt to pay it out.
+func (h *RefundHandler) GetRefund(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid refund ID",
+		})
+	}
+
+	refund, err := h.worker.GetRefund(uint(id))
+	if err != nil {
+		if errors.Is(err, interfaces.ErrRefundNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch refund",
+		})
+	}
+	return c.JSON(refund)
+}
+
+// RetryRefund tries to pay out a stuck refund again right away.
+func (h *RefundHandler) RetryRefund(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid refund ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	refund, err := h.worker.RetryRefund(uint(id), userID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrRefundNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, interfaces.ErrRefundStatusChanged) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(refund)
+}
+
+// ResolveRefund records that a stuck refund was sent back to the customer by hand.
+func (h *RefundHandler) ResolveRefund(c *fiber.Ctx) error {
+	type resolveRefundRequest struct {
+		Note string `json:"note" validate:"required"`
+	}
+
+	var req resolveRefundRequest
+	if err := c.BodyParser(&req); err != nil || req.Note == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input, a note on how the money was sent back is required",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid refund ID",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	refund, err := h.worker.ResolveRefund(uint(id), userID, req.Note)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrRefundNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, interfaces.ErrRefundStatusChanged) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(refund)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+// routeStopRequest offsets are minutes after midnight of the service date, e.g. 1450 for
+// 00:10 on the next day. DistanceKm is counted from the first stop.
+type routeStopRequest struct {
+	StationCode     string `json:"station_code" validate:"required"`
+	Order           int    `json:"order" validate:"required"`
+	ArrivalOffset   int    `json:"arrival_offset"`
+	DepartureOffset int    `json:"departure_offset"`
+	DistanceKm      int    `json:"distance_km"`
+}
+
+type routeRequest struct {
+	Stations []routeStopRequest `json:"stations" validate:"required"`
+}
+
+func (r routeRequest) toStops() []entities.StationOrderDetail {
+	stops := make([]entities.StationOrderDetail, len(r.Stations))
+	for i, stop := range r.Stations {
+		stops[i] = entities.StationOrderDetail{
+			StationCode:     stop.StationCode,
+			Order:           stop.Order,
+			ArrivalOffset:   stop.ArrivalOffset,
+			DepartureOffset: stop.DepartureOffset,
+			DistanceKm:      stop.DistanceKm,
+		}
+	}
+	return stops
+}
+
+// Route Handler
+func (h *TrainHandler) GetRoute(c *fiber.Ctx) error {
+	route, err := h.services.GetRoute(c.Params("code"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(route)
+}
+
+// GetTimetable returns the stop times of a train on a service date.
+func (h *TrainHandler) GetTimetable(c *fiber.Ctx) error {
+	serviceDate, err := parseServiceDate(c.Query("date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+
+	timetable, err := h.timetable.GetTimetable(c.Params("code"), serviceDate)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(timetable)
+}
+
+func (h *TrainHandler) CreateRoute(c *fiber.Ctx) error {
+	var req routeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	route, err := h.services.CreateRoute(c.Params("code"), req.toStops(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(route)
+}
+
+func (h *TrainHandler) ReplaceRoute(c *fiber.Ctx) error {
+	var req routeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	route, err := h.services.ReplaceRoute(c.Params("code"), req.toStops(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(route)
+}
+
+func (h *TrainHandler) ReorderRoute(c *fiber.Ctx) error {
+	var req routeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	route, err := h.services.ReorderRoute(c.Params("code"), req.toStops(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(route)
+}
+
+func (h *TrainHandler) DeleteRoute(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	if err := h.services.DeleteRoute(c.Params("code")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -7,12 +7,16 @@ import (
 )
 
 type TrainHandler struct {
-	services *services.TrainService
+	services  *services.TrainService
+	timetable *services.TimetableService
+	calendar  *services.CalendarService
 }
 
-func NewTrainHandler(services *services.TrainService) *TrainHandler {
+func NewTrainHandler(services *services.TrainService, timetable *services.TimetableService, calendar *services.CalendarService) *TrainHandler {
 	return &TrainHandler{
-		services: services,
+		services:  services,
+		timetable: timetable,
+		calendar:  calendar,
 	}
 }
 
@@ -160,6 +164,7 @@ func (h *TrainHandler) GetStations(c *fiber.Ctx) error {
 
 	// Build filters using a helper function
 	filters := map[string]interface{}{
+		"code":              getQueryParam("code"),
 		"station_type_code": getQueryParam("station_type_code"),
 		"name":              getQueryParam("name"),
 		"postal_code":       getQueryParam("postal_code"),
@@ -183,8 +188,20 @@ func (h *TrainHandler) GetStations(c *fiber.Ctx) error {
 	return c.JSON(trainStations)
 }
 
+func (h *TrainHandler) GetStation(c *fiber.Ctx) error {
+	trainStation, err := h.services.GetTrainStationByCode(c.Params("code"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(trainStation)
+}
+
 func (h *TrainHandler) BulkCreateStation(c *fiber.Ctx) error {
 	type createTrainStationRequest struct {
+		Code            string `json:"code" validate:"required"`
 		Name            string `json:"name" validate:"required"`
 		StationTypeCode string `json:"station_type_code" validate:"required"`
 		PostalCode      string `json:"postal_code"`
@@ -220,6 +237,7 @@ func (h *TrainHandler) BulkCreateStation(c *fiber.Ctx) error {
 	trainStations := make([]entities.TrainStation, len(req))
 	for i, station := range req {
 		trainStations[i] = entities.TrainStation{
+			Code:            station.Code,
 			Name:            station.Name,
 			Province:        station.Province,
 			District:        station.District,
@@ -234,7 +252,8 @@ func (h *TrainHandler) BulkCreateStation(c *fiber.Ctx) error {
 	createdStations, err := h.services.BulkCreateTrainStation(trainStations)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create train stations",
+			"error":   "Failed to create train stations",
+			"details": err.Error(),
 		})
 	}
 	return c.Status(fiber.StatusCreated).JSON(createdStations)
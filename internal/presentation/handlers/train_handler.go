@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+)
+
+type trainLookupRequest struct {
+	Code string `json:"code" validate:"required"`
+	Name string `json:"name" validate:"required"`
+
+	// Fee applies to train types and FarePercent to profit types only
+	Fee         int `json:"fee"`
+	FarePercent int `json:"fare_percent"`
+}
+
+// Train Handler
+func (h *TrainHandler) GetTrains(c *fiber.Ctx) error {
+	filters := map[string]interface{}{
+		"from_station_code":      c.Query("from"),
+		"to_station_code":        c.Query("to"),
+		"train_type_code":        c.Query("train_type_code"),
+		"train_line_code":        c.Query("train_line_code"),
+		"train_profit_type_code": c.Query("train_profit_type_code"),
+	}
+
+	trains, err := h.services.GetTrains(filters)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch trains",
+		})
+	}
+	return c.JSON(trains)
+}
+
+func (h *TrainHandler) GetTrain(c *fiber.Ctx) error {
+	train, err := h.services.GetTrainByCode(c.Params("code"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(train)
+}
+
+func (h *TrainHandler) CreateTrain(c *fiber.Ctx) error {
+	type createTrainRequest struct {
+		Code                string `json:"code" validate:"required"`
+		Name                string `json:"name" validate:"required"`
+		FromStationCode     string `json:"from" validate:"required"`
+		ToStationCode       string `json:"to" validate:"required"`
+		Seats               int    `json:"seats" validate:"required"`
+		TrainTypeCode       string `json:"train_type_code" validate:"required"`
+		TrainLineCode       string `json:"train_line_code" validate:"required"`
+		TrainProfitTypeCode string `json:"train_profit_type_code" validate:"required"`
+	}
+
+	var req createTrainRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	train := entities.Train{
+		Code:                req.Code,
+		Name:                req.Name,
+		FromStationCode:     req.FromStationCode,
+		ToStationCode:       req.ToStationCode,
+		Seats:               req.Seats,
+		TrainTypeCode:       req.TrainTypeCode,
+		TrainLineCode:       req.TrainLineCode,
+		TrainProfitTypeCode: req.TrainProfitTypeCode,
+		ModifyBy:            userID,
+	}
+
+	createdTrain, err := h.services.CreateTrain(train)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(createdTrain)
+}
+
+func (h *TrainHandler) UpdateTrain(c *fiber.Ctx) error {
+	type updateTrainRequest struct {
+		Name                string `json:"name"`
+		FromStationCode     string `json:"from"`
+		ToStationCode       string `json:"to"`
+		Seats               int    `json:"seats"`
+		TrainTypeCode       string `json:"train_type_code"`
+		TrainLineCode       string `json:"train_line_code"`
+		TrainProfitTypeCode string `json:"train_profit_type_code"`
+	}
+
+	var req updateTrainRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	train := entities.Train{
+		Code:                c.Params("code"),
+		Name:                req.Name,
+		FromStationCode:     req.FromStationCode,
+		ToStationCode:       req.ToStationCode,
+		Seats:               req.Seats,
+		TrainTypeCode:       req.TrainTypeCode,
+		TrainLineCode:       req.TrainLineCode,
+		TrainProfitTypeCode: req.TrainProfitTypeCode,
+		ModifyBy:            userID,
+	}
+
+	updatedTrain, err := h.services.UpdateTrain(train)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(updatedTrain)
+}
+
+func (h *TrainHandler) DeleteTrain(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	if err := h.services.DeleteTrain(c.Params("code")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TrainType Handler
+func (h *TrainHandler) GetTrainTypes(c *fiber.Ctx) error {
+	trainTypes, err := h.services.GetTrainTypes()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch train types",
+		})
+	}
+	return c.JSON(trainTypes)
+}
+
+func (h *TrainHandler) CreateTrainType(c *fiber.Ctx) error {
+	var req trainLookupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	createdTrainType, err := h.services.CreateTrainType(entities.TrainType{
+		Code:     req.Code,
+		Name:     req.Name,
+		Fee:      req.Fee,
+		ModifyBy: userID,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(createdTrainType)
+}
+
+func (h *TrainHandler) UpdateTrainType(c *fiber.Ctx) error {
+	var req trainLookupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	updatedTrainType, err := h.services.UpdateTrainType(entities.TrainType{
+		Code:     c.Params("code"),
+		Name:     req.Name,
+		Fee:      req.Fee,
+		ModifyBy: userID,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(updatedTrainType)
+}
+
+func (h *TrainHandler) DeleteTrainType(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	if err := h.services.DeleteTrainType(c.Params("code")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TrainLine Handler
+func (h *TrainHandler) GetTrainLines(c *fiber.Ctx) error {
+	trainLines, err := h.services.GetTrainLines()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch train lines",
+		})
+	}
+	return c.JSON(trainLines)
+}
+
+func (h *TrainHandler) CreateTrainLine(c *fiber.Ctx) error {
+	var req trainLookupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	createdTrainLine, err := h.services.CreateTrainLine(entities.TrainLine{
+		Code:     req.Code,
+		Name:     req.Name,
+		ModifyBy: userID,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(createdTrainLine)
+}
+
+func (h *TrainHandler) UpdateTrainLine(c *fiber.Ctx) error {
+	var req trainLookupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	updatedTrainLine, err := h.services.UpdateTrainLine(entities.TrainLine{
+		Code:     c.Params("code"),
+		Name:     req.Name,
+		ModifyBy: userID,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(updatedTrainLine)
+}
+
+func (h *TrainHandler) DeleteTrainLine(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	if err := h.services.DeleteTrainLine(c.Params("code")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TrainProfitType Handler
+func (h *TrainHandler) GetTrainProfitTypes(c *fiber.Ctx) error {
+	profitTypes, err := h.services.GetTrainProfitTypes()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch profit types",
+		})
+	}
+	return c.JSON(profitTypes)
+}
+
+func (h *TrainHandler) CreateTrainProfitType(c *fiber.Ctx) error {
+	var req trainLookupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	createdProfitType, err := h.services.CreateTrainProfitType(entities.TrainProfitType{
+		Code:        req.Code,
+		Name:        req.Name,
+		FarePercent: req.FarePercent,
+		ModifyBy:    userID,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(createdProfitType)
+}
+
+func (h *TrainHandler) UpdateTrainProfitType(c *fiber.Ctx) error {
+	var req trainLookupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	updatedProfitType, err := h.services.UpdateTrainProfitType(entities.TrainProfitType{
+		Code:        c.Params("code"),
+		Name:        req.Name,
+		FarePercent: req.FarePercent,
+		ModifyBy:    userID,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(updatedProfitType)
+}
+
+func (h *TrainHandler) DeleteTrainProfitType(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	if err := h.services.DeleteTrainProfitType(c.Params("code")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
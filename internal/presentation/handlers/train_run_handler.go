@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+type TrainRunHandler struct {
+	service *services.TrainRunService
+	seats   *services.SeatService
+}
+
+func NewTrainRunHandler(service *services.TrainRunService, seats *services.SeatService) *TrainRunHandler {
+	return &TrainRunHandler{service: service, seats: seats}
+}
+
+// GetRun returns a train run with the free seats of each of its legs.
+func (h *TrainRunHandler) GetRun(c *fiber.Ctx) error {
+	serviceDate, err := parseServiceDate(c.Params("date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+
+	run, err := h.service.GetRun(c.Params("code"), serviceDate)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrTrainRunNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(run)
+}
+
+// GetRuns returns the runs of a train between the from and to service dates.
+func (h *TrainRunHandler) GetRuns(c *fiber.Ctx) error {
+	from, err := parseServiceDate(c.Query("from"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid from, expected YYYY-MM-DD",
+		})
+	}
+	to, err := parseServiceDate(c.Query("to"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid to, expected YYYY-MM-DD",
+		})
+	}
+
+	runs, err := h.service.GetRuns(c.Params("code"), from, to)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(runs)
+}
+
+// GetSeatMap returns the seats of a train run and which of them are free between the from
+// and to stations.
+func (h *TrainRunHandler) GetSeatMap(c *fiber.Ctx) error {
+	serviceDate, err := parseServiceDate(c.Params("date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+
+	seatMap, err := h.seats.GetSeatMap(c.Params("code"), serviceDate, c.Query("from"), c.Query("to"))
+	if err != nil {
+		if errors.Is(err, interfaces.ErrTrainRunNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(seatMap)
+}
+
+// GenerateRuns rolls the booking window forward right away, e.g. after a calendar change.
+func (h *TrainRunHandler) GenerateRuns(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	created, err := h.service.GenerateBookingWindow(services.DefaultBookingWindowDays)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"created": created,
+	})
+}
+
+func (h *TrainRunHandler) UpdateRunStatus(c *fiber.Ctx) error {
+	type updateRunStatusRequest struct {
+		Status       string     `json:"status" validate:"required"`
+		DelayMinutes int        `json:"delay_minutes"`
+		ActualTime   *time.Time `json:"actual_time"`
+	}
+
+	var req updateRunStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	serviceDate, err := parseServiceDate(c.Params("date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+
+	run, err := h.service.UpdateRunStatus(c.Params("code"), serviceDate, req.Status, req.DelayMinutes, req.ActualTime, userID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrTrainRunNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(run)
+}
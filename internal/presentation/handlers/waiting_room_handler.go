@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hamwiwatsapon/train-booking-go/internal/application/services"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/entities"
+	"github.com/hamwiwatsapon/train-booking-go/internal/domain/interfaces"
+)
+
+type WaitingRoomHandler struct {
+	service *services.WaitingRoomService
+}
+
+func NewWaitingRoomHandler(service *services.WaitingRoomService) *WaitingRoomHandler {
+	return &WaitingRoomHandler{service: service}
+}
+
+// GetActiveWindow tells clients whether a sale is on and they must queue to book.
+func (h *WaitingRoomHandler) GetActiveWindow(c *fiber.Ctx) error {
+	window, err := h.service.ActiveWindow()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"active":      window != nil,
+		"sale_window": window,
+	})
+}
+
+// Join hands out a queue token for the active sale window.
+func (h *WaitingRoomHandler) Join(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	status, err := h.service.Join(userID)
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(status)
+}
+
+// GetStatus is polled by queued clients for their position and, once admitted, their
+// admission token.
+func (h *WaitingRoomHandler) GetStatus(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	status, err := h.service.Status(userID, c.Params("token"))
+	if err != nil {
+		if errors.Is(err, services.ErrQueueTokenNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(status)
+}
+
+// Sale Window Handler
+func (h *WaitingRoomHandler) GetWindows(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	windows, err := h.service.GetWindows()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(windows)
+}
+
+func (h *WaitingRoomHandler) CreateWindow(c *fiber.Ctx) error {
+	type createWindowRequest struct {
+		Name             string    `json:"name" validate:"required"`
+		StartsAt         time.Time `json:"starts_at" validate:"required"`
+		EndsAt           time.Time `json:"ends_at" validate:"required"`
+		AdmitPerMinute   int       `json:"admit_per_minute" validate:"required"`
+		AdmissionMinutes int       `json:"admission_minutes"`
+	}
+
+	var req createWindowRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid input",
+		})
+	}
+
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	userID, ok := c.Locals("user").(uint)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or missing user ID",
+		})
+	}
+
+	window, err := h.service.CreateWindow(entities.SaleWindow{
+		Name:             req.Name,
+		StartsAt:         req.StartsAt,
+		EndsAt:           req.EndsAt,
+		AdmitPerMinute:   req.AdmitPerMinute,
+		AdmissionMinutes: req.AdmissionMinutes,
+		ModifyBy:         userID,
+	})
+	if err != nil {
+		if errors.Is(err, interfaces.ErrSaleWindowOverlap) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(window)
+}
+
+func (h *WaitingRoomHandler) DeleteWindow(c *fiber.Ctx) error {
+	role := c.Locals("role").(string)
+	if role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Permission denied",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid sale window ID",
+		})
+	}
+
+	if err := h.service.DeleteWindow(uint(id)); err != nil {
+		if errors.Is(err, interfaces.ErrSaleWindowNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
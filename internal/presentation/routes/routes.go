@@ -38,6 +38,15 @@ func SetupProfileRoutes(app fiber.Router, authHandler *handlers.AuthHandler) {
 	})
 }
 
+func SetupPassengerRoutes(app fiber.Router, passengerHandler *handlers.PassengerHandler) {
+	// Protected routes for the passengers saved on the user's profile
+	passenger := app.Group("/auth/profile/passengers", middleware.JWTMiddleware)
+	passenger.Get("/", passengerHandler.GetPassengers)
+	passenger.Post("/", passengerHandler.CreatePassenger)
+	passenger.Put("/:id", passengerHandler.UpdatePassenger)
+	passenger.Delete("/:id", passengerHandler.DeletePassenger)
+}
+
 func SetupStationRoutes(app fiber.Router, trainHandler *handlers.TrainHandler) {
 
 	// Public station routes (no middleware)
@@ -53,4 +62,182 @@ func SetupStationRoutes(app fiber.Router, trainHandler *handlers.TrainHandler) {
 	auth.Post("/type", trainHandler.CreateStationType)
 	auth.Put("/type", trainHandler.UpdateStationType)
 	auth.Delete("/type", trainHandler.DeleteStationType)
+
+	// Registered after "/type" so the code parameter does not shadow it
+	station.Get("/:code", trainHandler.GetStation)
+}
+
+func SetupTrainRoutes(app fiber.Router, trainHandler *handlers.TrainHandler) {
+	// Public train routes (no middleware)
+	app.Get("/trains", trainHandler.GetTrains)
+	app.Get("/trains/:code", trainHandler.GetTrain)
+	app.Get("/trains/:code/route", trainHandler.GetRoute)
+	app.Get("/trains/:code/timetable", trainHandler.GetTimetable)
+	app.Get("/trains/:code/calendar", trainHandler.GetCalendar)
+	app.Get("/trains/:code/coaches", trainHandler.GetCoaches)
+	app.Get("/train-types", trainHandler.GetTrainTypes)
+	app.Get("/train-lines", trainHandler.GetTrainLines)
+	app.Get("/profit-types", trainHandler.GetTrainProfitTypes)
+
+	// Protected train routes (with middleware)
+	train := app.Group("/auth/trains", middleware.JWTMiddleware)
+	train.Post("/", trainHandler.CreateTrain)
+	train.Put("/:code", trainHandler.UpdateTrain)
+	train.Delete("/:code", trainHandler.DeleteTrain)
+
+	// Protected route (StationOrder) routes
+	train.Post("/:code/route", trainHandler.CreateRoute)
+	train.Put("/:code/route", trainHandler.ReplaceRoute)
+	train.Put("/:code/route/order", trainHandler.ReorderRoute)
+	train.Delete("/:code/route", trainHandler.DeleteRoute)
+
+	// Protected service calendar routes
+	train.Put("/:code/calendar", trainHandler.SaveCalendar)
+	train.Post("/:code/calendar/exceptions", trainHandler.SaveCalendarException)
+	train.Delete("/:code/calendar/exceptions/:date", trainHandler.DeleteCalendarException)
+
+	// Protected coach routes
+	train.Put("/:code/coaches", trainHandler.ReplaceCoaches)
+
+	// Protected train type routes
+	trainType := app.Group("/auth/train-types", middleware.JWTMiddleware)
+	trainType.Post("/", trainHandler.CreateTrainType)
+	trainType.Put("/:code", trainHandler.UpdateTrainType)
+	trainType.Delete("/:code", trainHandler.DeleteTrainType)
+
+	// Protected train line routes
+	trainLine := app.Group("/auth/train-lines", middleware.JWTMiddleware)
+	trainLine.Post("/", trainHandler.CreateTrainLine)
+	trainLine.Put("/:code", trainHandler.UpdateTrainLine)
+	trainLine.Delete("/:code", trainHandler.DeleteTrainLine)
+
+	// Protected profit type routes
+	profitType := app.Group("/auth/profit-types", middleware.JWTMiddleware)
+	profitType.Post("/", trainHandler.CreateTrainProfitType)
+	profitType.Put("/:code", trainHandler.UpdateTrainProfitType)
+	profitType.Delete("/:code", trainHandler.DeleteTrainProfitType)
+}
+
+// SetupBookingRoutes registers the booking routes. Routes that take seats also pass
+// admission, which lets them through during a sale window only with a waiting room
+// admission token. Routes clients may resend pass idempotent, which replays the response
+// to a request resent with the same Idempotency-Key. Admission goes first, so a request
+// turned away by the waiting room does not hold on to its key.
+func SetupBookingRoutes(app fiber.Router, bookingHandler *handlers.BookingHandler, admission, idempotent fiber.Handler) {
+	// Public seat availability route (no middleware)
+	app.Get("/availability", bookingHandler.GetAvailability)
+
+	// Protected booking routes (with middleware)
+	booking := app.Group("/auth/bookings", middleware.JWTMiddleware)
+	booking.Post("/", admission, idempotent, bookingHandler.CreateBooking)
+	booking.Post("/holds", admission, idempotent, bookingHandler.HoldSeats)
+	booking.Get("/", bookingHandler.GetBookings)
+	booking.Get("/:id", bookingHandler.GetBooking)
+	booking.Post("/:id/confirm", idempotent, bookingHandler.ConfirmHold)
+	booking.Post("/:id/cancel", idempotent, bookingHandler.CancelBooking)
+	booking.Put("/:id/status", bookingHandler.UpdateBookingStatus)
+	booking.Get("/:id/history", bookingHandler.GetBookingHistory)
+	booking.Get("/:id/refunds", bookingHandler.GetRefunds)
+}
+
+func SetupTrainRunRoutes(app fiber.Router, trainRunHandler *handlers.TrainRunHandler) {
+	// Public train run routes (no middleware)
+	app.Get("/trains/:code/runs", trainRunHandler.GetRuns)
+	app.Get("/trains/:code/runs/:date", trainRunHandler.GetRun)
+	app.Get("/trains/:code/runs/:date/seats", trainRunHandler.GetSeatMap)
+
+	// Protected train run routes (with middleware)
+	trainRun := app.Group("/auth/train-runs", middleware.JWTMiddleware)
+	trainRun.Post("/generate", trainRunHandler.GenerateRuns)
+	trainRun.Put("/:code/:date/status", trainRunHandler.UpdateRunStatus)
+}
+
+func SetupFareRoutes(app fiber.Router, fareHandler *handlers.FareHandler) {
+	// Public fare routes (no middleware)
+	app.Get("/fares", fareHandler.GetFare)
+	app.Get("/concessions", fareHandler.GetConcessions)
+
+	// Protected concession routes (with middleware)
+	concession := app.Group("/auth/concessions", middleware.JWTMiddleware)
+	concession.Post("/", fareHandler.CreateConcession)
+	concession.Put("/:code", fareHandler.UpdateConcession)
+	concession.Delete("/:code", fareHandler.DeleteConcession)
+}
+
+func SetupJourneyRoutes(app fiber.Router, journeyHandler *handlers.JourneyHandler) {
+	// Public journey planner route (no middleware)
+	app.Get("/journeys", journeyHandler.PlanJourney)
+}
+
+func SetupWaitingRoomRoutes(app fiber.Router, waitingRoomHandler *handlers.WaitingRoomHandler) {
+	// Public waiting room route (no middleware)
+	app.Get("/waiting-room", waitingRoomHandler.GetActiveWindow)
+
+	// Protected waiting room routes (with middleware)
+	waitingRoom := app.Group("/auth/waiting-room", middleware.JWTMiddleware)
+	waitingRoom.Post("/join", waitingRoomHandler.Join)
+	waitingRoom.Get("/:token", waitingRoomHandler.GetStatus)
+
+	// Protected sale window routes
+	saleWindow := app.Group("/auth/sale-windows", middleware.JWTMiddleware)
+	saleWindow.Get("/", waitingRoomHandler.GetWindows)
+	saleWindow.Post("/", waitingRoomHandler.CreateWindow)
+	saleWindow.Delete("/:id", waitingRoomHandler.DeleteWindow)
+}
+
+func SetupPromotionRoutes(app fiber.Router, promotionHandler *handlers.PromotionHandler) {
+	// Protected promotion routes (with middleware)
+	promotion := app.Group("/auth/promotions", middleware.JWTMiddleware)
+	promotion.Get("/", promotionHandler.GetPromotions)
+	promotion.Get("/:code", promotionHandler.GetPromotion)
+	promotion.Post("/", promotionHandler.CreatePromotion)
+	promotion.Put("/:code", promotionHandler.UpdatePromotion)
+	promotion.Delete("/:code", promotionHandler.DeletePromotion)
+}
+
+// SetupPaymentRoutes registers the payment routes. Routes clients may resend pass
+// idempotent, see SetupBookingRoutes.
+func SetupPaymentRoutes(app fiber.Router, paymentHandler *handlers.PaymentHandler, idempotent fiber.Handler) {
+	// Public payment webhook route (no middleware), notifications are signed instead
+	app.Post("/webhooks/payments/:provider", paymentHandler.ReceiveWebhook)
+
+	// Protected payment routes (with middleware)
+	payment := app.Group("/auth/payments", middleware.JWTMiddleware)
+	payment.Post("/", idempotent, paymentHandler.CreatePayment)
+	payment.Get("/", paymentHandler.GetPayments)
+	payment.Get("/:id", paymentHandler.GetPayment)
+	payment.Post("/:id/refresh", paymentHandler.RefreshPayment)
+	payment.Post("/:id/confirm", paymentHandler.ConfirmPayment)
+
+	// Protected payment event routes (with middleware)
+	paymentEvent := app.Group("/auth/payment-events", middleware.JWTMiddleware)
+	paymentEvent.Get("/", paymentHandler.GetWebhookEvents)
+	paymentEvent.Post("/:id/replay", paymentHandler.ReplayWebhookEvent)
+}
+
+func SetupReconciliationRoutes(app fiber.Router, reconciliationHandler *handlers.ReconciliationHandler) {
+	// Protected reconciliation routes (with middleware)
+	reconciliation := app.Group("/auth/reconciliations", middleware.JWTMiddleware)
+	reconciliation.Post("/", reconciliationHandler.ImportSettlement)
+	reconciliation.Get("/", reconciliationHandler.GetReports)
+	reconciliation.Get("/:id", reconciliationHandler.GetReport)
+	reconciliation.Get("/:id/csv", reconciliationHandler.DownloadReport)
+}
+
+func SetupRefundRoutes(app fiber.Router, refundHandler *handlers.RefundHandler) {
+	// Public refund policy route (no middleware)
+	app.Get("/refund-rules", refundHandler.GetRules)
+
+	// Protected refund rule routes (with middleware)
+	refundRule := app.Group("/auth/refund-rules", middleware.JWTMiddleware)
+	refundRule.Post("/", refundHandler.CreateRule)
+	refundRule.Put("/:id", refundHandler.UpdateRule)
+	refundRule.Delete("/:id", refundHandler.DeleteRule)
+
+	// Protected refund routes (with middleware)
+	refund := app.Group("/auth/refunds", middleware.JWTMiddleware)
+	refund.Get("/stuck", refundHandler.GetStuckRefunds)
+	refund.Get("/:id", refundHandler.GetRefund)
+	refund.Post("/:id/retry", refundHandler.RetryRefund)
+	refund.Post("/:id/resolve", refundHandler.ResolveRefund)
 }